@@ -1,12 +1,14 @@
 package cmdlipautoremove
 
 import (
+	"context"
 	"flag"
 	"os"
 
+	cmdlipinstall "github.com/liteldev/lip/cmd/install"
 	cmdlipuninstall "github.com/liteldev/lip/cmd/uninstall"
-	"github.com/liteldev/lip/localfile"
-	"github.com/liteldev/lip/tooth/toothrecord"
+	"github.com/liteldev/lip/lip"
+	"github.com/liteldev/lip/output"
 	"github.com/liteldev/lip/utils/logger"
 )
 
@@ -29,8 +31,6 @@ Options:
   --keep-possession           Keep files that the tooth author specified the tooth to occupy. These files are often configuration files, data files, etc.`
 
 func Run(args []string) {
-	var err error
-
 	flagSet := flag.NewFlagSet("autoremove", flag.ExitOnError)
 
 	flagSet.Usage = func() {
@@ -51,74 +51,23 @@ func Run(args []string) {
 	}
 
 	if flagSet.NArg() != 0 {
-		logger.Error("Too many arguments.")
-		os.Exit(1)
+		output.Fail("Too many arguments.")
 	}
 
-	logger.Info("Discovering tooths not depended by any other tooths...")
-
-	// 1. Gets all installed tooths.
-	recordList, err := toothrecord.ListAll()
+	result, err := lip.Autoremove(context.Background(), lip.AutoremoveOptions{
+		Options: lip.Options{
+			Prompt: cmdlipinstall.TerminalPrompt,
+			Stdin:  os.Stdin,
+			Stdout: output.CommandStdout(),
+			Stderr: os.Stderr,
+		},
+		KeepPossession: flagDict.keepPossessionFlag,
+	})
 	if err != nil {
-		logger.Error(err.Error())
-		os.Exit(1)
-	}
-
-	// 2. Marks all manually installed tooths.
-	toothsToKeep := make(map[string]bool)
-	for _, record := range recordList {
-		if record.IsManuallyInstalled {
-			toothsToKeep[record.ToothPath] = true
-		}
-	}
-
-	// 3. Marks all tooths that are depended by other tooths.
-	markCount := 0
-	for {
-		for _, record := range recordList {
-			if !toothsToKeep[record.ToothPath] {
-				continue
-			}
-
-			for dep := range record.Dependencies {
-				if !toothsToKeep[dep] {
-					toothsToKeep[dep] = true
-					markCount++
-					break
-				}
-			}
-		}
-
-		if markCount == 0 {
-			break
-		}
-
-		markCount = 0
-	}
-
-	logger.Info("Uninstalling tooths not depended by any other tooths...")
-
-	// 4. Uninstalls all unmarked tooths.
-	for _, record := range recordList {
-		if toothsToKeep[record.ToothPath] {
-			continue
-		}
-
-		logger.Info("  Uninstalling " + record.ToothPath + "...")
-
-		possessionList := make([]string, 0)
-		if flagDict.keepPossessionFlag {
-			possessionList = record.Possession
-		}
-
-		recordFileName := localfile.GetRecordFileName(record.ToothPath)
-
-		err = cmdlipuninstall.Uninstall(recordFileName, possessionList, flagDict.yesFlag)
-		if err != nil {
-			logger.Error(err.Error())
-			os.Exit(1)
-		}
+		output.Fail(err.Error())
 	}
 
-	logger.Info("Successfully uninstalled all tooths not depended by any other tooths.")
+	output.Print(map[string]interface{}{
+		"uninstalled": cmdlipuninstall.UninstalledList(result),
+	})
 }
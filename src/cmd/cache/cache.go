@@ -2,9 +2,9 @@ package cmdlipcache
 
 import (
 	"flag"
-	"os"
 
 	cmdlipcachepurge "github.com/liteldev/lip/cmd/cache/purge"
+	"github.com/liteldev/lip/output"
 	"github.com/liteldev/lip/utils/logger"
 )
 
@@ -48,11 +48,11 @@ func Run(args []string) {
 	if flagSet.NArg() >= 1 {
 		switch flagSet.Arg(0) {
 		case "purge":
+			output.SetCommand("cache purge")
 			cmdlipcachepurge.Run(args[1:])
 			return
 		default:
-			logger.Error("Unknown command.")
-			os.Exit(1)
+			output.Fail("Unknown command.")
 		}
 	}
 
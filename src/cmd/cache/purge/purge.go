@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/liteldev/lip/localfile"
+	"github.com/liteldev/lip/output"
 	"github.com/liteldev/lip/utils/logger"
 )
 
@@ -48,16 +49,15 @@ func Run(args []string) {
 	if flagSet.NArg() == 0 {
 		err := purgeCache()
 		if err != nil {
-			logger.Error(err.Error())
-			os.Exit(1)
+			output.Fail(err.Error())
 		}
 		logger.Info("Cache has been purged successfully.")
+		output.Print(map[string]interface{}{})
 		return
 	}
 
 	// Otherwise, report an error.
-	logger.Error("Too many arguments.")
-	os.Exit(1)
+	output.Fail("Too many arguments.")
 }
 
 // purgeCache removes all items from the cache.
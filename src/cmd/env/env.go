@@ -0,0 +1,153 @@
+package cmdlipenv
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/liteldev/lip/output"
+	"github.com/liteldev/lip/tooth/toothshim"
+	"github.com/liteldev/lip/utils/logger"
+)
+
+// FlagDict is a dictionary of flags.
+type FlagDict struct {
+	helpFlag  bool
+	shellFlag string
+}
+
+const helpMessage = `
+Usage:
+  lip env [options]
+
+Description:
+  Print a shell snippet that puts the shims of tools in .lip/bin of the
+  workspace and ~/.lip/bin on PATH. Evaluate it in the shell, e.g.:
+
+    bash/zsh:    eval "$(lip env)"
+    fish:        lip env --shell fish | source
+    PowerShell:  lip env --shell powershell | Invoke-Expression
+
+Options:
+  -h, --help                  Show help.
+  --shell <shell>             The shell to print the snippet for: bash, zsh, fish or powershell. Detected from SHELL by default.`
+
+// Run is the entry point.
+func Run(args []string) {
+	flagSet := flag.NewFlagSet("env", flag.ExitOnError)
+
+	// Rewrite the default usage message.
+	flagSet.Usage = func() {
+		logger.Info(helpMessage)
+	}
+
+	var flagDict FlagDict
+	flagSet.BoolVar(&flagDict.helpFlag, "help", false, "")
+	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
+	flagSet.StringVar(&flagDict.shellFlag, "shell", "", "")
+	flagSet.Parse(args)
+
+	// Help flag has the highest priority.
+	if flagDict.helpFlag {
+		logger.Info(helpMessage)
+		return
+	}
+
+	if flagSet.NArg() > 0 {
+		output.Fail("Too many arguments.")
+	}
+
+	shell := flagDict.shellFlag
+	if shell == "" {
+		shell = detectShell()
+	}
+
+	// Make sure that shims of tools installed before exist.
+	err := toothshim.Sync()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	binDirList, err := toothshim.BinDirList()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	snippet, err := pathSnippet(shell, binDirList)
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	if output.IsMachineReadable() {
+		output.Print(map[string]interface{}{
+			"shell":    shell,
+			"bin_dirs": binDirList,
+			"snippet":  snippet,
+		})
+		return
+	}
+
+	// The snippet is printed to stdout even with --quiet.
+	fmt.Println(snippet)
+}
+
+// detectShell detects the shell from the SHELL environment variable. On
+// Windows, PowerShell is assumed.
+func detectShell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return strings.TrimSuffix(filepath.Base(shell), ".exe")
+	}
+
+	if runtime.GOOS == "windows" {
+		return "powershell"
+	}
+
+	return "sh"
+}
+
+// pathSnippet returns a snippet for the shell that prepends the directories to
+// PATH. Directories are quoted, so that they are taken literally.
+func pathSnippet(shell string, dirList []string) (string, error) {
+	switch shell {
+	case "bash", "zsh", "sh", "dash", "ksh":
+		quotedDirList := make([]string, len(dirList))
+		for i, dir := range dirList {
+			quotedDirList[i] = quoteForSh(dir)
+		}
+		return "export PATH=" + strings.Join(quotedDirList, ":") + ":\"$PATH\"", nil
+
+	case "fish":
+		quotedDirList := make([]string, len(dirList))
+		for i, dir := range dirList {
+			quotedDirList[i] = quoteForFish(dir)
+		}
+		return "set -gx PATH " + strings.Join(quotedDirList, " ") + " $PATH", nil
+
+	case "powershell", "pwsh":
+		return "$env:PATH = " + quoteForPowerShell(strings.Join(dirList, string(os.PathListSeparator))) + " + " +
+			"[IO.Path]::PathSeparator + $env:PATH", nil
+	}
+
+	return "", errors.New("unsupported shell: " + shell + ". Use bash, zsh, fish or powershell")
+}
+
+// quoteForSh quotes a string in single quotes for POSIX shells.
+func quoteForSh(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "'\\''") + "'"
+}
+
+// quoteForFish quotes a string in single quotes for fish, where only backslashes
+// and single quotes are escaped with a backslash.
+func quoteForFish(s string) string {
+	return "'" + strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(s) + "'"
+}
+
+// quoteForPowerShell quotes a string in single quotes for PowerShell, where
+// single quotes, including the curly ones, are escaped by doubling them.
+func quoteForPowerShell(s string) string {
+	return "'" + strings.NewReplacer("'", "''", "\u2018", "\u2018\u2018", "\u2019", "\u2019\u2019").Replace(s) + "'"
+}
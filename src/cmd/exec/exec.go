@@ -4,28 +4,46 @@ import (
 	"flag"
 	"os"
 	"os/exec"
-	"runtime"
+	"os/signal"
+	"path/filepath"
+	"sort"
 	"strings"
+	"syscall"
 
+	"github.com/liteldev/lip/localfile"
+	"github.com/liteldev/lip/output"
 	"github.com/liteldev/lip/tooth/toothrecord"
 	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/utils/platform"
 )
 
 type FlagDict struct {
 	helpFlag bool
 	listFlag bool
+	cwdFlag  string
 }
 
 const helpMessage = `
 Usage:
-  lip exec [options] <tool> [args...]
+  lip exec [options] <tool>[:<subcommand>] [args...]
 
 Description:
-  Execute a Lip tool.
+  Execute a Lip tool, or a subcommand of it, and exit with its exit code. If
+  several tools have the same name, call one of them by <owner>/<tool>, where
+  owner is the element of the tooth path before the last one. The tool runs in
+  the workspace with these environment variables set:
+
+    LIP_WORKSPACE             The directory of the workspace.
+    LIP_TOOL_NAME             The name of the tool.
+    LIP_TOOL_DIR              The workspace that the tool is installed in, which
+                              entrypoints are relative to.
+
+  SIGINT and SIGTERM are forwarded to the tool.
 
 Options:
   -h, --help                  Show help.
-  --list					  List all available tools.`
+  --list                      List all available tools and their subcommands.
+  --cwd <dir>                 Run the tool in the directory instead of the workspace. Relative to the current directory.`
 
 func Run(args []string) {
 	flagSet := flag.NewFlagSet("exec", flag.ExitOnError)
@@ -39,6 +57,7 @@ func Run(args []string) {
 	flagSet.BoolVar(&flagDict.helpFlag, "help", false, "")
 	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
 	flagSet.BoolVar(&flagDict.listFlag, "list", false, "")
+	flagSet.StringVar(&flagDict.cwdFlag, "cwd", "", "")
 	flagSet.Parse(args)
 
 	// Help flag has the highest priority.
@@ -49,8 +68,7 @@ func Run(args []string) {
 
 	if flagDict.listFlag {
 		if flagSet.NArg() > 0 {
-			logger.Error("too many arguments.")
-			os.Exit(1)
+			output.Fail("too many arguments.")
 		}
 
 		ListTools()
@@ -59,105 +77,282 @@ func Run(args []string) {
 
 	// The tool name should not be empty.
 	if len(flagSet.Args()) == 0 {
-		logger.Error("missing tool name.")
-		os.Exit(1)
+		output.Fail("missing tool name.")
+	}
+
+	// The tool runs in the workspace unless --cwd is given, which is relative
+	// to the directory where Lip was started.
+	cwd, err := localfile.WorkspaceDir()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	if flagDict.cwdFlag != "" {
+		workingDir, err := localfile.WorkingDir()
+		if err != nil {
+			output.Fail(err.Error())
+		}
+
+		cwd = flagDict.cwdFlag
+		if !filepath.IsAbs(cwd) {
+			cwd = filepath.Join(workingDir, cwd)
+		}
+
+		if fileInfo, err := os.Stat(cwd); err != nil || !fileInfo.IsDir() {
+			output.Fail("the working directory does not exist: " + cwd)
+		}
 	}
 
 	// Run the tool.
-	RunTool(flagSet.Args())
+	RunTool(flagSet.Args(), cwd)
 }
 
-// ListTools lists all available tools.
+// ListTools lists all available tools in the workspace and the global
+// workspace.
 func ListTools() {
-	var err error
-
-	recordList, err := toothrecord.ListAll()
-	if err != nil {
-		logger.Error(err.Error())
-		os.Exit(1)
+	type toolInfoType struct {
+		name        string
+		description string
+		scope       string
 	}
 
-	// Remove tooths that is not a tool.
-	for i := 0; i < len(recordList); i++ {
-		if !recordList[i].IsTool() {
-			recordList = append(recordList[:i], recordList[i+1:]...)
-			i--
+	toolInfoList := make([]toolInfoType, 0)
+	isListed := make(map[string]bool)
+
+	for _, scope := range []string{"workspace", "global"} {
+		recordList, err := listRecords(scope)
+		if err != nil {
+			output.Fail(err.Error())
+		}
+
+		for _, record := range recordList {
+			if !record.IsTool() {
+				continue
+			}
+
+			// Tools in the workspace take precedence over global tools with
+			// the same name, which can still be called by owner/tool.
+			name := toothrecord.ToolName(record, recordList)
+			if isListed[name] {
+				name = record.QualifiedToolName()
+				if isListed[name] {
+					continue
+				}
+			}
+			isListed[name] = true
+
+			toolInfoList = append(toolInfoList, toolInfoType{name, record.Tool.Description, scope})
+
+			subcommandNameList := make([]string, 0, len(record.Tool.Subcommands))
+			for subcommandName := range record.Tool.Subcommands {
+				subcommandNameList = append(subcommandNameList, subcommandName)
+			}
+			sort.Strings(subcommandNameList)
+
+			for _, subcommandName := range subcommandNameList {
+				toolInfoList = append(toolInfoList, toolInfoType{
+					name + ":" + subcommandName,
+					record.Tool.Subcommands[subcommandName].Description,
+					scope,
+				})
+			}
 		}
 	}
 
 	// Print table
 	longestNameLength := 10        // The minimum length of the column.
 	longestDescriptionLength := 20 // The minimum length of the column.
-	for _, record := range recordList {
-		if len(record.Tool.Name) > longestNameLength {
-			longestNameLength = len(record.Tool.Name)
+	for _, toolInfo := range toolInfoList {
+		if len(toolInfo.name) > longestNameLength {
+			longestNameLength = len(toolInfo.name)
 		}
-		if len(record.Tool.Description) > longestDescriptionLength {
-			longestDescriptionLength = len(record.Tool.Description)
+		if len(toolInfo.description) > longestDescriptionLength {
+			longestDescriptionLength = len(toolInfo.description)
 		}
 	}
 
 	// Print header
-	logger.Info("Name" + strings.Repeat(" ", longestNameLength-4) + " Description")
+	logger.Info("Name" + strings.Repeat(" ", longestNameLength-4) +
+		" Description" + strings.Repeat(" ", longestDescriptionLength-11) + " Scope")
 	logger.Info(strings.Repeat("-", longestNameLength) + " " +
-		strings.Repeat("-", longestDescriptionLength))
+		strings.Repeat("-", longestDescriptionLength) + " " + strings.Repeat("-", 9))
 
 	// Print tools
-	for _, record := range recordList {
-		logger.Info(record.Tool.Name +
-			strings.Repeat(" ", longestNameLength-len(record.Tool.Name)) + " " +
-			record.Tool.Description)
+	for _, toolInfo := range toolInfoList {
+		logger.Info(toolInfo.name +
+			strings.Repeat(" ", longestNameLength-len(toolInfo.name)) + " " +
+			toolInfo.description +
+			strings.Repeat(" ", longestDescriptionLength-len(toolInfo.description)) + " " +
+			toolInfo.scope)
+	}
+
+	outputList := make([]interface{}, 0, len(toolInfoList))
+	for _, toolInfo := range toolInfoList {
+		outputList = append(outputList, map[string]interface{}{
+			"name":        toolInfo.name,
+			"description": toolInfo.description,
+			"scope":       toolInfo.scope,
+		})
 	}
+	output.Print(outputList)
 }
 
-// RunTool runs a tool.
-func RunTool(args []string) {
+// RunTool runs a tool, or a subcommand of it given as tool:subcommand, in the
+// working directory cwd and exits with its exit code. Tools in the workspace
+// are searched first, and then tools in the global workspace. Entrypoints are
+// relative to the workspace that the tool is installed in.
+func RunTool(args []string, cwd string) {
 	var err error
 
-	toolName := args[0]
-	toolArgs := args[1:]
-
-	recordList, err := toothrecord.ListAll()
-	if err != nil {
-		logger.Error(err.Error())
-		os.Exit(1)
+	toolName, subcommand := args[0], ""
+	if index := strings.Index(toolName, ":"); index != -1 {
+		toolName, subcommand = toolName[:index], toolName[index+1:]
 	}
+	toolArgs := args[1:]
 
-	toolPath := ""
-FindCorrectToolPath:
-	for _, record := range recordList {
-		if !record.IsTool() || record.Tool.Name != toolName {
-			continue
+	var toolRecord toothrecord.Record
+	toolScope := ""
+	for _, scope := range []string{"workspace", "global"} {
+		recordList, err := listRecords(scope)
+		if err != nil {
+			output.Fail(err.Error())
 		}
 
-		for _, entrypoint := range record.Tool.Entrypoints {
-			if entrypoint.GOOS != runtime.GOOS {
-				continue
+		matchedRecordList := make([]toothrecord.Record, 0)
+		for _, record := range recordList {
+			if record.MatchToolName(toolName) {
+				matchedRecordList = append(matchedRecordList, record)
 			}
+		}
 
-			if entrypoint.GOARCH != "" && entrypoint.GOARCH != runtime.GOARCH {
-				continue
+		if len(matchedRecordList) > 1 {
+			qualifiedNameList := make([]string, 0, len(matchedRecordList))
+			for _, record := range matchedRecordList {
+				qualifiedNameList = append(qualifiedNameList, record.QualifiedToolName())
 			}
+			output.Fail("the tool name " + toolName + " is ambiguous. Use one of: " +
+				strings.Join(qualifiedNameList, ", "))
+		}
 
-			toolPath = entrypoint.Path
-			break FindCorrectToolPath
+		if len(matchedRecordList) == 1 {
+			toolRecord = matchedRecordList[0]
+			toolScope = scope
+			break
 		}
 	}
 
-	if toolPath == "" {
-		logger.Error("tool not found.")
-		os.Exit(1)
+	if toolScope == "" {
+		output.Fail("tool not found.")
+	}
+
+	if _, ok := toolRecord.Tool.Subcommands[subcommand]; subcommand != "" && !ok {
+		output.Fail("the tool " + toolName + " has no subcommand " + subcommand + ".")
+	}
+
+	// Entrypoints are selected by the platform that the tool was installed for.
+	goos, goarch := toolRecord.Platform()
+
+	if !platform.IsNativePlatform(goos, goarch) {
+		output.Fail("the tool " + toolName + " is installed for " + goos + "/" + goarch +
+			" and cannot run on " + platform.Native() + ".")
+	}
+
+	entrypointPath, ok := toolRecord.ToolEntrypoint(subcommand, goos, goarch)
+	if !ok {
+		output.Fail("the tool " + args[0] + " has no entrypoint for " + goos + "/" + goarch + ".")
+	}
+
+	// The tool is installed in the workspace or the global workspace.
+	toolDir, err := scopeDir(toolScope)
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	toolPath := filepath.FromSlash(entrypointPath)
+	if !filepath.IsAbs(toolPath) {
+		toolPath = filepath.Join(toolDir, toolPath)
+	}
+
+	workspaceDir, err := localfile.WorkspaceDir()
+	if err != nil {
+		output.Fail(err.Error())
 	}
 
 	// Run the tool.
+	logger.With("command", "exec", "tool", toolRecord.QualifiedToolName(), "tooth", toolRecord.ToothPath,
+		"version", toolRecord.Version.String(), "scope", toolScope).Debug("Running " + toolPath + "...")
+
 	cmd := exec.Command(toolPath, toolArgs...)
+	cmd.Dir = cwd
+	cmd.Env = append(os.Environ(),
+		"LIP_WORKSPACE="+workspaceDir,
+		"LIP_TOOL_NAME="+toolRecord.Tool.Name,
+		"LIP_TOOL_DIR="+toolDir,
+	)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
 
-	err = cmd.Run()
+	RunAndExit(cmd)
+}
+
+// RunAndExit runs the command and exits with its exit code. SIGINT and SIGTERM
+// are forwarded to the command.
+func RunAndExit(cmd *exec.Cmd) {
+	err := cmd.Start()
 	if err != nil {
-		logger.Error("failed to run the tool: %s", err.Error())
-		os.Exit(1)
+		output.Fail("failed to run %s: %s", cmd.Path, err.Error())
 	}
+
+	// Forward signals to the command, and let it decide when to exit.
+	signalChannel := make(chan os.Signal, 1)
+	signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for sig := range signalChannel {
+			cmd.Process.Signal(sig)
+		}
+	}()
+
+	err = cmd.Wait()
+	signal.Stop(signalChannel)
+	close(signalChannel)
+
+	if exitError, ok := err.(*exec.ExitError); ok {
+		// Exit like a shell if the command is killed by a signal.
+		if status, ok := exitError.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			os.Exit(128 + int(status.Signal()))
+		}
+
+		os.Exit(exitError.ExitCode())
+	} else if err != nil {
+		output.Fail("failed to run %s: %s", cmd.Path, err.Error())
+	}
+}
+
+// listRecords lists the records of the workspace or the global workspace.
+func listRecords(scope string) ([]toothrecord.Record, error) {
+	if scope == "workspace" {
+		return toothrecord.ListAll()
+	}
+
+	globalDir, err := scopeDir(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	recordDir := filepath.Join(globalDir, ".lip", "records")
+	if _, err := os.Stat(recordDir); os.IsNotExist(err) {
+		return make([]toothrecord.Record, 0), nil
+	}
+
+	return toothrecord.ListAllInDir(recordDir)
+}
+
+// scopeDir returns the directory of the workspace or the global workspace.
+func scopeDir(scope string) (string, error) {
+	if scope == "workspace" {
+		return localfile.WorkspaceDir()
+	}
+
+	return localfile.GlobalDir()
 }
@@ -0,0 +1,29 @@
+package cmdlipinstall
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/liteldev/lip/lip"
+)
+
+// TerminalPrompt asks the prompt in the terminal and reads the answer from the
+// standard input. An empty answer takes the default. The prompt is written to
+// the standard error directly, so that it is shown regardless of the logging
+// level and format and does not mix with JSON output on the standard output.
+func TerminalPrompt(ctx context.Context, prompt lip.Prompt) (bool, error) {
+	if prompt.Default {
+		fmt.Fprint(os.Stderr, prompt.Message+" (Y/n) ")
+	} else {
+		fmt.Fprint(os.Stderr, prompt.Message+" (y/N) ")
+	}
+
+	var ans string
+	fmt.Scanln(&ans)
+	if ans == "" {
+		return prompt.Default, nil
+	}
+
+	return ans == "y" || ans == "Y", nil
+}
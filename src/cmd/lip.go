@@ -6,17 +6,26 @@ import (
 	"os"
 	"path/filepath"
 
-	"github.com/liteldev/lip/context"
+	lipcontext "github.com/liteldev/lip/context"
+	"github.com/liteldev/lip/localfile"
+	"github.com/liteldev/lip/output"
 	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/utils/platform"
+	"github.com/liteldev/lip/workspace"
 
 	cmdlipautoremove "github.com/liteldev/lip/cmd/autoremove"
 	cmdlipcache "github.com/liteldev/lip/cmd/cache"
+	cmdlipenv "github.com/liteldev/lip/cmd/env"
 	cmdlipexec "github.com/liteldev/lip/cmd/exec"
 	cmdlipinstall "github.com/liteldev/lip/cmd/install"
 	cmdliplist "github.com/liteldev/lip/cmd/list"
+	cmdlipplatform "github.com/liteldev/lip/cmd/platform"
+	cmdliprun "github.com/liteldev/lip/cmd/run"
+	cmdlipself "github.com/liteldev/lip/cmd/self"
 	cmdlipshow "github.com/liteldev/lip/cmd/show"
 	cmdliptooth "github.com/liteldev/lip/cmd/tooth"
 	cmdlipuninstall "github.com/liteldev/lip/cmd/uninstall"
+	cmdlipworkspaces "github.com/liteldev/lip/cmd/workspaces"
 )
 
 // FlagDict is a dictionary of flags.
@@ -25,6 +34,13 @@ type FlagDict struct {
 	versionFlag bool
 	verboseFlag bool
 	quietFlag   bool
+	targetOS    string
+	targetArch  string
+	workspace   string
+	logFormat   string
+	logFile     string
+	color       string
+	format      string
 }
 
 const helpMessage = `
@@ -34,25 +50,37 @@ Usage:
 Commands:
   autoremove                  Uninstall tooths that are not depended by any other tooths.
   cache                       Inspect and manage Lip's cache.
+  env                         Print a shell snippet to put shims of tools on PATH.
   exec                        Execute a Lip tool.
   install                     Install a tooth.
   list                        List installed tooths.
+  platform                    Show or set the target platform of the workspace.
+  run                         Run a script of the workspace.
+  self                        Update Lip and show its version.
   show                        Show information about installed tooths.
   tooth                       Maintain a tooth.
   uninstall                   Uninstall a tooth.
+  workspaces                  Manage known workspaces.
 
 Options:
   -h, --help                  Show help.
   -V, --version               Show version and exit.
   -v, --verbose               Show verbose output.
-  -q, --quiet                 Show only errors.`
+  -q, --quiet                 Show only errors.
+  --target-os <GOOS>          Install and uninstall tooths for the GOOS instead of the workspace platform.
+  --target-arch <GOARCH>      Install and uninstall tooths for the GOARCH instead of the workspace platform.
+  --workspace <dir>           Use the workspace in the directory instead of the nearest one.
+  --log-format <format>       Print messages as text or json (one JSON object per line). Default: text.
+  --log-file <path>           Also write messages of all levels to the file, rotated at 10 MiB.
+  --color <when>              Color messages: auto, always or never. Default: auto.
+  --format <format>           Print the result of the command as table, json or yaml. Default: table.`
 
 const versionMessage = "Lip %s from %s"
 
 // Run is the entry point of the lip command.
 func Run(args []string) {
 	// Initialize context
-	context.Init()
+	lipcontext.Init()
 
 	flagSet := flag.NewFlagSet("lip", flag.ExitOnError)
 
@@ -71,8 +99,61 @@ func Run(args []string) {
 	flagSet.BoolVar(&flagDict.verboseFlag, "v", false, "")
 	flagSet.BoolVar(&flagDict.quietFlag, "quiet", false, "")
 	flagSet.BoolVar(&flagDict.quietFlag, "q", false, "")
+	flagSet.StringVar(&flagDict.targetOS, "target-os", "", "")
+	flagSet.StringVar(&flagDict.targetArch, "target-arch", "", "")
+	// The workspace is selected in main.go before the redirection.
+	flagSet.StringVar(&flagDict.workspace, "workspace", "", "")
+	flagSet.StringVar(&flagDict.logFormat, "log-format", "text", "")
+	flagSet.StringVar(&flagDict.logFile, "log-file", "", "")
+	flagSet.StringVar(&flagDict.color, "color", "auto", "")
+	flagSet.StringVar(&flagDict.format, "format", "table", "")
 	flagSet.Parse(args)
 
+	// Set the output of messages before anything is printed.
+	outputFormat, err := output.ParseFormat(flagDict.format)
+	if err != nil {
+		output.Fail(err.Error())
+	}
+	output.SetFormat(outputFormat)
+
+	switch flagDict.color {
+	case "auto":
+		logger.SetColorMode(logger.ColorAuto)
+	case "always":
+		logger.SetColorMode(logger.ColorAlways)
+	case "never":
+		logger.SetColorMode(logger.ColorNever)
+	default:
+		output.Fail("Unknown color mode: %s. Use auto, always or never", flagDict.color)
+	}
+
+	switch flagDict.logFormat {
+	case "text":
+		logger.SetFormat(logger.TextFormat)
+	case "json":
+		logger.SetFormat(logger.JSONFormat)
+	default:
+		output.Fail("Unknown log format: %s. Use text or json", flagDict.logFormat)
+	}
+
+	if flagDict.logFile != "" {
+		// The current directory is the workspace by now, but a relative path
+		// is given relative to the directory where Lip was started.
+		logFilePath := flagDict.logFile
+		if !filepath.IsAbs(logFilePath) {
+			workingDir, err := localfile.WorkingDir()
+			if err != nil {
+				output.Fail(err.Error())
+			}
+			logFilePath = filepath.Join(workingDir, logFilePath)
+		}
+
+		err = logger.SetLogFile(logFilePath)
+		if err != nil {
+			output.Fail(err.Error())
+		}
+	}
+
 	// Help flag has the highest priority.
 	if flagDict.helpFlag {
 		logger.Info(helpMessage)
@@ -82,14 +163,17 @@ func Run(args []string) {
 	// Version flag has the second highest priority.
 	if flagDict.versionFlag {
 		exPath, _ := filepath.Abs(os.Args[0])
-		logger.Info(versionMessage, context.Version.String(), exPath)
+		logger.Info(versionMessage, lipcontext.Version.String(), exPath)
+		output.Print(map[string]interface{}{
+			"version": lipcontext.Version.String(),
+			"path":    exPath,
+		})
 		return
 	}
 
 	// Verbose and quiet flags are mutually exclusive.
 	if flagDict.verboseFlag && flagDict.quietFlag {
-		logger.Error("Verbose and quiet flags are mutually exclusive")
-		os.Exit(1)
+		output.Fail("Verbose and quiet flags are mutually exclusive")
 	}
 
 	// Set logging level.
@@ -101,44 +185,92 @@ func Run(args []string) {
 		logger.SetLevel(logger.InfoLevel)
 	}
 
+	// Set the target platform. Flags take precedence over the platform of the
+	// workspace.
+	workspaceConfig, err := workspace.LoadConfig()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+	platform.SetDefaultTarget(workspaceConfig.TargetOS, workspaceConfig.TargetArch)
+
+	if flagDict.targetOS != "" && !platform.IsKnownGOOS(flagDict.targetOS) {
+		output.Fail("Unknown GOOS: %s", flagDict.targetOS)
+	}
+	if flagDict.targetArch != "" && !platform.IsKnownGOARCH(flagDict.targetArch) {
+		output.Fail("Unknown GOARCH: %s", flagDict.targetArch)
+	}
+	platform.SetTarget(flagDict.targetOS, flagDict.targetArch)
+
 	// If there is a subcommand, run it and exit.
 	if flagSet.NArg() >= 1 {
 		switch flagSet.Arg(0) {
 		case "autoremove":
+			output.SetCommand("autoremove")
 			cmdlipautoremove.Run(flagSet.Args()[1:])
 			return
 
 		case "cache":
+			output.SetCommand("cache")
 			cmdlipcache.Run(flagSet.Args()[1:])
 			return
 
+		case "env":
+			output.SetCommand("env")
+			cmdlipenv.Run(flagSet.Args()[1:])
+			return
+
 		case "exec", "x":
+			output.SetCommand("exec")
 			cmdlipexec.Run(flagSet.Args()[1:])
 			return
 
 		case "install", "i", "add":
+			output.SetCommand("install")
 			cmdlipinstall.Run(flagSet.Args()[1:])
 			return
 
 		case "list", "ls":
+			output.SetCommand("list")
 			cmdliplist.Run(flagSet.Args()[1:])
 			return
 
+		case "platform":
+			output.SetCommand("platform")
+			cmdlipplatform.Run(flagSet.Args()[1:])
+			return
+
+		case "run":
+			output.SetCommand("run")
+			cmdliprun.Run(flagSet.Args()[1:])
+			return
+
+		case "self":
+			output.SetCommand("self")
+			cmdlipself.Run(flagSet.Args()[1:])
+			return
+
 		case "show", "view", "v", "info":
+			output.SetCommand("show")
 			cmdlipshow.Run(flagSet.Args()[1:])
 			return
 
 		case "tooth":
+			output.SetCommand("tooth")
 			cmdliptooth.Run(flagSet.Args()[1:])
 			return
 
 		case "uninstall", "un", "remove", "rm", "r":
+			output.SetCommand("uninstall")
 			cmdlipuninstall.Run(flagSet.Args()[1:])
 			return
 
+		case "workspaces":
+			output.SetCommand("workspaces")
+			cmdlipworkspaces.Run(flagSet.Args()[1:])
+			return
+
 		default:
-			logger.Error("Unknown command: lip %s", flagSet.Arg(0))
-			os.Exit(1)
+			output.Fail("Unknown command: lip %s", flagSet.Arg(0))
 		}
 	}
 
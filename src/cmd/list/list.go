@@ -1,16 +1,13 @@
 package cmdliplist
 
 import (
-	"encoding/json"
+	"context"
 	"flag"
-	"fmt"
-	"os"
 	"strings"
 
-	"github.com/liteldev/lip/specifiers"
-	"github.com/liteldev/lip/tooth/toothrecord"
+	"github.com/liteldev/lip/lip"
+	"github.com/liteldev/lip/output"
 	"github.com/liteldev/lip/utils/logger"
-	"github.com/liteldev/lip/utils/versions"
 )
 
 // FlagDict is a dictionary of flags.
@@ -18,6 +15,7 @@ type FlagDict struct {
 	helpFlag       bool
 	upgradableFlag bool
 	jsonFlag       bool
+	globalFlag     bool
 }
 
 const helpMessage = `
@@ -30,7 +28,8 @@ Description:
 Options:
   -h, --help                  Show help.
   --upgradable                List upgradable tooths.
-  --json                      Output in JSON format. (cannot be hidden with "--quiet")`
+  --json                      Same as "lip --format json list".
+  -g, --global                List tooths in the global workspace in ~/.lip/global.`
 
 // Run is the entry point.
 func Run(args []string) {
@@ -46,6 +45,8 @@ func Run(args []string) {
 	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
 	flagSet.BoolVar(&flagDict.upgradableFlag, "upgradable", false, "")
 	flagSet.BoolVar(&flagDict.jsonFlag, "json", false, "")
+	flagSet.BoolVar(&flagDict.globalFlag, "global", false, "")
+	flagSet.BoolVar(&flagDict.globalFlag, "g", false, "")
 	flagSet.Parse(args)
 
 	// Help flag has the highest priority.
@@ -54,38 +55,46 @@ func Run(args []string) {
 		return
 	}
 
+	// The json flag is kept for compatibility.
+	if flagDict.jsonFlag {
+		output.SetFormat(output.JSONFormat)
+	}
+
 	if flagSet.NArg() > 0 {
-		logger.Error("Too many arguments.")
-		os.Exit(1)
+		output.Fail("Too many arguments.")
+	}
+
+	itemList, err := lip.List(context.Background(), lip.ListOptions{
+		Options: lip.Options{
+			// Tools installed globally live in the global workspace.
+			Global: flagDict.globalFlag,
+		},
+		Upgradable: flagDict.upgradableFlag,
+	})
+	if err != nil {
+		output.Fail(err.Error())
 	}
 
 	if flagDict.upgradableFlag {
 		// List upgradable tooths.
-		listUpgradableTooths(flagDict.jsonFlag)
+		listUpgradableTooths(itemList)
 	} else {
 		// List installed tooths.
-		listInstalledTooths(flagDict.jsonFlag)
+		listInstalledTooths(itemList)
 	}
 }
 
 // listInstalledTooths lists installed tooths.
-func listInstalledTooths(isJSON bool) {
-	// Get the sorted list of records.
-	recordList, err := toothrecord.ListAll()
-	if err != nil {
-		logger.Error(err.Error())
-		os.Exit(1)
-	}
-
+func listInstalledTooths(itemList []lip.ListItem) {
 	// Print table.
 	longestToothPath := 20     // The mininum length
 	longestVersionString := 10 // The mininum length
-	for _, record := range recordList {
-		if len(record.ToothPath) > longestToothPath {
-			longestToothPath = len(record.ToothPath)
+	for _, item := range itemList {
+		if len(item.Record.ToothPath) > longestToothPath {
+			longestToothPath = len(item.Record.ToothPath)
 		}
-		if len(record.Version.String()) > longestVersionString {
-			longestVersionString = len(record.Version.String())
+		if len(item.Record.Version.String()) > longestVersionString {
+			longestVersionString = len(item.Record.Version.String())
 		}
 	}
 
@@ -95,74 +104,40 @@ func listInstalledTooths(isJSON bool) {
 		strings.Repeat("-", longestVersionString))
 
 	// Print records.
-	for _, record := range recordList {
-		logger.Info(record.ToothPath + strings.Repeat(" ", longestToothPath-len(record.ToothPath)) +
-			" " + record.Version.String())
+	for _, item := range itemList {
+		logger.Info(item.Record.ToothPath + strings.Repeat(" ", longestToothPath-len(item.Record.ToothPath)) +
+			" " + item.Record.Version.String())
 	}
 
-	if isJSON {
-		// Print JSON.
-		var outputMap = make([]interface{}, 0)
-		for _, record := range recordList {
-			outputMap = append(outputMap, map[string]interface{}{
-				"tooth":   record.ToothPath,
-				"version": record.Version.String(),
-				"information": map[string]interface{}{
-					"name":        record.Information.Name,
-					"description": record.Information.Description,
-					"author":      record.Information.Author,
-					"license":     record.Information.License,
-					"homepage":    record.Information.Homepage,
-				},
-			})
-		}
-		outputJson, _ := json.Marshal(outputMap)
-		fmt.Println(string(outputJson))
+	var outputList = make([]interface{}, 0)
+	for _, item := range itemList {
+		record := item.Record
+		outputList = append(outputList, map[string]interface{}{
+			"tooth":   record.ToothPath,
+			"version": record.Version.String(),
+			"information": map[string]interface{}{
+				"name":        record.Information.Name,
+				"description": record.Information.Description,
+				"author":      record.Information.Author,
+				"license":     record.Information.License,
+				"homepage":    record.Information.Homepage,
+			},
+		})
 	}
+	output.Print(outputList)
 }
 
 // listUpgradableTooths lists upgradable tooths.
-func listUpgradableTooths(isJSON bool) {
-	type UpgradableToothInfoType struct {
-		ToothPath string
-		Version   versions.Version
-	}
-
-	logger.Info("Checking for upgradable tooths... (this may take a while)")
-
-	// Get the sorted list of records.
-	recordList, err := toothrecord.ListAll()
-	if err != nil {
-		logger.Error(err.Error())
-		os.Exit(1)
-	}
-
-	// Get upgradable tooths.
-	upgradableToothInfoList := []UpgradableToothInfoType{}
+func listUpgradableTooths(itemList []lip.ListItem) {
 	longestToothPath := 20     // The mininum length
 	longestVersionString := 20 // The mininum length
-	for _, record := range recordList {
-		// Get the latest version.
-		specifier, err := specifiers.New(record.ToothPath)
-		if err != nil {
-			logger.Error("failed to get the latest version of " + record.ToothPath + ": " + err.Error())
-		}
-		latestVersion := specifier.ToothVersion()
-		if versions.Equal(latestVersion, record.Version) {
-			continue
+	for _, item := range itemList {
+		if len(item.Record.ToothPath) > longestToothPath {
+			longestToothPath = len(item.Record.ToothPath)
 		}
-
-		if len(record.ToothPath) > longestToothPath {
-			longestToothPath = len(record.ToothPath)
+		if len(item.LatestVersion.String()) > longestVersionString {
+			longestVersionString = len(item.LatestVersion.String())
 		}
-		if len(latestVersion.String()) > longestVersionString {
-			longestVersionString = len(latestVersion.String())
-		}
-
-		upgradableToothInfoList = append(upgradableToothInfoList, UpgradableToothInfoType{
-			ToothPath: record.ToothPath,
-			Version:   latestVersion,
-		})
 	}
 
 	// Print table.
@@ -173,21 +148,17 @@ func listUpgradableTooths(isJSON bool) {
 		strings.Repeat("-", longestVersionString))
 
 	// Print upgradable tooth information.
-	for _, upgradableToothInfo := range upgradableToothInfoList {
-		logger.Info(upgradableToothInfo.ToothPath + strings.Repeat(" ", longestToothPath-len(upgradableToothInfo.ToothPath)) +
-			" " + upgradableToothInfo.Version.String())
+	for _, item := range itemList {
+		logger.Info(item.Record.ToothPath + strings.Repeat(" ", longestToothPath-len(item.Record.ToothPath)) +
+			" " + item.LatestVersion.String())
 	}
 
-	if isJSON {
-		// Print JSON.
-		var outputMap = make([]interface{}, 0)
-		for _, upgradableToothInfo := range upgradableToothInfoList {
-			outputMap = append(outputMap, map[string]interface{}{
-				"tooth":   upgradableToothInfo.ToothPath,
-				"version": upgradableToothInfo.Version.String(),
-			})
-		}
-		outputJson, _ := json.Marshal(outputMap)
-		fmt.Println(string(outputJson))
+	var outputList = make([]interface{}, 0)
+	for _, item := range itemList {
+		outputList = append(outputList, map[string]interface{}{
+			"tooth":   item.Record.ToothPath,
+			"version": item.LatestVersion.String(),
+		})
 	}
+	output.Print(outputList)
 }
@@ -0,0 +1,93 @@
+package cmdlipplatform
+
+import (
+	"flag"
+
+	cmdlipplatformset "github.com/liteldev/lip/cmd/platform/set"
+	cmdlipplatformunset "github.com/liteldev/lip/cmd/platform/unset"
+	"github.com/liteldev/lip/output"
+	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/utils/platform"
+	"github.com/liteldev/lip/workspace"
+)
+
+// FlagDict is a dictionary of flags.
+type FlagDict struct {
+	helpFlag bool
+}
+
+const helpMessage = `
+Usage:
+  lip platform [options]
+  lip platform <command> [subcommand options] ...
+
+Description:
+  Show the target platform that tooths are installed for.
+
+Commands:
+  set                         Set the platform of the workspace.
+  unset                       Remove the platform of the workspace.
+
+Options:
+  -h, --help                  Show help.`
+
+// Run is the entry point.
+func Run(args []string) {
+	flagSet := flag.NewFlagSet("platform", flag.ExitOnError)
+
+	// Rewrite the default usage message.
+	flagSet.Usage = func() {
+		logger.Info(helpMessage)
+	}
+
+	var flagDict FlagDict
+	flagSet.BoolVar(&flagDict.helpFlag, "help", false, "")
+	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
+	flagSet.Parse(args)
+
+	// Help flag has the highest priority.
+	if flagDict.helpFlag {
+		logger.Info(helpMessage)
+		return
+	}
+
+	// If there is a subcommand, run it and exit.
+	if flagSet.NArg() >= 1 {
+		switch flagSet.Arg(0) {
+		case "set":
+			output.SetCommand("platform set")
+			cmdlipplatformset.Run(flagSet.Args()[1:])
+			return
+		case "unset":
+			output.SetCommand("platform unset")
+			cmdlipplatformunset.Run(flagSet.Args()[1:])
+			return
+		default:
+			output.Fail("Unknown command.")
+		}
+	}
+
+	// If there is no subcommand, show the platforms.
+	config, err := workspace.LoadConfig()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	// The workspace platform is null in documents if it is not set.
+	var workspacePlatform interface{}
+	workspacePlatformString := "(not set)"
+	if config.TargetOS != "" {
+		workspacePlatformString = config.TargetOS + "/" + config.TargetArch
+		workspacePlatform = workspacePlatformString
+	}
+
+	logger.Info("Target platform: " + platform.String())
+	logger.Info("Workspace platform: " + workspacePlatformString)
+	logger.Info("Native platform: " + platform.Native())
+
+	output.Print(map[string]interface{}{
+		"target":    platform.String(),
+		"workspace": workspacePlatform,
+		"native":    platform.Native(),
+	})
+}
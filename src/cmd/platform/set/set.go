@@ -0,0 +1,91 @@
+package cmdlipplatformset
+
+import (
+	"flag"
+	"runtime"
+	"strings"
+
+	"github.com/liteldev/lip/output"
+	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/utils/platform"
+	"github.com/liteldev/lip/workspace"
+)
+
+// FlagDict is a dictionary of flags.
+type FlagDict struct {
+	helpFlag bool
+}
+
+const helpMessage = `
+Usage:
+  lip platform set [options] <GOOS>[/<GOARCH>]
+
+Description:
+  Set the platform that tooths in the workspace are installed for. If GOARCH
+  is omitted, the architecture Lip is running on is used. The platform is
+  recorded in .lip/workspace.json, so it should be set before installing any
+  tooth.
+
+Options:
+  -h, --help                  Show help.`
+
+// Run is the entry point.
+func Run(args []string) {
+	flagSet := flag.NewFlagSet("set", flag.ExitOnError)
+
+	// Rewrite the default usage message.
+	flagSet.Usage = func() {
+		logger.Info(helpMessage)
+	}
+
+	var flagDict FlagDict
+	flagSet.BoolVar(&flagDict.helpFlag, "help", false, "")
+	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
+	flagSet.Parse(args)
+
+	// Help flag has the highest priority.
+	if flagDict.helpFlag {
+		logger.Info(helpMessage)
+		return
+	}
+
+	if flagSet.NArg() == 0 {
+		output.Fail("Missing platform.")
+	}
+
+	if flagSet.NArg() > 1 {
+		output.Fail("Too many arguments.")
+	}
+
+	goos, goarch, found := strings.Cut(flagSet.Arg(0), "/")
+	if !found {
+		goarch = runtime.GOARCH
+	}
+
+	if !platform.IsKnownGOOS(goos) {
+		output.Fail("Unknown GOOS: %s", goos)
+	}
+
+	if !platform.IsKnownGOARCH(goarch) {
+		output.Fail("Unknown GOARCH: %s", goarch)
+	}
+
+	config, err := workspace.LoadConfig()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	config.TargetOS = goos
+	config.TargetArch = goarch
+
+	err = workspace.SaveConfig(config)
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	logger.Info("The platform of the workspace has been set to " + goos + "/" + goarch + ".")
+
+	output.Print(map[string]interface{}{
+		"workspace": goos + "/" + goarch,
+	})
+}
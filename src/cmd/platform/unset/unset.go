@@ -0,0 +1,69 @@
+package cmdlipplatformunset
+
+import (
+	"flag"
+
+	"github.com/liteldev/lip/output"
+	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/workspace"
+)
+
+// FlagDict is a dictionary of flags.
+type FlagDict struct {
+	helpFlag bool
+}
+
+const helpMessage = `
+Usage:
+  lip platform unset [options]
+
+Description:
+  Remove the platform of the workspace. Tooths are then installed for the
+  platform Lip is running on.
+
+Options:
+  -h, --help                  Show help.`
+
+// Run is the entry point.
+func Run(args []string) {
+	flagSet := flag.NewFlagSet("unset", flag.ExitOnError)
+
+	// Rewrite the default usage message.
+	flagSet.Usage = func() {
+		logger.Info(helpMessage)
+	}
+
+	var flagDict FlagDict
+	flagSet.BoolVar(&flagDict.helpFlag, "help", false, "")
+	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
+	flagSet.Parse(args)
+
+	// Help flag has the highest priority.
+	if flagDict.helpFlag {
+		logger.Info(helpMessage)
+		return
+	}
+
+	if flagSet.NArg() > 0 {
+		output.Fail("Too many arguments.")
+	}
+
+	config, err := workspace.LoadConfig()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	config.TargetOS = ""
+	config.TargetArch = ""
+
+	err = workspace.SaveConfig(config)
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	logger.Info("The platform of the workspace has been removed.")
+
+	output.Print(map[string]interface{}{
+		"workspace": nil,
+	})
+}
@@ -0,0 +1,147 @@
+package cmdliprun
+
+import (
+	"flag"
+	"os"
+	"sort"
+	"strings"
+
+	cmdlipexec "github.com/liteldev/lip/cmd/exec"
+	"github.com/liteldev/lip/localfile"
+	"github.com/liteldev/lip/output"
+	"github.com/liteldev/lip/tooth/toothshim"
+	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/workspace"
+)
+
+// FlagDict is a dictionary of flags.
+type FlagDict struct {
+	helpFlag bool
+}
+
+const helpMessage = `
+Usage:
+  lip run [options] [<script> [args...]]
+
+Description:
+  Run a script defined in the scripts section of .lip/workspace.json and exit
+  with its exit code. Without a script name, list all scripts.
+
+  Scripts run in the workspace with sh, or cmd on Windows. The shims of tools in
+  .lip/bin of the workspace and ~/.lip/bin are on PATH, so scripts can call
+  installed tools by name. Arguments are appended to the script, quoted so that
+  the shell passes them as they are. These environment variables are set:
+
+    LIP_WORKSPACE             The directory of the workspace.
+    LIP_SCRIPT_NAME           The name of the script.
+
+Options:
+  -h, --help                  Show help.`
+
+// Run is the entry point.
+func Run(args []string) {
+	flagSet := flag.NewFlagSet("run", flag.ExitOnError)
+
+	// Rewrite the default usage message.
+	flagSet.Usage = func() {
+		logger.Info(helpMessage)
+	}
+
+	var flagDict FlagDict
+	flagSet.BoolVar(&flagDict.helpFlag, "help", false, "")
+	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
+	flagSet.Parse(args)
+
+	// Help flag has the highest priority.
+	if flagDict.helpFlag {
+		logger.Info(helpMessage)
+		return
+	}
+
+	config, err := workspace.LoadConfig()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	if flagSet.NArg() == 0 {
+		listScripts(config.Scripts)
+		return
+	}
+
+	scriptName := flagSet.Arg(0)
+	script, ok := config.Scripts[scriptName]
+	if !ok {
+		output.Fail("script " + scriptName + " not found. Run lip run to list all scripts.")
+	}
+
+	runScript(scriptName, script, flagSet.Args()[1:])
+}
+
+// listScripts prints the names and contents of scripts.
+func listScripts(scriptMap map[string]string) {
+	if len(scriptMap) == 0 {
+		logger.Info("No scripts are defined in .lip/workspace.json.")
+		output.Print(map[string]string{})
+		return
+	}
+
+	scriptNameList := make([]string, 0, len(scriptMap))
+	longestNameLength := 10 // The minimum length of the column.
+	for scriptName := range scriptMap {
+		scriptNameList = append(scriptNameList, scriptName)
+		if len(scriptName) > longestNameLength {
+			longestNameLength = len(scriptName)
+		}
+	}
+	sort.Strings(scriptNameList)
+
+	// Print header
+	logger.Info("Name" + strings.Repeat(" ", longestNameLength-4) + " Script")
+	logger.Info(strings.Repeat("-", longestNameLength) + " " + strings.Repeat("-", 20))
+
+	// Print scripts
+	for _, scriptName := range scriptNameList {
+		logger.Info(scriptName + strings.Repeat(" ", longestNameLength-len(scriptName)) + " " +
+			scriptMap[scriptName])
+	}
+	output.Print(scriptMap)
+}
+
+// runScript runs the script in the workspace with the shims of tools on PATH,
+// and exits with its exit code.
+func runScript(scriptName string, script string, args []string) {
+	workspaceDir, err := localfile.WorkspaceDir()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	// Make sure that shims of tools installed before exist.
+	err = toothshim.Sync()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	binDirList, err := toothshim.BinDirList()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	cmd := scriptCommand(scriptName, script, args)
+
+	path := strings.Join(binDirList, string(os.PathListSeparator))
+	if currentPath := os.Getenv("PATH"); currentPath != "" {
+		path += string(os.PathListSeparator) + currentPath
+	}
+
+	cmd.Dir = workspaceDir
+	cmd.Env = append(os.Environ(),
+		"PATH="+path,
+		"LIP_WORKSPACE="+workspaceDir,
+		"LIP_SCRIPT_NAME="+scriptName,
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+
+	cmdlipexec.RunAndExit(cmd)
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package cmdliprun
+
+import (
+	"os/exec"
+)
+
+// scriptCommand creates the command to run the script with sh. Arguments are
+// passed as positional parameters to avoid quoting them.
+func scriptCommand(scriptName string, script string, args []string) *exec.Cmd {
+	cmd := exec.Command("sh", "-c", script+" \"$@\"", scriptName)
+	cmd.Args = append(cmd.Args, args...)
+
+	return cmd
+}
@@ -0,0 +1,58 @@
+package cmdliprun
+
+import (
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// scriptCommand creates the command to run the script with cmd. The command
+// line is built by hand, since cmd does not parse it like other programs.
+func scriptCommand(scriptName string, script string, args []string) *exec.Cmd {
+	commandLine := script
+	for _, arg := range args {
+		commandLine += " " + escapeCmdArg(arg)
+	}
+
+	// With /s, cmd removes the outer quotes and runs the rest as it is.
+	cmd := exec.Command("cmd")
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CmdLine: `cmd /d /s /c "` + commandLine + `"`,
+	}
+
+	return cmd
+}
+
+// escapeCmdArg quotes the argument for programs parsing their command line like
+// CommandLineToArgvW, and then escapes characters special to cmd with ^, so that
+// cmd passes the quoted argument as it is.
+func escapeCmdArg(arg string) string {
+	// Backslashes are literal unless they precede a quote, so those before
+	// quotes, including the closing quote, are doubled.
+	quotedArg := `"`
+	backslashCount := 0
+	for _, c := range arg {
+		switch c {
+		case '\\':
+			backslashCount++
+			continue
+		case '"':
+			quotedArg += strings.Repeat(`\`, backslashCount*2+1)
+		default:
+			quotedArg += strings.Repeat(`\`, backslashCount)
+		}
+		backslashCount = 0
+		quotedArg += string(c)
+	}
+	quotedArg += strings.Repeat(`\`, backslashCount*2) + `"`
+
+	var builder strings.Builder
+	for _, c := range quotedArg {
+		if strings.ContainsRune(`()[]%!^"<>&|;, `+"\t", c) {
+			builder.WriteRune('^')
+		}
+		builder.WriteRune(c)
+	}
+
+	return builder.String()
+}
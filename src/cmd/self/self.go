@@ -0,0 +1,68 @@
+package cmdlipself
+
+import (
+	"flag"
+
+	cmdlipselfupdate "github.com/liteldev/lip/cmd/self/update"
+	cmdlipselfversion "github.com/liteldev/lip/cmd/self/version"
+	"github.com/liteldev/lip/output"
+	"github.com/liteldev/lip/utils/logger"
+)
+
+// FlagDict is a dictionary of flags.
+type FlagDict struct {
+	helpFlag bool
+}
+
+const helpMessage = `
+Usage:
+  lip self <command> [subcommand options] ...
+
+Description:
+  Manage Lip itself.
+
+Commands:
+  update                      Update the workspace-local Lip.
+  version                     Show the version of Lip and whether the workspace-local or global Lip is active.
+
+Options:
+  -h, --help                  Show help.`
+
+// Run is the entry point.
+func Run(args []string) {
+	flagSet := flag.NewFlagSet("self", flag.ExitOnError)
+
+	// Rewrite the default usage message.
+	flagSet.Usage = func() {
+		logger.Info(helpMessage)
+	}
+
+	var flagDict FlagDict
+	flagSet.BoolVar(&flagDict.helpFlag, "help", false, "")
+	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
+	flagSet.Parse(args)
+
+	// Help flag has the highest priority.
+	if flagDict.helpFlag {
+		logger.Info(helpMessage)
+		return
+	}
+
+	if flagSet.NArg() == 0 {
+		logger.Info(helpMessage)
+		return
+	}
+
+	switch flagSet.Arg(0) {
+	case "update":
+		output.SetCommand("self update")
+		cmdlipselfupdate.Run(flagSet.Args()[1:])
+		return
+	case "version":
+		output.SetCommand("self version")
+		cmdlipselfversion.Run(flagSet.Args()[1:])
+		return
+	default:
+		output.Fail("Unknown command.")
+	}
+}
@@ -0,0 +1,123 @@
+package cmdlipselfupdate
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/liteldev/lip/context"
+	"github.com/liteldev/lip/output"
+	"github.com/liteldev/lip/selfupdate"
+	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/utils/versions"
+	"github.com/liteldev/lip/workspace"
+)
+
+// FlagDict is a dictionary of flags.
+type FlagDict struct {
+	helpFlag       bool
+	versionFlag    string
+	releaseURLFlag string
+}
+
+const helpMessage = `
+Usage:
+  lip self update [options]
+
+Description:
+  Update the workspace-local Lip in .lip/tools/lip. The release is downloaded
+  from the release URL, verified against its SHA-256 checksum and staged as
+  .lip/tools/lip/lip.update, which replaces the workspace-local Lip on the next
+  run of Lip in the workspace. Without --version, the latest stable version
+  listed via GOPROXY is used. Workspaces pinning a Lip version with
+  lip_version in .lip/workspace.json cannot be updated this way.
+
+Options:
+  -h, --help                  Show help.
+  --version <version>         The version to update to.
+  --release-url <url>         The URL to download releases from. Overrides LIP_RELEASE_URL.`
+
+// Run is the entry point.
+func Run(args []string) {
+	flagSet := flag.NewFlagSet("update", flag.ExitOnError)
+
+	// Rewrite the default usage message.
+	flagSet.Usage = func() {
+		logger.Info(helpMessage)
+	}
+
+	var flagDict FlagDict
+	flagSet.BoolVar(&flagDict.helpFlag, "help", false, "")
+	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
+	flagSet.StringVar(&flagDict.versionFlag, "version", "", "")
+	flagSet.StringVar(&flagDict.releaseURLFlag, "release-url", "", "")
+	flagSet.Parse(args)
+
+	// Help flag has the highest priority.
+	if flagDict.helpFlag {
+		logger.Info(helpMessage)
+		return
+	}
+
+	if flagSet.NArg() > 0 {
+		output.Fail("Too many arguments.")
+	}
+
+	// The pinned version would replace the update on the next run.
+	config, err := workspace.LoadConfig()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	if config.LipVersion != "" {
+		output.Fail("the workspace pins Lip " + config.LipVersion +
+			". Change lip_version in .lip/workspace.json to use another version.")
+	}
+
+	if flagDict.releaseURLFlag != "" {
+		context.ReleaseURL = flagDict.releaseURLFlag
+	}
+
+	var version versions.Version
+	if flagDict.versionFlag != "" {
+		version, err = versions.NewFromString(strings.TrimPrefix(flagDict.versionFlag, "v"))
+		if err != nil {
+			output.Fail("invalid version: " + flagDict.versionFlag)
+		}
+	} else {
+		logger.Info("Fetching the latest version of Lip...")
+		version, err = selfupdate.LatestVersion()
+		if err != nil {
+			output.Fail(err.Error())
+		}
+	}
+
+	if versions.Equal(version, context.Version) {
+		logger.Info("Lip is already at version " + version.String() + ".")
+		output.Print(map[string]interface{}{
+			"from_version": context.Version.String(),
+			"to_version":   version.String(),
+			"staged_path":  nil,
+		})
+		return
+	}
+
+	logger.Info("Downloading Lip " + version.String() + "...")
+	err = selfupdate.Stage(version)
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	logger.Info("Updated Lip from " + context.Version.String() + " to " + version.String() +
+		". The new version takes effect on the next run of Lip in this workspace.")
+
+	stagingPath, err := selfupdate.StagingPath()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	output.Print(map[string]interface{}{
+		"from_version": context.Version.String(),
+		"to_version":   version.String(),
+		"staged_path":  stagingPath,
+	})
+}
@@ -0,0 +1,134 @@
+package cmdlipselfversion
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/liteldev/lip/context"
+	"github.com/liteldev/lip/output"
+	"github.com/liteldev/lip/selfupdate"
+	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/workspace"
+)
+
+// FlagDict is a dictionary of flags.
+type FlagDict struct {
+	helpFlag bool
+}
+
+const helpMessage = `
+Usage:
+  lip self version [options]
+
+Description:
+  Show the version of Lip and whether the workspace-local Lip in
+  .lip/tools/lip or the global Lip is active.
+
+Options:
+  -h, --help                  Show help.`
+
+// Run is the entry point.
+func Run(args []string) {
+	flagSet := flag.NewFlagSet("version", flag.ExitOnError)
+
+	// Rewrite the default usage message.
+	flagSet.Usage = func() {
+		logger.Info(helpMessage)
+	}
+
+	var flagDict FlagDict
+	flagSet.BoolVar(&flagDict.helpFlag, "help", false, "")
+	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
+	flagSet.Parse(args)
+
+	// Help flag has the highest priority.
+	if flagDict.helpFlag {
+		logger.Info(helpMessage)
+		return
+	}
+
+	if flagSet.NArg() > 0 {
+		output.Fail("Too many arguments.")
+	}
+
+	executablePath, err := os.Executable()
+	if err != nil {
+		output.Fail("failed to locate the executable of Lip: " + err.Error())
+	}
+	executablePath = resolvePath(executablePath)
+
+	localPath, err := selfupdate.LocalPath()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	stagingPath, err := selfupdate.StagingPath()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	logger.Info("Version: " + context.Version.String())
+
+	isLocalActive := executablePath == resolvePath(localPath)
+	if isLocalActive {
+		logger.Info("Active: workspace-local (" + executablePath + ")")
+	} else {
+		logger.Info("Active: global (" + executablePath + ")")
+
+		if _, err := os.Stat(localPath); err == nil {
+			logger.Info("Workspace-local: " + localPath + " (not active)")
+		}
+	}
+
+	// Paths that do not exist are null in documents.
+	var localPathValue, stagingPathValue, pinnedVersionValue interface{}
+	if _, err := os.Stat(localPath); err == nil {
+		localPathValue = localPath
+	}
+
+	if _, err := os.Stat(stagingPath); err == nil {
+		logger.Info("Pending update: " + stagingPath)
+		stagingPathValue = stagingPath
+	}
+
+	config, err := workspace.LoadConfig()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	if config.LipVersion != "" {
+		logger.Info("Pinned version: " + config.LipVersion)
+		pinnedVersionValue = config.LipVersion
+	}
+
+	active := "global"
+	if isLocalActive {
+		active = "workspace-local"
+	}
+
+	output.Print(map[string]interface{}{
+		"version":         context.Version.String(),
+		"active":          active,
+		"executable_path": executablePath,
+		"local_path":      localPathValue,
+		"pending_update":  stagingPathValue,
+		"pinned_version":  pinnedVersionValue,
+	})
+}
+
+// resolvePath returns the absolute path with symbolic links resolved, or the
+// path itself if it cannot be resolved.
+func resolvePath(path string) string {
+	resolvedPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return path
+	}
+
+	absPath, err := filepath.Abs(resolvedPath)
+	if err != nil {
+		return resolvedPath
+	}
+
+	return absPath
+}
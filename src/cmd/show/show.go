@@ -1,18 +1,15 @@
 package cmdlipshow
 
 import (
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
-
-	"github.com/liteldev/lip/localfile"
-	"github.com/liteldev/lip/registry"
-	"github.com/liteldev/lip/tooth/toothrecord"
-	"github.com/liteldev/lip/tooth/toothrepo"
+	"sort"
+
+	"github.com/liteldev/lip/lip"
+	"github.com/liteldev/lip/output"
 	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/utils/versions/versionmatch"
 )
 
 // FlagDict is a dictionary of flags.
@@ -34,12 +31,10 @@ Options:
   -h, --help                  Show help.
   --files                     Show the full list of installed files.
   --available                 Show the full list of available versions.
-  --json                      Output in JSON format. (cannot be hidden with "--quiet")`
+  --json                      Same as "lip --format json show".`
 
 // Run is the entry point.
 func Run(args []string) {
-	var err error
-
 	flagSet := flag.NewFlagSet("list", flag.ExitOnError)
 
 	// Rewrite the default usage message.
@@ -61,46 +56,32 @@ func Run(args []string) {
 		return
 	}
 
+	// The json flag is kept for compatibility.
+	if flagDict.jsonFlag {
+		output.SetFormat(output.JSONFormat)
+	}
+
 	// The tooth path should not be empty or more than one.
 	if len(flagSet.Args()) == 0 ||
 		len(flagSet.Args()) > 1 {
-		logger.Error("the tooth path should be exactly one")
-		os.Exit(1)
+		output.Fail("the tooth path should be exactly one")
 	}
 
-	// Get the record file path.
-	// If the input is an alias, convert it to the repo path.
-	toothPath := strings.ToLower(flagSet.Arg(0))
-	if !strings.Contains(toothPath, "/") {
-		toothPath, err = registry.LookupAlias(toothPath)
-		if err != nil {
-			logger.Error(err.Error())
-			os.Exit(1)
-		}
-		logger.Info("The alias is converted to the repo path: " + toothPath)
-	}
-
-	recordFileName := localfile.GetRecordFileName(toothPath)
-	recordDir, err := localfile.RecordDir()
+	result, err := lip.Show(context.Background(), lip.ShowOptions{
+		ToothPath:         flagSet.Arg(0),
+		AvailableVersions: flagDict.availableFlag,
+	})
 	if err != nil {
-		logger.Error(err.Error())
-		os.Exit(1)
+		output.Fail(err.Error())
 	}
-	recordFilePath := filepath.Join(recordDir, recordFileName)
 
 	outputJSONMap := map[string]interface{}{}
 
-	// Check if the record file exists.
-	if _, err := os.Stat(recordFilePath); os.IsNotExist(err) {
+	if !result.IsInstalled {
 		logger.Info("The tooth is not installed")
 		logger.Info("")
 	} else {
-		// Get the record file content.
-		recordObject, err := toothrecord.NewFromFile(recordFilePath)
-		if err != nil {
-			logger.Error(err.Error())
-			os.Exit(1)
-		}
+		recordObject := result.Record
 
 		// Show information.
 		logger.Info("Tooth information:")
@@ -112,8 +93,64 @@ func Run(args []string) {
 		logger.Info("  License: " + recordObject.Information.License)
 		logger.Info("  Homepage: " + recordObject.Information.Homepage)
 		logger.Info("  Is-manually-installed: " + fmt.Sprint(recordObject.IsManuallyInstalled))
+		if recordObject.GitURL != "" {
+			logger.Info("  Git-source: " + recordObject.GitURL + "@" + recordObject.GitCommit)
+		}
+		if recordObject.IsEditable {
+			logger.Info("  Editable: " + recordObject.SourceDir)
+		}
 		logger.Info("")
 
+		// Show extras.
+		if len(recordObject.OptionalDependencies) > 0 {
+			logger.Info("Extras:")
+			for _, extra := range sortedKeys(recordObject.OptionalDependencies) {
+				isSelected := false
+				for _, selectedExtra := range recordObject.Extras {
+					if selectedExtra == extra {
+						isSelected = true
+						break
+					}
+				}
+
+				extraString := "  " + extra
+				if isSelected {
+					extraString += " (selected)"
+				}
+				logger.Info(extraString)
+
+				for _, toothPath := range sortedKeys(recordObject.OptionalDependencies[extra]) {
+					logger.Info("    " + toothPath + " " + versionmatch.RangeString(recordObject.OptionalDependencies[extra][toothPath]))
+				}
+			}
+			logger.Info("")
+		}
+
+		// Show relationships with other tooths.
+		if len(recordObject.Conflicts) > 0 {
+			logger.Info("Conflicts:")
+			for _, toothPath := range sortedKeys(recordObject.Conflicts) {
+				logger.Info("  " + toothPath + " " + versionmatch.RangeString(recordObject.Conflicts[toothPath]))
+			}
+			logger.Info("")
+		}
+
+		if len(recordObject.Provides) > 0 {
+			logger.Info("Provides:")
+			for _, toothPath := range sortedKeys(recordObject.Provides) {
+				logger.Info("  " + toothPath + "@" + recordObject.Provides[toothPath].String())
+			}
+			logger.Info("")
+		}
+
+		if len(recordObject.Replaces) > 0 {
+			logger.Info("Replaces:")
+			for _, toothPath := range recordObject.Replaces {
+				logger.Info("  " + toothPath)
+			}
+			logger.Info("")
+		}
+
 		// Save to JSON map.
 		outputJSONMap["tooth"] = recordObject.ToothPath
 		outputJSONMap["version"] = recordObject.Version.String()
@@ -123,6 +160,39 @@ func Run(args []string) {
 		outputJSONMap["license"] = recordObject.Information.License
 		outputJSONMap["homepage"] = recordObject.Information.Homepage
 		outputJSONMap["is-manually-installed"] = recordObject.IsManuallyInstalled
+		if recordObject.GitURL != "" {
+			outputJSONMap["git-url"] = recordObject.GitURL
+			outputJSONMap["git-commit"] = recordObject.GitCommit
+		}
+		if recordObject.IsEditable {
+			outputJSONMap["editable-source-dir"] = recordObject.SourceDir
+		}
+
+		outputJSONMap["extras"] = recordObject.Extras
+
+		optionalDependenciesJSONMap := map[string]interface{}{}
+		for extra, dependencies := range recordObject.OptionalDependencies {
+			dependenciesJSONMap := map[string]interface{}{}
+			for toothPath, versionRange := range dependencies {
+				dependenciesJSONMap[toothPath] = versionRangeToJSON(versionRange)
+			}
+			optionalDependenciesJSONMap[extra] = dependenciesJSONMap
+		}
+		outputJSONMap["optional-dependencies"] = optionalDependenciesJSONMap
+
+		conflictsJSONMap := map[string]interface{}{}
+		for toothPath, versionRange := range recordObject.Conflicts {
+			conflictsJSONMap[toothPath] = versionRangeToJSON(versionRange)
+		}
+		outputJSONMap["conflicts"] = conflictsJSONMap
+
+		providesJSONMap := map[string]interface{}{}
+		for toothPath, version := range recordObject.Provides {
+			providesJSONMap[toothPath] = version.String()
+		}
+		outputJSONMap["provides"] = providesJSONMap
+
+		outputJSONMap["replaces"] = recordObject.Replaces
 
 		// Show the full list of installed files if the files flag is set.
 		if flagDict.filesFlag {
@@ -143,19 +213,10 @@ func Run(args []string) {
 
 	// Show the full list of available versions if the available flag is set.
 	if flagDict.availableFlag {
-		logger.Info("Fetching available versions...")
-
-		// Show version information
-		versionList, err := toothrepo.FetchVersionList(toothPath)
-		if err != nil {
-			logger.Error("failed to fetch available versions: " + err.Error())
-			os.Exit(1)
-		}
-
 		logger.Info("Available versions:")
 		versionListString := ""
 		outputJSONMap["versions"] = []string{}
-		for _, version := range versionList {
+		for _, version := range result.AvailableVersions {
 			versionListString += "  " + version.String()
 
 			// Save to JSON map.
@@ -165,9 +226,30 @@ func Run(args []string) {
 		logger.Info("")
 	}
 
-	// Output in JSON format.
-	if flagDict.jsonFlag {
-		outputJSON, _ := json.Marshal(outputJSONMap)
-		fmt.Println(string(outputJSON))
+	output.Print(outputJSONMap)
+}
+
+// sortedKeys returns the keys of a map from tooth paths in ascending order.
+func sortedKeys[T any](m map[string]T) []string {
+	keyList := make([]string, 0, len(m))
+	for key := range m {
+		keyList = append(keyList, key)
 	}
+	sort.Strings(keyList)
+
+	return keyList
+}
+
+// versionRangeToJSON converts a version range to nested lists of strings as in
+// tooth.json.
+func versionRangeToJSON(versionRange [][]versionmatch.VersionMatch) [][]string {
+	versionRangeJSONList := make([][]string, len(versionRange))
+	for i, innerVersionRange := range versionRange {
+		versionRangeJSONList[i] = make([]string, len(innerVersionRange))
+		for j, versionMatch := range innerVersionRange {
+			versionRangeJSONList[i][j] = versionMatch.String()
+		}
+	}
+
+	return versionRangeJSONList
 }
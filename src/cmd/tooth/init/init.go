@@ -1,40 +1,58 @@
 package cmdliptoothinit
 
 import (
+	"bufio"
+	"encoding/json"
 	"errors"
 	"flag"
+	"io"
 	"os"
+	"strings"
 
+	"github.com/liteldev/lip/output"
+	"github.com/liteldev/lip/tooth/toothlint"
+	"github.com/liteldev/lip/tooth/toothutils"
 	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/utils/versions"
 )
 
 // FlagDict is a dictionary of flags.
 type FlagDict struct {
-	helpFlag bool
+	helpFlag        bool
+	yesFlag         bool
+	toothFlag       string
+	versionFlag     string
+	nameFlag        string
+	descriptionFlag string
+	authorFlag      string
+	licenseFlag     string
+	homepageFlag    string
+	noPlacementFlag bool
 }
 
-const defaultToothJsonContent = `{
-    "format_version": 1,
-    "tooth": "<tooth path>",
-    "version": "<version>",
-    "dependencies": {},
-    "information": {
-        "name": "<name>",
-        "description": "<description>",
-        "author": "<author>",
-        "license": "<license>",
-        "homepage": "<homepage>"
-    },
-    "placement": [
-        {
-            "source": "<placement source>",
-            "destination": "<placement destination>"
-        }
-    ],
-	"possession": [
-		"<private directory of your tooth ends with '/'>"
-	]
-}`
+// toothJSONStruct is the content of tooth.json written by lip tooth init.
+type toothJSONStruct struct {
+	FormatVersion int               `json:"format_version"`
+	Tooth         string            `json:"tooth"`
+	Version       string            `json:"version"`
+	Dependencies  map[string]string `json:"dependencies"`
+	Information   informationStruct `json:"information"`
+	Placement     []placementStruct `json:"placement"`
+	Possession    []string          `json:"possession"`
+}
+
+type informationStruct struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+	License     string `json:"license,omitempty"`
+	Homepage    string `json:"homepage,omitempty"`
+}
+
+type placementStruct struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
 
 const helpMessage = `
 Usage:
@@ -42,9 +60,24 @@ Usage:
 
 Description:
   Initialize and writes a new tooth.json file in the current directory, in effect creating a new tooth rooted at the current directory.
+  Lip asks for the tooth path, version, name, description, author, license and homepage. Defaults are inferred from the git remote,
+  git tags, git config, the LICENSE file and the directory name. Lip also offers to generate placements from existing files.
+  Values given by flags are not asked for. The written tooth.json always passes "lip tooth lint --offline".
 
 Options:
-  -h, --help                  Show help.`
+  -h, --help                  Show help.
+  -y, --yes                   Do not ask. Use values given by flags or inferred defaults.
+  --tooth <tooth path>        The tooth path, e.g. github.com/tooth-hub/example.
+  --version <version>         The version. Defaults to the latest git tag or 0.1.0.
+  --name <name>               The name. Defaults to the directory name.
+  --description <text>        The description.
+  --author <author>           The author. Defaults to git config user.name.
+  --license <license>         The license. Defaults to the license detected from the LICENSE file.
+  --homepage <url>            The homepage. Defaults to the URL of the git remote.
+  --no-placement              Do not generate placements from existing files.`
+
+// stdinReader reads answers from the standard input line by line.
+var stdinReader = bufio.NewReader(os.Stdin)
 
 // Run is the entry point.
 func Run(args []string) {
@@ -58,6 +91,16 @@ func Run(args []string) {
 	var flagDict FlagDict
 	flagSet.BoolVar(&flagDict.helpFlag, "help", false, "")
 	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
+	flagSet.BoolVar(&flagDict.yesFlag, "yes", false, "")
+	flagSet.BoolVar(&flagDict.yesFlag, "y", false, "")
+	flagSet.StringVar(&flagDict.toothFlag, "tooth", "", "")
+	flagSet.StringVar(&flagDict.versionFlag, "version", "", "")
+	flagSet.StringVar(&flagDict.nameFlag, "name", "", "")
+	flagSet.StringVar(&flagDict.descriptionFlag, "description", "", "")
+	flagSet.StringVar(&flagDict.authorFlag, "author", "", "")
+	flagSet.StringVar(&flagDict.licenseFlag, "license", "", "")
+	flagSet.StringVar(&flagDict.homepageFlag, "homepage", "", "")
+	flagSet.BoolVar(&flagDict.noPlacementFlag, "no-placement", false, "")
 	flagSet.Parse(args)
 
 	// Help flag has the highest priority.
@@ -68,39 +111,211 @@ func Run(args []string) {
 
 	// No other arguments are supported.
 	if flagSet.NArg() > 0 {
-		logger.Error("Too many arguments.")
-		os.Exit(1)
+		output.Fail("Too many arguments.")
 	}
 
-	err := initTooth()
+	err := initTooth(flagDict)
 
 	if err != nil {
-		logger.Error(err.Error())
-		os.Exit(1)
+		output.Fail(err.Error())
 	}
 
 	logger.Info("tooth.json created successfully")
-	logger.Info("please edit tooth.json and modify the values with \"<>\"")
+
+	jsonData, err := os.ReadFile("tooth.json")
+	if err != nil {
+		output.Fail("failed to read tooth.json: " + err.Error())
+	}
+
+	output.Print(map[string]interface{}{
+		"file":     "tooth.json",
+		"metadata": json.RawMessage(jsonData),
+	})
 }
 
 // initTooth initializes a new tooth.
-func initTooth() error {
+func initTooth(flagDict FlagDict) error {
 	// Check if tooth.json already exists.
 	if _, err := os.Stat("tooth.json"); err == nil {
 		return errors.New("tooth.json already exists in the current directory")
 	}
 
-	// Create tooth.json.
-	file, err := os.Create("tooth.json")
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return errors.New("failed to get the current directory: " + err.Error())
+	}
+
+	// Infer defaults.
+	remoteToothPath := inferToothPath()
+	defaultAuthor := inferAuthor(remoteToothPath)
+	defaultHomepage := ""
+	if remoteToothPath != "" {
+		defaultHomepage = "https://" + remoteToothPath
+	}
+
+	// Ask for values not given by flags.
+	toothPath, err := ask("Tooth path", flagDict.toothFlag, remoteToothPath, flagDict.yesFlag, func(value string) error {
+		if value == "" || !toothutils.IsValidToothPath(value) {
+			return errors.New("invalid tooth path: \"" + value +
+				"\". It should be lower case and look like github.com/<owner>/<repository>")
+		}
+		return nil
+	})
 	if err != nil {
-		return errors.New("failed to create tooth.json")
+		return err
 	}
 
-	// Write default tooth.json content.
-	_, err = file.WriteString(defaultToothJsonContent)
+	version, err := ask("Version", flagDict.versionFlag, inferVersion(), flagDict.yesFlag, func(value string) error {
+		if !versions.IsValidVersionString(value) {
+			return errors.New("invalid version: \"" + value + "\". It should look like 1.0.0")
+		}
+		return nil
+	})
 	if err != nil {
-		return errors.New("failed to write tooth.json")
+		return err
+	}
+
+	name, err := ask("Name", flagDict.nameFlag, inferName(workingDir), flagDict.yesFlag, nil)
+	if err != nil {
+		return err
+	}
+
+	description, err := ask("Description", flagDict.descriptionFlag, "", flagDict.yesFlag, nil)
+	if err != nil {
+		return err
+	}
+
+	author, err := ask("Author", flagDict.authorFlag, defaultAuthor, flagDict.yesFlag, nil)
+	if err != nil {
+		return err
+	}
+
+	license, err := ask("License", flagDict.licenseFlag, inferLicense(), flagDict.yesFlag, nil)
+	if err != nil {
+		return err
+	}
+
+	homepage, err := ask("Homepage", flagDict.homepageFlag, defaultHomepage, flagDict.yesFlag, nil)
+	if err != nil {
+		return err
+	}
+
+	// Generate placements from existing files.
+	placementList := make([]placementStruct, 0)
+	if !flagDict.noPlacementFlag {
+		generatedPlacementList, err := generatePlacements(".")
+		if err != nil {
+			return err
+		}
+
+		if len(generatedPlacementList) > 0 {
+			isAccepted := flagDict.yesFlag
+			if !flagDict.yesFlag {
+				logger.Info("Placements generated from existing files:")
+				for _, placement := range generatedPlacementList {
+					logger.Info("  " + placement.Source + " -> " + placement.Destination)
+				}
+				logger.Info("Do you want to use these placements? (Y/n)")
+				ans, _ := readLine()
+				isAccepted = ans == "" || ans == "y" || ans == "Y"
+			}
+
+			if isAccepted {
+				placementList = generatedPlacementList
+			}
+		}
+	}
+
+	toothJSON := toothJSONStruct{
+		FormatVersion: 1,
+		Tooth:         toothPath,
+		Version:       version,
+		Dependencies:  make(map[string]string),
+		Information: informationStruct{
+			Name:        name,
+			Description: description,
+			Author:      author,
+			License:     license,
+			Homepage:    homepage,
+		},
+		Placement:  placementList,
+		Possession: make([]string, 0),
+	}
+
+	jsonData, err := json.MarshalIndent(toothJSON, "", "    ")
+	if err != nil {
+		return errors.New("failed to generate tooth.json: " + err.Error())
+	}
+	jsonData = append(jsonData, '\n')
+
+	// Make sure the generated tooth.json is valid before writing it.
+	for _, finding := range toothlint.Lint(jsonData, nil) {
+		if finding.Severity == toothlint.SeverityError {
+			return errors.New("generated tooth.json is invalid: " + finding.Pointer + ": " + finding.Message)
+		}
+	}
+
+	err = os.WriteFile("tooth.json", jsonData, 0644)
+	if err != nil {
+		return errors.New("failed to write tooth.json: " + err.Error())
 	}
 
 	return nil
 }
+
+// ask asks for a value. If the value is given by a flag, the flag value is
+// used. If isYes is true, the default value is used without asking. Invalid
+// answers are asked again.
+func ask(question string, flagValue string, defaultValue string, isYes bool,
+	validate func(string) error) (string, error) {
+	if validate == nil {
+		validate = func(string) error { return nil }
+	}
+
+	if flagValue != "" {
+		return flagValue, validate(flagValue)
+	}
+
+	if isYes {
+		err := validate(defaultValue)
+		if err != nil && defaultValue == "" {
+			return "", errors.New("cannot infer " + strings.ToLower(question) + ", please specify it with a flag")
+		}
+		return defaultValue, err
+	}
+
+	for {
+		if defaultValue != "" {
+			logger.Info(question + " (" + defaultValue + "):")
+		} else {
+			logger.Info(question + ":")
+		}
+
+		ans, err := readLine()
+		if ans == "" {
+			ans = defaultValue
+		}
+
+		validateErr := validate(ans)
+		if validateErr == nil {
+			return ans, nil
+		}
+
+		// Stop asking if there is nothing more to read.
+		if err != nil {
+			return "", validateErr
+		}
+
+		logger.Error(validateErr.Error())
+	}
+}
+
+// readLine reads a line from the standard input.
+func readLine() (string, error) {
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && !(err == io.EOF && line != "") {
+		return strings.TrimSpace(line), err
+	}
+
+	return strings.TrimSpace(line), nil
+}
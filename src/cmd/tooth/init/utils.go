@@ -0,0 +1,190 @@
+package cmdliptoothinit
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/liteldev/lip/tooth/toothpack"
+	"github.com/liteldev/lip/tooth/toothutils"
+	"github.com/liteldev/lip/utils/versions"
+)
+
+// metaFilePattern matches files that are not placed, like README and LICENSE.
+var metaFilePattern = regexp.MustCompile(`(?i)^(readme|license|licence|copying|changelog|contributing)(\..*)?$`)
+
+// generatePlacements generates placements from existing files in the directory.
+// Each top-level directory is placed with a wildcard and each top-level file is
+// placed as is. Hidden files, tooth files and files like README are skipped.
+func generatePlacements(dirPath string) ([]placementStruct, error) {
+	fileList, err := toothpack.ListFiles(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	placementList := make([]placementStruct, 0)
+	placedDirMap := make(map[string]bool)
+	for _, file := range fileList {
+		topLevelName := strings.Split(file, "/")[0]
+
+		if strings.HasPrefix(topLevelName, ".") || topLevelName == "tooth.json" ||
+			strings.HasSuffix(topLevelName, ".tth") || metaFilePattern.MatchString(topLevelName) {
+			continue
+		}
+
+		// Files in the top level.
+		if topLevelName == file {
+			placementList = append(placementList, placementStruct{
+				Source:      file,
+				Destination: file,
+			})
+			continue
+		}
+
+		// Directories in the top level.
+		if !placedDirMap[topLevelName] {
+			placedDirMap[topLevelName] = true
+			placementList = append(placementList, placementStruct{
+				Source:      topLevelName + "/*",
+				Destination: topLevelName + "/*",
+			})
+		}
+	}
+
+	return placementList, nil
+}
+
+// inferAuthor infers the author from git config, or from the owner in the
+// tooth path.
+func inferAuthor(toothPath string) string {
+	if author, err := runGit("config", "user.name"); err == nil && author != "" {
+		return author
+	}
+
+	toothPathParts := strings.Split(toothPath, "/")
+	if len(toothPathParts) >= 2 {
+		return toothPathParts[1]
+	}
+
+	return ""
+}
+
+// inferLicense infers the SPDX license identifier from the license file.
+func inferLicense() string {
+	for _, fileName := range []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "LICENCE", "COPYING"} {
+		content, err := os.ReadFile(fileName)
+		if err != nil {
+			continue
+		}
+
+		return detectLicense(string(content))
+	}
+
+	return ""
+}
+
+// detectLicense detects the SPDX license identifier of a license text.
+func detectLicense(text string) string {
+	switch {
+	case strings.Contains(text, "GNU AFFERO GENERAL PUBLIC LICENSE"):
+		return "AGPL-3.0"
+	case strings.Contains(text, "GNU LESSER GENERAL PUBLIC LICENSE"):
+		if strings.Contains(text, "Version 2.1") {
+			return "LGPL-2.1"
+		}
+		return "LGPL-3.0"
+	case strings.Contains(text, "GNU GENERAL PUBLIC LICENSE"):
+		if strings.Contains(text, "Version 2") {
+			return "GPL-2.0"
+		}
+		return "GPL-3.0"
+	case strings.Contains(text, "Apache License") && strings.Contains(text, "Version 2.0"):
+		return "Apache-2.0"
+	case strings.Contains(text, "Mozilla Public License Version 2.0"):
+		return "MPL-2.0"
+	case strings.Contains(text, "Permission is hereby granted, free of charge"):
+		return "MIT"
+	case strings.Contains(text, "Redistribution and use in source and binary forms"):
+		if strings.Contains(text, "Neither the name") {
+			return "BSD-3-Clause"
+		}
+		return "BSD-2-Clause"
+	case strings.Contains(text, "This is free and unencumbered software"):
+		return "Unlicense"
+	}
+
+	return ""
+}
+
+// inferName infers the name from the directory name.
+func inferName(workingDir string) string {
+	return filepath.Base(workingDir)
+}
+
+// inferToothPath infers the tooth path from the URL of the git remote.
+func inferToothPath() string {
+	remoteURL, err := runGit("remote", "get-url", "origin")
+	if err != nil {
+		return ""
+	}
+
+	toothPath := toothPathFromRemoteURL(remoteURL)
+	if !toothutils.IsValidToothPath(toothPath) {
+		return ""
+	}
+
+	return toothPath
+}
+
+// inferVersion infers the version from the latest git tag.
+func inferVersion() string {
+	tag, err := runGit("describe", "--tags", "--abbrev=0")
+	if err == nil && versions.IsValidVersionString(strings.TrimPrefix(tag, "v")) {
+		return strings.TrimPrefix(tag, "v")
+	}
+
+	return "0.1.0"
+}
+
+// runGit runs a git command in the current directory and returns the trimmed
+// output.
+func runGit(args ...string) (string, error) {
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// toothPathFromRemoteURL converts a git remote URL to a tooth path, e.g.
+// git@github.com:Owner/Repo.git to github.com/owner/repo.
+func toothPathFromRemoteURL(remoteURL string) string {
+	toothPath := remoteURL
+
+	if index := strings.Index(toothPath, "://"); index >= 0 {
+		toothPath = toothPath[index+len("://"):]
+	} else if index := strings.Index(toothPath, ":"); index >= 0 {
+		// SCP-like syntax, e.g. git@github.com:owner/repo.git.
+		toothPath = toothPath[:index] + "/" + toothPath[index+1:]
+	}
+
+	// Remove the user info.
+	if index := strings.Index(toothPath, "@"); index >= 0 && index < strings.Index(toothPath, "/") {
+		toothPath = toothPath[index+1:]
+	}
+
+	// Remove the port.
+	if hostEnd := strings.Index(toothPath, "/"); hostEnd >= 0 {
+		if index := strings.Index(toothPath[:hostEnd], ":"); index >= 0 {
+			toothPath = toothPath[:index] + toothPath[hostEnd:]
+		}
+	}
+
+	toothPath = strings.TrimSuffix(toothPath, "/")
+	toothPath = strings.TrimSuffix(toothPath, ".git")
+
+	return strings.ToLower(toothPath)
+}
@@ -0,0 +1,158 @@
+package cmdliptoothinit
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestToothPathFromRemoteURL(t *testing.T) {
+	testList := []struct {
+		remoteURL string
+		toothPath string
+	}{
+		{"https://github.com/Owner/Repo.git", "github.com/owner/repo"},
+		{"https://github.com/owner/repo", "github.com/owner/repo"},
+		{"https://github.com/owner/repo/", "github.com/owner/repo"},
+		{"https://user@github.com/owner/repo.git", "github.com/owner/repo"},
+		{"git@github.com:Owner/Repo.git", "github.com/owner/repo"},
+		{"ssh://git@github.com:22/owner/repo.git", "github.com/owner/repo"},
+		{"https://gitlab.example.com:8443/group/sub/repo.git", "gitlab.example.com/group/sub/repo"},
+	}
+
+	for _, test := range testList {
+		if toothPath := toothPathFromRemoteURL(test.remoteURL); toothPath != test.toothPath {
+			t.Errorf("toothPathFromRemoteURL(%q) = %q, want %q", test.remoteURL, toothPath, test.toothPath)
+		}
+	}
+}
+
+func TestDetectLicense(t *testing.T) {
+	testList := []struct {
+		text    string
+		license string
+	}{
+		{"GNU AFFERO GENERAL PUBLIC LICENSE\nVersion 3", "AGPL-3.0"},
+		{"GNU LESSER GENERAL PUBLIC LICENSE\nVersion 2.1, February 1999", "LGPL-2.1"},
+		{"GNU LESSER GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007", "LGPL-3.0"},
+		{"GNU GENERAL PUBLIC LICENSE\nVersion 2, June 1991", "GPL-2.0"},
+		{"GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007", "GPL-3.0"},
+		{"Apache License\nVersion 2.0, January 2004", "Apache-2.0"},
+		{"Mozilla Public License Version 2.0", "MPL-2.0"},
+		{"MIT License\n\nPermission is hereby granted, free of charge, to any person", "MIT"},
+		{"Redistribution and use in source and binary forms, with or without modification", "BSD-2-Clause"},
+		{"Redistribution and use in source and binary forms...\nNeither the name of the copyright holder", "BSD-3-Clause"},
+		{"This is free and unencumbered software released into the public domain.", "Unlicense"},
+		{"All rights reserved.", ""},
+	}
+
+	for _, test := range testList {
+		if license := detectLicense(test.text); license != test.license {
+			t.Errorf("detectLicense(%q) = %q, want %q", test.text, license, test.license)
+		}
+	}
+}
+
+func TestGeneratePlacements(t *testing.T) {
+	testList := []struct {
+		fileList      []string
+		placementList []placementStruct
+	}{
+		{
+			[]string{"tooth.json", "README.md", "LICENSE", ".gitignore", "old.tth"},
+			[]placementStruct{},
+		},
+		{
+			[]string{"tooth.json", "plugin.dll", "plugins/a.js", "plugins/sub/b.js", "config/c.json"},
+			[]placementStruct{
+				{Source: "config/*", Destination: "config/*"},
+				{Source: "plugin.dll", Destination: "plugin.dll"},
+				{Source: "plugins/*", Destination: "plugins/*"},
+			},
+		},
+		{
+			[]string{"tooth.json", ".toothignore", "a.txt", "b.log", "logs/c.log"},
+			[]placementStruct{
+				{Source: "a.txt", Destination: "a.txt"},
+			},
+		},
+	}
+
+	for index, test := range testList {
+		dirPath := t.TempDir()
+		for _, file := range test.fileList {
+			content := ""
+			if file == ".toothignore" {
+				content = "*.log\n"
+			}
+
+			filePath := filepath.Join(dirPath, filepath.FromSlash(file))
+			os.MkdirAll(filepath.Dir(filePath), 0755)
+			err := os.WriteFile(filePath, []byte(content), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		placementList, err := generatePlacements(dirPath)
+		if err != nil {
+			t.Fatalf("error at test %d: %s", index, err.Error())
+		}
+
+		if !reflect.DeepEqual(placementList, test.placementList) {
+			t.Errorf("wrong placements at test %d: %v, want %v", index, placementList, test.placementList)
+		}
+	}
+}
+
+func TestInferVersion(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not found in PATH")
+	}
+
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("USERPROFILE", homeDir)
+
+	workingDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(workingDir)
+
+	testList := []struct {
+		tag     string
+		version string
+	}{
+		{"", "0.1.0"},
+		{"v1.2.3", "1.2.3"},
+		{"2.0.0-beta.1", "2.0.0-beta.1"},
+		{"release-1", "0.1.0"},
+	}
+
+	for _, test := range testList {
+		repoDir := t.TempDir()
+		os.Chdir(repoDir)
+
+		gitArgsList := [][]string{
+			{"init", "--quiet"},
+			{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "--allow-empty", "--message=init"},
+		}
+		if test.tag != "" {
+			gitArgsList = append(gitArgsList, []string{"tag", test.tag})
+		}
+
+		for _, args := range gitArgsList {
+			if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+				t.Fatalf("git %s: %s", strings.Join(args, " "), output)
+			}
+		}
+
+		if version := inferVersion(); version != test.version {
+			t.Errorf("inferVersion() with tag %q = %q, want %q", test.tag, version, test.version)
+		}
+	}
+}
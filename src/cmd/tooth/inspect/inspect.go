@@ -0,0 +1,212 @@
+package cmdliptoothinspect
+
+import (
+	"context"
+	"flag"
+
+	"github.com/liteldev/lip/download"
+	"github.com/liteldev/lip/lip"
+	"github.com/liteldev/lip/output"
+	"github.com/liteldev/lip/specifiers"
+	"github.com/liteldev/lip/tooth/toothfile"
+	"github.com/liteldev/lip/utils/logger"
+)
+
+// FlagDict is a dictionary of flags.
+type FlagDict struct {
+	helpFlag bool
+	jsonFlag bool
+}
+
+const helpMessage = `
+Usage:
+  lip tooth inspect [options] <specifier>
+
+Description:
+  Show what a tooth will do without installing it. The specifier can be a local .tth file, a URL of a .tth
+  file, a requirement specifier, a git specifier or a local tooth directory. Tooths not on the local file
+  system are downloaded to the cache.
+
+  The metadata, dependencies, placements for each platform (with wildcards expanded), possessions,
+  commands, confirmations and tool entrypoints are shown, as well as files in the archive that no
+  placement references.
+
+Options:
+  -h, --help                  Show help.
+  --json                      Same as "lip --format json tooth inspect".`
+
+// Run is the entry point.
+func Run(args []string) {
+	flagSet := flag.NewFlagSet("inspect", flag.ExitOnError)
+
+	// Rewrite the default usage message.
+	flagSet.Usage = func() {
+		logger.Info(helpMessage)
+	}
+
+	var flagDict FlagDict
+	flagSet.BoolVar(&flagDict.helpFlag, "help", false, "")
+	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
+	flagSet.BoolVar(&flagDict.jsonFlag, "json", false, "")
+	flagSet.Parse(args)
+
+	// Help flag has the highest priority.
+	if flagDict.helpFlag {
+		logger.Info(helpMessage)
+		return
+	}
+
+	// The json flag is kept for compatibility.
+	if flagDict.jsonFlag {
+		output.SetFormat(output.JSONFormat)
+	}
+
+	if flagSet.NArg() != 1 {
+		output.Fail("the specifier should be exactly one")
+	}
+
+	specifier, err := specifiers.New(context.Background(), flagSet.Arg(0))
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	_, toothFilePath, err := lip.GetTooth(context.Background(), specifier, download.StyleDefault)
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	toothFile, err := toothfile.New(toothFilePath)
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	fileList, err := toothFile.FileList()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	report := newReport(toothFile.Metadata(), fileList)
+
+	showReport(report)
+
+	output.Print(report)
+}
+
+// showReport shows the report in human readable format.
+func showReport(report reportType) {
+	logger.Info("Tooth information:")
+	logger.Info("  Tooth-path: " + report.Tooth)
+	logger.Info("  Version: " + report.Version)
+	logger.Info("  Name: " + report.Name)
+	logger.Info("  Description: " + report.Description)
+	logger.Info("  Author: " + report.Author)
+	logger.Info("  License: " + report.License)
+	logger.Info("  Homepage: " + report.Homepage)
+	logger.Info("")
+
+	if len(report.Dependencies) > 0 {
+		logger.Info("Dependencies:")
+		for _, toothPath := range sortedKeys(report.Dependencies) {
+			logger.Info("  " + toothPath + " " + report.Dependencies[toothPath])
+		}
+		logger.Info("")
+	}
+
+	if len(report.OptionalDependencies) > 0 {
+		logger.Info("Extras:")
+		for _, extra := range sortedKeys(report.OptionalDependencies) {
+			logger.Info("  " + extra)
+			for _, toothPath := range sortedKeys(report.OptionalDependencies[extra]) {
+				logger.Info("    " + toothPath + " " + report.OptionalDependencies[extra][toothPath])
+			}
+		}
+		logger.Info("")
+	}
+
+	if len(report.Conflicts) > 0 {
+		logger.Info("Conflicts:")
+		for _, toothPath := range sortedKeys(report.Conflicts) {
+			logger.Info("  " + toothPath + " " + report.Conflicts[toothPath])
+		}
+		logger.Info("")
+	}
+
+	if len(report.Provides) > 0 {
+		logger.Info("Provides:")
+		for _, toothPath := range sortedKeys(report.Provides) {
+			logger.Info("  " + toothPath + "@" + report.Provides[toothPath])
+		}
+		logger.Info("")
+	}
+
+	if len(report.Replaces) > 0 {
+		logger.Info("Replaces:")
+		for _, toothPath := range report.Replaces {
+			logger.Info("  " + toothPath)
+		}
+		logger.Info("")
+	}
+
+	for _, platformName := range sortedKeys(report.Placement) {
+		logger.Info("Placements for " + platformName + ":")
+		if len(report.Placement[platformName]) == 0 {
+			logger.Info("  (none)")
+		}
+		for _, placement := range report.Placement[platformName] {
+			logger.Info("  " + placement.Source + " -> " + placement.Destination)
+		}
+		logger.Info("")
+	}
+
+	if len(report.Possession) > 0 {
+		logger.Info("Possessions:")
+		for _, possession := range report.Possession {
+			logger.Info("  " + possession)
+		}
+		logger.Info("")
+	}
+
+	if len(report.Commands) > 0 {
+		logger.Info("Commands:")
+		for _, command := range report.Commands {
+			logger.Info("  " + command.Type + " (" + command.Platform + "):")
+			for _, commandLine := range command.Commands {
+				logger.Info("    " + commandLine)
+			}
+		}
+		logger.Info("")
+	}
+
+	if len(report.Confirmation) > 0 {
+		logger.Info("Confirmations:")
+		for _, confirmation := range report.Confirmation {
+			logger.Info("  " + confirmation.Type + " (" + confirmation.Platform + "): " + confirmation.Message)
+		}
+		logger.Info("")
+	}
+
+	if report.Tool != nil {
+		logger.Info("Tool:")
+		logger.Info("  Name: " + report.Tool.Name)
+		logger.Info("  Description: " + report.Tool.Description)
+		logger.Info("  Entrypoints:")
+		for _, entrypoint := range report.Tool.Entrypoints {
+			logger.Info("    " + entrypoint.Path + " (" + entrypoint.Platform + ")")
+		}
+		for _, subcommand := range report.Tool.Subcommands {
+			logger.Info("  Subcommand " + subcommand.Name + ": " + subcommand.Description)
+			for _, entrypoint := range subcommand.Entrypoints {
+				logger.Info("    " + entrypoint.Path + " (" + entrypoint.Platform + ")")
+			}
+		}
+		logger.Info("")
+	}
+
+	if len(report.UnreferencedFiles) > 0 {
+		logger.Info("Files not referenced by any placement:")
+		for _, file := range report.UnreferencedFiles {
+			logger.Info("  " + file)
+		}
+		logger.Info("")
+	}
+}
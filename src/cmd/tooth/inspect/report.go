@@ -0,0 +1,250 @@
+package cmdliptoothinspect
+
+import (
+	"sort"
+
+	"github.com/liteldev/lip/tooth/toothmetadata"
+	"github.com/liteldev/lip/utils/platform"
+	"github.com/liteldev/lip/utils/versions/versionmatch"
+)
+
+// reportType is the result of inspecting a tooth.
+type reportType struct {
+	Tooth                string                       `json:"tooth"`
+	Version              string                       `json:"version"`
+	Name                 string                       `json:"name"`
+	Description          string                       `json:"description"`
+	Author               string                       `json:"author"`
+	License              string                       `json:"license"`
+	Homepage             string                       `json:"homepage"`
+	Dependencies         map[string]string            `json:"dependencies"`
+	OptionalDependencies map[string]map[string]string `json:"optional-dependencies"`
+	Conflicts            map[string]string            `json:"conflicts"`
+	Provides             map[string]string            `json:"provides"`
+	Replaces             []string                     `json:"replaces"`
+	// Placement maps platforms in the form of GOOS/GOARCH to the placements
+	// for them. "*" stands for any other GOOS or GOARCH.
+	Placement         map[string][]placementReportType `json:"placement"`
+	Possession        []string                         `json:"possession"`
+	Commands          []commandReportType              `json:"commands"`
+	Confirmation      []confirmationReportType         `json:"confirmation"`
+	Tool              *toolReportType                  `json:"tool,omitempty"`
+	UnreferencedFiles []string                         `json:"unreferenced-files"`
+}
+
+type placementReportType struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+type commandReportType struct {
+	Type     string   `json:"type"`
+	Platform string   `json:"platform"`
+	Commands []string `json:"commands"`
+}
+
+type confirmationReportType struct {
+	Type     string `json:"type"`
+	Platform string `json:"platform"`
+	Message  string `json:"message"`
+}
+
+type toolReportType struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Entrypoints []entrypointReportType `json:"entrypoints"`
+	Subcommands []subcommandReportType `json:"subcommands"`
+}
+
+type subcommandReportType struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Entrypoints []entrypointReportType `json:"entrypoints"`
+}
+
+type entrypointReportType struct {
+	Path     string `json:"path"`
+	Platform string `json:"platform"`
+}
+
+// newReport creates a report from the metadata with wildcard placements
+// expanded and the list of files in the tooth.
+func newReport(metadata toothmetadata.Metadata, fileList []string) reportType {
+	report := reportType{
+		Tooth:                metadata.ToothPath,
+		Version:              metadata.Version.String(),
+		Name:                 metadata.Information.Name,
+		Description:          metadata.Information.Description,
+		Author:               metadata.Information.Author,
+		License:              metadata.Information.License,
+		Homepage:             metadata.Information.Homepage,
+		Dependencies:         make(map[string]string),
+		OptionalDependencies: make(map[string]map[string]string),
+		Conflicts:            make(map[string]string),
+		Provides:             make(map[string]string),
+		Replaces:             make([]string, 0),
+		Placement:            make(map[string][]placementReportType),
+		Possession:           make([]string, 0),
+		Commands:             make([]commandReportType, 0),
+		Confirmation:         make([]confirmationReportType, 0),
+		UnreferencedFiles:    make([]string, 0),
+	}
+
+	for toothPath, versionRange := range metadata.Dependencies {
+		report.Dependencies[toothPath] = versionmatch.RangeString(versionRange)
+	}
+
+	for extra, dependencies := range metadata.OptionalDependencies {
+		report.OptionalDependencies[extra] = make(map[string]string)
+		for toothPath, versionRange := range dependencies {
+			report.OptionalDependencies[extra][toothPath] = versionmatch.RangeString(versionRange)
+		}
+	}
+
+	for toothPath, versionRange := range metadata.Conflicts {
+		report.Conflicts[toothPath] = versionmatch.RangeString(versionRange)
+	}
+
+	for toothPath, version := range metadata.Provides {
+		report.Provides[toothPath] = version.String()
+	}
+
+	report.Replaces = append(report.Replaces, metadata.Replaces...)
+
+	// Resolve placements for each GOOS and GOARCH mentioned by placements.
+	goosList := make([]string, 0)
+	goarchList := make([]string, 0)
+	for _, placement := range metadata.Placement {
+		goosList = appendUnique(goosList, placement.GOOS)
+		goarchList = appendUnique(goarchList, placement.GOARCH)
+	}
+	if len(goosList) == 0 {
+		goosList = append(goosList, "")
+	}
+	if len(goarchList) == 0 {
+		goarchList = append(goarchList, "")
+	}
+
+	for _, goos := range goosList {
+		for _, goarch := range goarchList {
+			placementList := make([]placementReportType, 0)
+			for _, placement := range metadata.Placement {
+				if !platform.MatchPlatform(placement.GOOS, placement.GOARCH, goos, goarch) {
+					continue
+				}
+
+				placementList = append(placementList, placementReportType{
+					Source:      placement.Source,
+					Destination: placement.Destination,
+				})
+			}
+
+			report.Placement[platformString(goos, goarch)] = placementList
+		}
+	}
+
+	report.Possession = append(report.Possession, metadata.Possession...)
+
+	for _, command := range metadata.Commands {
+		report.Commands = append(report.Commands, commandReportType{
+			Type:     command.Type,
+			Platform: platformString(command.GOOS, command.GOARCH),
+			Commands: command.Commands,
+		})
+	}
+
+	for _, confirmation := range metadata.Confirmation {
+		report.Confirmation = append(report.Confirmation, confirmationReportType{
+			Type:     confirmation.Type,
+			Platform: platformString(confirmation.GOOS, confirmation.GOARCH),
+			Message:  confirmation.Message,
+		})
+	}
+
+	if metadata.IsTool() {
+		report.Tool = &toolReportType{
+			Name:        metadata.Tool.Name,
+			Description: metadata.Tool.Description,
+			Entrypoints: entrypointReports(metadata.Tool.Entrypoints),
+			Subcommands: make([]subcommandReportType, 0),
+		}
+		for name, subcommand := range metadata.Tool.Subcommands {
+			report.Tool.Subcommands = append(report.Tool.Subcommands, subcommandReportType{
+				Name:        name,
+				Description: subcommand.Description,
+				Entrypoints: entrypointReports(subcommand.Entrypoints),
+			})
+		}
+		sort.Slice(report.Tool.Subcommands, func(i, j int) bool {
+			return report.Tool.Subcommands[i].Name < report.Tool.Subcommands[j].Name
+		})
+	}
+
+	// Find files not referenced by any placement. tooth.json is always included
+	// in tooths, so it is not reported.
+	referencedFileMap := make(map[string]bool)
+	for _, placement := range metadata.Placement {
+		referencedFileMap[placement.Source] = true
+	}
+	for _, file := range fileList {
+		if file != "tooth.json" && !referencedFileMap[file] {
+			report.UnreferencedFiles = append(report.UnreferencedFiles, file)
+		}
+	}
+
+	return report
+}
+
+// appendUnique appends a non-empty value to the list if it is not in the list.
+func appendUnique(list []string, value string) []string {
+	if value == "" {
+		return list
+	}
+
+	for _, item := range list {
+		if item == value {
+			return list
+		}
+	}
+
+	return append(list, value)
+}
+
+// platformString returns the platform in the form of GOOS/GOARCH. Empty GOOS
+// or GOARCH is shown as "*".
+func platformString(goos string, goarch string) string {
+	if goos == "" {
+		goos = "*"
+	}
+
+	if goarch == "" {
+		goarch = "*"
+	}
+
+	return goos + "/" + goarch
+}
+
+// sortedKeys returns the keys of a map in ascending order.
+func sortedKeys[T any](m map[string]T) []string {
+	keyList := make([]string, 0, len(m))
+	for key := range m {
+		keyList = append(keyList, key)
+	}
+	sort.Strings(keyList)
+
+	return keyList
+}
+
+// entrypointReports creates reports of the entrypoints of a tool or a
+// subcommand.
+func entrypointReports(entrypoints []toothmetadata.ToolEntrypointStruct) []entrypointReportType {
+	reportList := make([]entrypointReportType, 0, len(entrypoints))
+	for _, entrypoint := range entrypoints {
+		reportList = append(reportList, entrypointReportType{
+			Path:     entrypoint.Path,
+			Platform: platformString(entrypoint.GOOS, entrypoint.GOARCH),
+		})
+	}
+
+	return reportList
+}
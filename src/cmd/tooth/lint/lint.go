@@ -0,0 +1,144 @@
+package cmdliptoothlint
+
+import (
+	"context"
+	"flag"
+	"os"
+	"strconv"
+
+	"github.com/liteldev/lip/output"
+	"github.com/liteldev/lip/tooth/toothlint"
+	"github.com/liteldev/lip/tooth/toothrepo"
+	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/utils/versions"
+)
+
+// FlagDict is a dictionary of flags.
+type FlagDict struct {
+	helpFlag    bool
+	jsonFlag    bool
+	offlineFlag bool
+	strictFlag  bool
+}
+
+const helpMessage = `
+Usage:
+  lip tooth lint [options] [path]
+
+Description:
+  Check tooth.json in the current directory (or at [path]) for problems that the JSON schema cannot catch:
+  unknown GOOS/GOARCH values, destinations escaping the workspace, wildcards on only one side of a
+  placement, duplicate destinations, possessions not ending with "/" and dependency ranges that no
+  published version satisfies. Each finding is reported with a JSON pointer, a severity and a suggestion.
+  The command exits with a non-zero code if any error is found, so it can be used in CI.
+
+Options:
+  -h, --help                  Show help.
+  --json                      Same as "lip --format json tooth lint".
+  --offline                   Do not check dependency ranges against published versions.
+  --strict                    Exit with a non-zero code on warnings as well.`
+
+// Run is the entry point.
+func Run(args []string) {
+	flagSet := flag.NewFlagSet("lint", flag.ExitOnError)
+
+	// Rewrite the default usage message.
+	flagSet.Usage = func() {
+		logger.Info(helpMessage)
+	}
+
+	var flagDict FlagDict
+	flagSet.BoolVar(&flagDict.helpFlag, "help", false, "")
+	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
+	flagSet.BoolVar(&flagDict.jsonFlag, "json", false, "")
+	flagSet.BoolVar(&flagDict.offlineFlag, "offline", false, "")
+	flagSet.BoolVar(&flagDict.strictFlag, "strict", false, "")
+	flagSet.Parse(args)
+
+	// Help flag has the highest priority.
+	if flagDict.helpFlag {
+		logger.Info(helpMessage)
+		return
+	}
+
+	// The json flag is kept for compatibility.
+	if flagDict.jsonFlag {
+		output.SetFormat(output.JSONFormat)
+	}
+
+	toothJSONPath := "tooth.json"
+	switch flagSet.NArg() {
+	case 0:
+	case 1:
+		toothJSONPath = flagSet.Arg(0)
+		if fileInfo, err := os.Stat(toothJSONPath); err == nil && fileInfo.IsDir() {
+			toothJSONPath = toothJSONPath + "/tooth.json"
+		}
+	default:
+		output.Fail("Too many arguments.")
+	}
+
+	jsonData, err := os.ReadFile(toothJSONPath)
+	if err != nil {
+		output.Fail("Cannot read " + toothJSONPath + ": " + err.Error())
+	}
+
+	var fetchVersionList toothlint.VersionListFetcher
+	if !flagDict.offlineFlag {
+		fetchVersionList = func(toothPath string) ([]versions.Version, error) {
+			return toothrepo.FetchModuleVersionList(context.Background(), toothPath)
+		}
+	}
+
+	findingList := toothlint.Lint(jsonData, fetchVersionList)
+
+	errorCount := 0
+	warningCount := 0
+	for _, finding := range findingList {
+		switch finding.Severity {
+		case toothlint.SeverityError:
+			errorCount++
+		case toothlint.SeverityWarning:
+			warningCount++
+		}
+	}
+
+	for _, finding := range findingList {
+		pointer := finding.Pointer
+		if pointer == "" {
+			pointer = "(root)"
+		}
+
+		message := pointer + ": " + finding.Message
+		if finding.Suggestion != "" {
+			message += "\n  Suggestion: " + finding.Suggestion
+		}
+
+		switch finding.Severity {
+		case toothlint.SeverityError:
+			logger.Error(message)
+		case toothlint.SeverityWarning:
+			logger.Warning(message)
+		}
+	}
+
+	if findingList == nil {
+		findingList = []toothlint.Finding{}
+	}
+
+	if len(findingList) == 0 {
+		logger.Info("No problems found in " + toothJSONPath + ".")
+		output.Print(findingList)
+		return
+	}
+
+	summary := "Found " + strconv.Itoa(errorCount) + " error(s) and " +
+		strconv.Itoa(warningCount) + " warning(s) in " + toothJSONPath + "."
+
+	if errorCount > 0 || (flagDict.strictFlag && warningCount > 0) {
+		output.FailWith(findingList, summary)
+	}
+
+	logger.Info(summary)
+	output.Print(findingList)
+}
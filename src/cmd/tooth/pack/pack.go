@@ -0,0 +1,86 @@
+package cmdliptoothpack
+
+import (
+	"flag"
+	"path"
+	"strconv"
+
+	"github.com/liteldev/lip/output"
+	"github.com/liteldev/lip/tooth/toothpack"
+	"github.com/liteldev/lip/utils/logger"
+)
+
+// FlagDict is a dictionary of flags.
+type FlagDict struct {
+	helpFlag   bool
+	outputFlag string
+}
+
+const helpMessage = `
+Usage:
+  lip tooth pack [options]
+
+Description:
+  Pack the tooth in the current directory into a .tth file. tooth.json is validated and the source of every
+  placement must exist. Files matching the rules in .toothignore are not packed. The archive is
+  reproducible: identical inputs give byte-identical output.
+
+Options:
+  -h, --help                  Show help.
+  -o, --output <file>         The path of the .tth file. Defaults to <tooth name>-<version>.tth.`
+
+// Run is the entry point.
+func Run(args []string) {
+	flagSet := flag.NewFlagSet("pack", flag.ExitOnError)
+
+	// Rewrite the default usage message.
+	flagSet.Usage = func() {
+		logger.Info(helpMessage)
+	}
+
+	var flagDict FlagDict
+	flagSet.BoolVar(&flagDict.helpFlag, "help", false, "")
+	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
+	flagSet.StringVar(&flagDict.outputFlag, "output", "", "")
+	flagSet.StringVar(&flagDict.outputFlag, "o", "", "")
+	flagSet.Parse(args)
+
+	// Help flag has the highest priority.
+	if flagDict.helpFlag {
+		logger.Info(helpMessage)
+		return
+	}
+
+	// No other arguments are supported.
+	if flagSet.NArg() > 0 {
+		output.Fail("Too many arguments.")
+	}
+
+	logger.Info("Validating tooth.json...")
+
+	metadata, err := toothpack.ReadMetadata(".")
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	outputPath := flagDict.outputFlag
+	if outputPath == "" {
+		outputPath = path.Base(metadata.ToothPath) + "-" + metadata.Version.String() + ".tth"
+	}
+
+	logger.Info("Packing " + metadata.ToothPath + "@" + metadata.Version.String() + "...")
+
+	fileList, err := toothpack.Pack(".", outputPath)
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	logger.Info("Packed " + strconv.Itoa(len(fileList)) + " files into " + outputPath + ".")
+
+	output.Print(map[string]interface{}{
+		"tooth":   metadata.ToothPath,
+		"version": metadata.Version.String(),
+		"file":    outputPath,
+		"files":   fileList,
+	})
+}
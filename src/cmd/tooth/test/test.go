@@ -0,0 +1,117 @@
+package cmdliptoothtest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/liteldev/lip/output"
+	"github.com/liteldev/lip/tooth/toothpack"
+	"github.com/liteldev/lip/utils/logger"
+)
+
+// FlagDict is a dictionary of flags.
+type FlagDict struct {
+	helpFlag           bool
+	targetOSFlag       string
+	targetArchFlag     string
+	noDependenciesFlag bool
+	keepFlag           bool
+}
+
+const helpMessage = `
+Usage:
+  lip tooth test [options]
+
+Description:
+  Check that the tooth in the current directory can be installed and uninstalled cleanly. The tooth is
+  packed and installed into a temporary workspace, where its hooks run. Then the tooth is uninstalled.
+  The following problems are reported:
+
+  - failed install or uninstall commands (hooks).
+  - files written outside the placements and possessions of installed tooths.
+  - files and directories left over after uninstalling.
+
+Options:
+  -h, --help                  Show help.
+  --target-os <GOOS>          Install for another GOOS. Commands are not run for other platforms.
+  --target-arch <GOARCH>      Install for another GOARCH. Commands are not run for other platforms.
+  --no-dependencies           Do not install dependencies.
+  --keep                      Keep the temporary workspace for inspection.`
+
+// Run is the entry point.
+func Run(args []string) {
+	flagSet := flag.NewFlagSet("test", flag.ExitOnError)
+
+	// Rewrite the default usage message.
+	flagSet.Usage = func() {
+		logger.Info(helpMessage)
+	}
+
+	var flagDict FlagDict
+	flagSet.BoolVar(&flagDict.helpFlag, "help", false, "")
+	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
+	flagSet.StringVar(&flagDict.targetOSFlag, "target-os", "", "")
+	flagSet.StringVar(&flagDict.targetArchFlag, "target-arch", "", "")
+	flagSet.BoolVar(&flagDict.noDependenciesFlag, "no-dependencies", false, "")
+	flagSet.BoolVar(&flagDict.keepFlag, "keep", false, "")
+	flagSet.Parse(args)
+
+	// Help flag has the highest priority.
+	if flagDict.helpFlag {
+		logger.Info(helpMessage)
+		return
+	}
+
+	// No other arguments are supported.
+	if flagSet.NArg() > 0 {
+		output.Fail("Too many arguments.")
+	}
+
+	// Set the target platform for the install and uninstall processes.
+	if flagDict.targetOSFlag != "" {
+		os.Setenv("LIP_TARGET_OS", flagDict.targetOSFlag)
+	}
+	if flagDict.targetArchFlag != "" {
+		os.Setenv("LIP_TARGET_ARCH", flagDict.targetArchFlag)
+	}
+
+	metadata, err := toothpack.ReadMetadata(".")
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	tempDir, err := os.MkdirTemp("", "lip-tooth-test-")
+	if err != nil {
+		output.Fail("failed to create temporary directory: " + err.Error())
+	}
+
+	workspaceDir := filepath.Join(tempDir, "workspace")
+	err = os.Mkdir(workspaceDir, 0755)
+	if err != nil {
+		output.Fail("failed to create temporary workspace: " + err.Error())
+	}
+
+	problemList, err := testTooth(metadata, tempDir, workspaceDir, flagDict.noDependenciesFlag)
+
+	if flagDict.keepFlag {
+		logger.Info("The temporary workspace is kept at " + workspaceDir + ".")
+	} else {
+		os.RemoveAll(tempDir)
+	}
+
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	if len(problemList) > 0 {
+		for _, problem := range problemList {
+			logger.Error("  " + problem)
+		}
+		output.FailWith(problemList, "Found "+strconv.Itoa(len(problemList))+" problem(s).")
+	}
+
+	logger.Info("No problems found.")
+	output.Print([]string{})
+}
@@ -0,0 +1,256 @@
+package cmdliptoothtest
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/liteldev/lip/output"
+	"github.com/liteldev/lip/tooth/toothmetadata"
+	"github.com/liteldev/lip/tooth/toothpack"
+	"github.com/liteldev/lip/tooth/toothrecord"
+	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/utils/platform"
+)
+
+// commandFailurePrefix is the prefix of the error message of a failed command
+// in the output of lip install and lip uninstall.
+const commandFailurePrefix = "failed to run command: "
+
+// testTooth packs the tooth, installs it into the workspace, uninstalls it and
+// returns the problems found.
+func testTooth(metadata toothmetadata.Metadata, tempDir string, workspaceDir string,
+	isNoDependencies bool) ([]string, error) {
+	problemList := make([]string, 0)
+
+	// 1. Pack the tooth.
+
+	logger.Info("Packing " + metadata.ToothPath + "@" + metadata.Version.String() + "...")
+
+	toothFilePath := filepath.Join(tempDir, path.Base(metadata.ToothPath)+"-"+metadata.Version.String()+".tth")
+	_, err := toothpack.Pack(".", toothFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Install the tooth.
+
+	logger.Info("Installing into " + workspaceDir + " for " + platform.String() + "...")
+
+	installArgs := []string{"install", "--yes", "--numeric-progress"}
+	if isNoDependencies {
+		installArgs = append(installArgs, "--no-dependencies")
+	}
+	installArgs = append(installArgs, toothFilePath)
+
+	output, err := runLip(workspaceDir, installArgs...)
+	for _, command := range failedCommands(output) {
+		problemList = append(problemList, "install command failed: "+command)
+	}
+	if err != nil {
+		return nil, errors.New("failed to install the tooth: " + err.Error())
+	}
+
+	// 3. Check files written outside placements and possessions.
+
+	installedPathList, err := snapshot(workspaceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	recordList, err := listRecords(workspaceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range installedPathList {
+		if !isOwned(p, recordList) {
+			problemList = append(problemList, "written outside placements and possessions: "+p)
+		}
+	}
+
+	// 4. Uninstall the tooth.
+
+	logger.Info("Uninstalling " + metadata.ToothPath + "...")
+
+	output, err = runLip(workspaceDir, "uninstall", "--yes", metadata.ToothPath)
+	for _, command := range failedCommands(output) {
+		problemList = append(problemList, "uninstall command failed: "+command)
+	}
+	if err != nil {
+		problemList = append(problemList, "failed to uninstall the tooth: "+err.Error())
+		return problemList, nil
+	}
+
+	// 5. Check leftovers. Files of dependencies still installed are not leftovers.
+
+	remainingPathList, err := snapshot(workspaceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	remainingRecordList, err := listRecords(workspaceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range remainingPathList {
+		if isOwned(p, remainingRecordList) {
+			continue
+		}
+
+		// Report directories only if they are empty, or their files are reported instead.
+		if strings.HasSuffix(p, "/") && hasChild(p, remainingPathList) {
+			continue
+		}
+
+		problemList = append(problemList, "left over after uninstalling: "+p)
+	}
+
+	return problemList, nil
+}
+
+// failedCommands returns the commands reported as failed in the output of Lip.
+func failedCommands(output []byte) []string {
+	commandList := make([]string, 0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if index := strings.Index(line, commandFailurePrefix); index >= 0 {
+			commandList = append(commandList, strings.TrimSpace(line[index+len(commandFailurePrefix):]))
+		}
+	}
+
+	return commandList
+}
+
+// hasChild returns true if any path in the list is under the directory.
+func hasChild(dirPath string, pathList []string) bool {
+	for _, p := range pathList {
+		if p != dirPath && strings.HasPrefix(p, dirPath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isOwned returns true if the path is the destination of a placement for the
+// target platform, an ancestor directory of such a destination, or inside a
+// possession of any of the records.
+func isOwned(p string, recordList []toothrecord.Record) bool {
+	for _, record := range recordList {
+		for _, placement := range record.Placement {
+			if !platform.Match(placement.GOOS, placement.GOARCH) {
+				continue
+			}
+
+			destination := path.Clean(placement.Destination)
+			if p == destination || (strings.HasSuffix(p, "/") && strings.HasPrefix(destination, p)) {
+				return true
+			}
+		}
+
+		for _, possession := range record.Possession {
+			possession = path.Clean(possession) + "/"
+			if strings.HasPrefix(p, possession) || strings.HasPrefix(possession, p) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// listRecords lists the records of tooths installed in the workspace.
+func listRecords(workspaceDir string) ([]toothrecord.Record, error) {
+	recordDir := filepath.Join(workspaceDir, ".lip", "records")
+
+	entryList, err := os.ReadDir(recordDir)
+	if err != nil {
+		return nil, errors.New("failed to read records in " + recordDir + ": " + err.Error())
+	}
+
+	recordList := make([]toothrecord.Record, 0)
+	for _, entry := range entryList {
+		record, err := toothrecord.NewFromFile(filepath.Join(recordDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		recordList = append(recordList, record)
+	}
+
+	return recordList, nil
+}
+
+// runLip runs Lip itself in the workspace. The output is shown and returned.
+func runLip(workspaceDir string, args ...string) ([]byte, error) {
+	lipPath, err := os.Executable()
+	if err != nil {
+		return nil, errors.New("failed to find the Lip executable: " + err.Error())
+	}
+
+	var outputBuffer bytes.Buffer
+	cmd := exec.Command(lipPath, args...)
+	cmd.Dir = workspaceDir
+	// Do not redirect to the Lip version of the workspace, and do not look for
+	// a workspace in ancestor directories.
+	cmd.Env = append(os.Environ(), "LIP_REDIRECTED=1", "LIP_WORKSPACE="+workspaceDir)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = io.MultiWriter(output.CommandStdout(), &outputBuffer)
+	cmd.Stderr = io.MultiWriter(os.Stderr, &outputBuffer)
+
+	err = cmd.Run()
+
+	return outputBuffer.Bytes(), err
+}
+
+// snapshot lists all files and directories in the workspace except the .lip
+// directory, relative to the workspace and separated by slashes. Directories
+// end with "/".
+func snapshot(workspaceDir string) ([]string, error) {
+	pathList := make([]string, 0)
+
+	err := filepath.WalkDir(workspaceDir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(workspaceDir, filePath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if relPath == "." {
+			return nil
+		}
+
+		if d.IsDir() {
+			if relPath == ".lip" {
+				return filepath.SkipDir
+			}
+			pathList = append(pathList, relPath+"/")
+			return nil
+		}
+
+		pathList = append(pathList, relPath)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New("failed to list files in " + workspaceDir + ": " + err.Error())
+	}
+
+	sort.Strings(pathList)
+
+	return pathList, nil
+}
@@ -0,0 +1,81 @@
+package cmdliptoothtest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/liteldev/lip/tooth/toothrecord"
+	"github.com/liteldev/lip/utils/platform"
+)
+
+func TestFailedCommands(t *testing.T) {
+	testList := []struct {
+		output      string
+		commandList []string
+	}{
+		{"", []string{}},
+		{"Installing tooths...\nSuccessfully installed all tooth files.\n", []string{}},
+		{
+			"ERROR failed to run command: echo a\n" +
+				"some output\n" +
+				"WARNING  failed to run command: exit 1  \r\n",
+			[]string{"echo a", "exit 1"},
+		},
+	}
+
+	for index, test := range testList {
+		commandList := failedCommands([]byte(test.output))
+		if !reflect.DeepEqual(commandList, test.commandList) {
+			t.Errorf("wrong commands at test %d: %q, want %q", index, commandList, test.commandList)
+		}
+	}
+}
+
+func TestIsOwned(t *testing.T) {
+	otherGOOS := "linux"
+	if platform.GOOS() == "linux" {
+		otherGOOS = "windows"
+	}
+
+	recordList := []toothrecord.Record{
+		{
+			ToothPath: "example.com/a",
+			Placement: []toothrecord.PlacementStruct{
+				{Source: "a.dll", Destination: "plugins/a/a.dll"},
+				{Source: "a.so", Destination: "plugins/a/a.so", GOOS: otherGOOS},
+				{Source: "b.txt", Destination: "./docs/b.txt", GOOS: platform.GOOS()},
+			},
+			Possession: []string{"data/a/"},
+		},
+		{
+			ToothPath:  "example.com/b",
+			Possession: []string{"worlds"},
+		},
+	}
+
+	testList := []struct {
+		path    string
+		isOwned bool
+	}{
+		{"plugins/a/a.dll", true},
+		{"plugins/", true},
+		{"plugins/a/", true},
+		{"plugins/a/a.so", false},
+		{"plugins/b/", false},
+		{"docs/b.txt", true},
+		{"docs/", true},
+		{"data/", true},
+		{"data/a/", true},
+		{"data/a/config.json", true},
+		{"data/ab.json", false},
+		{"worlds/level.dat", true},
+		{"worlds2/", false},
+		{"other.txt", false},
+	}
+
+	for _, test := range testList {
+		if isOwned := isOwned(test.path, recordList); isOwned != test.isOwned {
+			t.Errorf("isOwned(%q) = %v, want %v", test.path, isOwned, test.isOwned)
+		}
+	}
+}
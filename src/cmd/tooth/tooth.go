@@ -5,6 +5,13 @@ import (
 	"os"
 
 	cmdliptoothinit "github.com/liteldev/lip/cmd/tooth/init"
+	cmdliptoothinspect "github.com/liteldev/lip/cmd/tooth/inspect"
+	cmdliptoothlint "github.com/liteldev/lip/cmd/tooth/lint"
+	cmdliptoothpack "github.com/liteldev/lip/cmd/tooth/pack"
+	cmdliptoothtest "github.com/liteldev/lip/cmd/tooth/test"
+	cmdliptoothversion "github.com/liteldev/lip/cmd/tooth/version"
+	"github.com/liteldev/lip/localfile"
+	"github.com/liteldev/lip/output"
 	"github.com/liteldev/lip/utils/logger"
 )
 
@@ -20,6 +27,11 @@ Usage:
 
 Commands:
   init                        Initialize and writes a new tooth.json file in the current directory.
+  inspect                     Show what a tooth will do without installing it.
+  lint                        Check tooth.json in the current directory for semantic problems.
+  pack                        Pack the tooth in the current directory into a reproducible .tth file.
+  test                        Check that the tooth in the current directory installs and uninstalls cleanly.
+  version                     Show or increment the version of the tooth in the current directory.
 
 Options:
   -h, --help                  Show help.`
@@ -44,15 +56,46 @@ func Run(args []string) {
 		return
 	}
 
+	// Tooth commands work on the tooth in the directory where Lip was started
+	// rather than the workspace.
+	workingDir, err := localfile.WorkingDir()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+	err = os.Chdir(workingDir)
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
 	// If there is a subcommand, run it and exit.
 	if flagSet.NArg() >= 1 {
 		switch flagSet.Arg(0) {
 		case "init":
+			output.SetCommand("tooth init")
 			cmdliptoothinit.Run(args[1:])
 			return
+		case "inspect":
+			output.SetCommand("tooth inspect")
+			cmdliptoothinspect.Run(args[1:])
+			return
+		case "lint":
+			output.SetCommand("tooth lint")
+			cmdliptoothlint.Run(args[1:])
+			return
+		case "pack":
+			output.SetCommand("tooth pack")
+			cmdliptoothpack.Run(args[1:])
+			return
+		case "test":
+			output.SetCommand("tooth test")
+			cmdliptoothtest.Run(args[1:])
+			return
+		case "version":
+			output.SetCommand("tooth version")
+			cmdliptoothversion.Run(args[1:])
+			return
 		default:
-			logger.Error("Unknown command.")
-			os.Exit(1)
+			output.Fail("Unknown command.")
 		}
 	}
 
@@ -0,0 +1,163 @@
+package cmdliptoothversionbump
+
+import (
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/liteldev/lip/output"
+	"github.com/liteldev/lip/tooth/toothgit"
+	"github.com/liteldev/lip/tooth/toothmetadata"
+	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/utils/versions"
+)
+
+// FlagDict is a dictionary of flags.
+type FlagDict struct {
+	helpFlag bool
+	preFlag  string
+	gitFlag  bool
+}
+
+const helpMessage = `
+Usage:
+  lip tooth version bump <major|minor|patch|prerelease> [options]
+
+Description:
+  Increment the version in tooth.json of the tooth in the current directory. Only the version value is
+  rewritten, so the formatting of tooth.json is kept.
+
+  major, minor and patch increment the corresponding part, or release a pre-release version whose lower
+  parts are already zero. prerelease increments the last numeric pre-release identifier, or starts a
+  pre-release of the next patch version if the version is stable.
+
+Options:
+  -h, --help                  Show help.
+  --pre <identifier>          Start a pre-release with the identifier, e.g. "beta" gives 1.0.0-beta.0.
+  --git                       Commit tooth.json and create the matching tag v<version>. The git work tree
+                              must be clean.`
+
+// Run is the entry point.
+func Run(args []string) {
+	flagSet := flag.NewFlagSet("bump", flag.ExitOnError)
+
+	// Rewrite the default usage message.
+	flagSet.Usage = func() {
+		logger.Info(helpMessage)
+	}
+
+	var flagDict FlagDict
+	flagSet.BoolVar(&flagDict.helpFlag, "help", false, "")
+	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
+	flagSet.StringVar(&flagDict.preFlag, "pre", "", "")
+	flagSet.BoolVar(&flagDict.gitFlag, "git", false, "")
+	flagSet.Parse(args)
+
+	// Flags are also allowed after the version part, e.g. "bump minor --pre beta".
+	argList := make([]string, 0)
+	for flagSet.NArg() > 0 {
+		argList = append(argList, flagSet.Arg(0))
+		flagSet.Parse(flagSet.Args()[1:])
+	}
+
+	// Help flag has the highest priority.
+	if flagDict.helpFlag {
+		logger.Info(helpMessage)
+		return
+	}
+
+	if len(argList) != 1 {
+		output.Fail("Exactly one of major, minor, patch and prerelease should be specified.")
+	}
+
+	var bumpType versions.BumpType
+	switch argList[0] {
+	case "major":
+		bumpType = versions.MajorBumpType
+	case "minor":
+		bumpType = versions.MinorBumpType
+	case "patch":
+		bumpType = versions.PatchBumpType
+	case "prerelease":
+		bumpType = versions.PreReleaseBumpType
+	default:
+		output.Fail("Unknown version part: " + argList[0] + ". Use major, minor, patch or prerelease.")
+	}
+
+	oldVersion, newVersion, err := bump(bumpType, flagDict.preFlag, flagDict.gitFlag)
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	var tag interface{}
+	if flagDict.gitFlag {
+		tag = "v" + newVersion.String()
+	}
+
+	output.Print(map[string]interface{}{
+		"from_version": oldVersion.String(),
+		"to_version":   newVersion.String(),
+		"git_tag":      tag,
+	})
+}
+
+// bump increments the version in tooth.json and optionally commits and tags it.
+// It returns the old and new versions.
+func bump(bumpType versions.BumpType, preReleaseID string, isGit bool) (versions.Version, versions.Version, error) {
+	jsonData, err := os.ReadFile("tooth.json")
+	if err != nil {
+		return versions.Version{}, versions.Version{}, err
+	}
+
+	metadata, err := toothmetadata.NewFromJSON(jsonData)
+	if err != nil {
+		return versions.Version{}, versions.Version{}, err
+	}
+
+	newVersion, err := versions.Bump(metadata.Version, bumpType, preReleaseID)
+	if err != nil {
+		return versions.Version{}, versions.Version{}, err
+	}
+
+	// Tags of GOPROXY versions are always prefixed with "v".
+	tag := "v" + newVersion.String()
+
+	// Check git before changing anything.
+	if isGit {
+		isClean, err := toothgit.IsWorkTreeClean(".")
+		if err != nil {
+			return versions.Version{}, versions.Version{}, err
+		}
+
+		if !isClean {
+			return versions.Version{}, versions.Version{}, errors.New("git work tree is not clean, please commit or stash your changes first")
+		}
+
+		if toothgit.HasTag(".", tag) {
+			return versions.Version{}, versions.Version{}, errors.New("git tag " + tag + " already exists")
+		}
+	}
+
+	newJSONData, err := toothmetadata.ReplaceVersion(jsonData, newVersion)
+	if err != nil {
+		return versions.Version{}, versions.Version{}, err
+	}
+
+	err = os.WriteFile("tooth.json", newJSONData, 0644)
+	if err != nil {
+		return versions.Version{}, versions.Version{}, errors.New("failed to write tooth.json: " + err.Error())
+	}
+
+	logger.Info(metadata.Version.String() + " -> " + newVersion.String())
+
+	if isGit {
+		err = toothgit.CommitAndTag(".", []string{"tooth.json"}, "Bump version to "+newVersion.String(), tag)
+		if err != nil {
+			return versions.Version{}, versions.Version{}, err
+		}
+
+		logger.Info("Created git commit and tag " + tag + ". Push them with \"git push --follow-tags\".")
+	}
+
+	return metadata.Version, newVersion, nil
+}
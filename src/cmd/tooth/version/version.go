@@ -0,0 +1,73 @@
+package cmdliptoothversion
+
+import (
+	"flag"
+
+	cmdliptoothversionbump "github.com/liteldev/lip/cmd/tooth/version/bump"
+	"github.com/liteldev/lip/output"
+	"github.com/liteldev/lip/tooth/toothpack"
+	"github.com/liteldev/lip/utils/logger"
+)
+
+// FlagDict is a dictionary of flags.
+type FlagDict struct {
+	helpFlag bool
+}
+
+const helpMessage = `
+Usage:
+  lip tooth version [options]
+  lip tooth version <command> [subcommand options] ...
+
+Description:
+  Show the version of the tooth in the current directory.
+
+Commands:
+  bump                        Increment the version of the tooth in the current directory.
+
+Options:
+  -h, --help                  Show help.`
+
+// Run is the entry point.
+func Run(args []string) {
+	flagSet := flag.NewFlagSet("version", flag.ExitOnError)
+
+	// Rewrite the default usage message.
+	flagSet.Usage = func() {
+		logger.Info(helpMessage)
+	}
+
+	var flagDict FlagDict
+	flagSet.BoolVar(&flagDict.helpFlag, "help", false, "")
+	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
+	flagSet.Parse(args)
+
+	// Help flag has the highest priority.
+	if flagDict.helpFlag {
+		logger.Info(helpMessage)
+		return
+	}
+
+	// If there is a subcommand, run it and exit.
+	if flagSet.NArg() >= 1 {
+		switch flagSet.Arg(0) {
+		case "bump":
+			output.SetCommand("tooth version bump")
+			cmdliptoothversionbump.Run(args[1:])
+			return
+		default:
+			output.Fail("Unknown command.")
+		}
+	}
+
+	metadata, err := toothpack.ReadMetadata(".")
+	if err != nil {
+		output.Fail(err.Error())
+	}
+
+	logger.Info(metadata.Version.String())
+
+	output.Print(map[string]interface{}{
+		"version": metadata.Version.String(),
+	})
+}
@@ -1,15 +1,15 @@
 package cmdlipuninstall
 
 import (
+	"context"
 	"flag"
 	"os"
-	"path/filepath"
-	"strings"
 
-	"github.com/liteldev/lip/localfile"
-	"github.com/liteldev/lip/registry"
-	"github.com/liteldev/lip/tooth/toothrecord"
+	"github.com/liteldev/lip/lip"
+	"github.com/liteldev/lip/output"
 	"github.com/liteldev/lip/utils/logger"
+
+	cmdlipinstall "github.com/liteldev/lip/cmd/install"
 )
 
 // FlagDict is a dictionary of flags.
@@ -17,6 +17,7 @@ type FlagDict struct {
 	helpFlag           bool
 	yesFlag            bool
 	keepPossessionFlag bool
+	globalFlag         bool
 }
 
 const helpMessage = `
@@ -29,12 +30,11 @@ Description:
 Options:
   -h, --help                  Show help.
   -y, --yes                   Skip confirmation.
-  --keep-possession           Keep files that the tooth author specified the tooth to occupy. These files are often configuration files, data files, etc.`
+  --keep-possession           Keep files that the tooth author specified the tooth to occupy. These files are often configuration files, data files, etc.
+  -g, --global                Uninstall from the global workspace in ~/.lip/global.`
 
 // Run is the entry point.
 func Run(args []string) {
-	var err error
-
 	flagSet := flag.NewFlagSet("uninstall", flag.ExitOnError)
 
 	// Rewrite the default usage message.
@@ -48,6 +48,8 @@ func Run(args []string) {
 	flagSet.BoolVar(&flagDict.yesFlag, "yes", false, "")
 	flagSet.BoolVar(&flagDict.yesFlag, "y", false, "")
 	flagSet.BoolVar(&flagDict.keepPossessionFlag, "keep-possession", false, "")
+	flagSet.BoolVar(&flagDict.globalFlag, "global", false, "")
+	flagSet.BoolVar(&flagDict.globalFlag, "g", false, "")
 	flagSet.Parse(args)
 
 	// Help flag has the highest priority.
@@ -58,102 +60,39 @@ func Run(args []string) {
 
 	// Check if there are any arguments.
 	if flagSet.NArg() == 0 {
-		logger.Error("Too few arguments")
-		os.Exit(1)
-	}
-
-	// 1. Check if all tooth paths are installed.
-
-	logger.Info("Checking if all tooth paths are installed...")
-
-	// Get tooth paths from arguments.
-	toothPathList := flagSet.Args()
-
-	// Convert all aliases to tooth paths.
-	for i, toothPath := range toothPathList {
-		if !strings.Contains(toothPath, "/") {
-			toothPathList[i], err = registry.LookupAlias(toothPath)
-			if err != nil {
-				logger.Error(err.Error())
-				os.Exit(1)
-			}
-		}
-	}
-
-	// Make a map of tooth paths.
-	// The value of the map is the name of the record file.
-	toothPathMap := make(map[string]string)
-	for _, toothPath := range toothPathList {
-		toothPathMap[strings.ToLower(toothPath)] = ""
-	}
-
-	// Read record files.
-	recordDir, err := localfile.RecordDir()
-	if err != nil {
-		logger.Error(err.Error())
-		os.Exit(1)
+		output.Fail("Too few arguments")
 	}
 
-	files, err := os.ReadDir(recordDir)
+	result, err := lip.Uninstall(context.Background(), lip.UninstallOptions{
+		Options: lip.Options{
+			// Tools installed globally live in the global workspace.
+			Global: flagDict.globalFlag,
+			Prompt: cmdlipinstall.TerminalPrompt,
+			Stdin:  os.Stdin,
+			Stdout: output.CommandStdout(),
+			Stderr: os.Stderr,
+		},
+		ToothPaths:     flagSet.Args(),
+		KeepPossession: flagDict.keepPossessionFlag,
+	})
 	if err != nil {
-		logger.Error("cannot read the record directory " + recordDir + ": " + err.Error())
-		os.Exit(1)
+		output.Fail(err.Error())
 	}
 
-	for _, file := range files {
-		// Read the file as JSON.
-		content, err := os.ReadFile(recordDir + "/" + file.Name())
-		if err != nil {
-			logger.Error("cannot read the record file " + recordDir + "/" + file.Name() + ": " + err.Error())
-			os.Exit(1)
-		}
-
-		// Parse the JSON.
-		currentRecord, err := toothrecord.NewFromJSON(content)
-		if err != nil {
-			logger.Error(err.Error())
-			os.Exit(1)
-		}
-
-		// Check if the tooth path is in toothPathMap.
-		if _, ok := toothPathMap[currentRecord.ToothPath]; ok {
-			toothPathMap[currentRecord.ToothPath] = file.Name()
-		}
-	}
-
-	// Check if all tooths to uninstall are installed.
-	for toothPath, recordFilePath := range toothPathMap {
-		if recordFilePath == "" {
-			logger.Error("the tooth " + toothPath + " is not installed")
-			os.Exit(1)
-		}
-	}
+	output.Print(map[string]interface{}{
+		"uninstalled": UninstalledList(result),
+	})
+}
 
-	// 2. Uninstall tooths.
-
-	logger.Info("Uninstalling tooths...")
-
-	for toothPath, recordFileName := range toothPathMap {
-		logger.Info("  Uninstalling " + toothPath + "...")
-
-		possessionList := make([]string, 0)
-		if flagDict.keepPossessionFlag {
-			// Read the record file.
-			recordFilePath := filepath.Join(recordDir, recordFileName)
-			record, err := toothrecord.NewFromFile(recordFilePath)
-			if err != nil {
-				logger.Error("cannot read the record file " + recordFilePath + ": " + err.Error())
-				os.Exit(1)
-			}
-			possessionList = record.Possession
-		}
-
-		err = Uninstall(recordFileName, possessionList, flagDict.yesFlag)
-		if err != nil {
-			logger.Error(err.Error())
-			os.Exit(1)
-		}
+// UninstalledList converts the tooths uninstalled to a list in the output.
+func UninstalledList(result lip.UninstallResult) []interface{} {
+	uninstalledList := make([]interface{}, 0, len(result.Uninstalled))
+	for _, uninstalled := range result.Uninstalled {
+		uninstalledList = append(uninstalledList, map[string]interface{}{
+			"tooth":   uninstalled.ToothPath,
+			"version": uninstalled.Version.String(),
+		})
 	}
 
-	logger.Info("Successfully uninstalled all tooths.")
+	return uninstalledList
 }
@@ -0,0 +1,145 @@
+package cmdlipworkspaceslist
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/liteldev/lip/localfile"
+	"github.com/liteldev/lip/output"
+	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/workspace"
+)
+
+// FlagDict is a dictionary of flags.
+type FlagDict struct {
+	helpFlag bool
+	jsonFlag bool
+}
+
+const helpMessage = `
+Usage:
+  lip workspaces list [options]
+
+Description:
+  List workspaces that Lip has run in, with the number of installed tooths.
+  Workspaces that no longer exist are removed from the list. The current
+  workspace is marked with "*".
+
+Options:
+  -h, --help                  Show help.
+  --json                      Same as "lip --format json workspaces list".`
+
+// Run is the entry point.
+func Run(args []string) {
+	flagSet := flag.NewFlagSet("list", flag.ExitOnError)
+
+	// Rewrite the default usage message.
+	flagSet.Usage = func() {
+		logger.Info(helpMessage)
+	}
+
+	var flagDict FlagDict
+	flagSet.BoolVar(&flagDict.helpFlag, "help", false, "")
+	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
+	flagSet.BoolVar(&flagDict.jsonFlag, "json", false, "")
+	flagSet.Parse(args)
+
+	// Help flag has the highest priority.
+	if flagDict.helpFlag {
+		logger.Info(helpMessage)
+		return
+	}
+
+	if flagSet.NArg() > 0 {
+		output.Fail("Too many arguments.")
+	}
+
+	// The json flag is kept for compatibility.
+	if flagDict.jsonFlag {
+		output.SetFormat(output.JSONFormat)
+	}
+
+	err := listWorkspaces()
+	if err != nil {
+		output.Fail(err.Error())
+	}
+}
+
+// listWorkspaces lists known workspaces and removes those no longer existing.
+func listWorkspaces() error {
+	workspaceList, err := workspace.ListRegistered()
+	if err != nil {
+		return err
+	}
+
+	currentWorkspaceDir, err := localfile.WorkspaceDir()
+	if err != nil {
+		return err
+	}
+
+	type workspaceInfoType struct {
+		dir        string
+		toothCount int
+	}
+
+	workspaceInfoList := make([]workspaceInfoType, 0)
+	for _, workspaceDir := range workspaceList {
+		recordFileList, err := os.ReadDir(filepath.Join(workspaceDir, ".lip", "records"))
+		if err != nil {
+			// The workspace no longer exists.
+			logger.Debug("Removing " + workspaceDir + " from the workspace registry: " + err.Error())
+			err = workspace.Unregister(workspaceDir)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		toothCount := 0
+		for _, recordFile := range recordFileList {
+			if !recordFile.IsDir() && strings.HasSuffix(recordFile.Name(), ".json") {
+				toothCount++
+			}
+		}
+
+		workspaceInfoList = append(workspaceInfoList, workspaceInfoType{workspaceDir, toothCount})
+	}
+
+	// Print table.
+	longestDir := 20 // The mininum length
+	for _, workspaceInfo := range workspaceInfoList {
+		if len(workspaceInfo.dir) > longestDir {
+			longestDir = len(workspaceInfo.dir)
+		}
+	}
+
+	// Print header.
+	logger.Info("  Workspace" + strings.Repeat(" ", longestDir-9) + " Tooths")
+	logger.Info("  " + strings.Repeat("-", longestDir) + " " + strings.Repeat("-", 6))
+
+	// Print workspaces.
+	for _, workspaceInfo := range workspaceInfoList {
+		mark := "  "
+		if workspaceInfo.dir == currentWorkspaceDir {
+			mark = "* "
+		}
+
+		logger.Info(mark + workspaceInfo.dir + strings.Repeat(" ", longestDir-len(workspaceInfo.dir)) +
+			" " + strconv.Itoa(workspaceInfo.toothCount))
+	}
+
+	var outputList = make([]interface{}, 0)
+	for _, workspaceInfo := range workspaceInfoList {
+		outputList = append(outputList, map[string]interface{}{
+			"workspace":   workspaceInfo.dir,
+			"tooth-count": workspaceInfo.toothCount,
+			"is-current":  workspaceInfo.dir == currentWorkspaceDir,
+		})
+	}
+	output.Print(outputList)
+
+	return nil
+}
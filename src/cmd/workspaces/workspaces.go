@@ -0,0 +1,61 @@
+package cmdlipworkspaces
+
+import (
+	"flag"
+
+	cmdlipworkspaceslist "github.com/liteldev/lip/cmd/workspaces/list"
+	"github.com/liteldev/lip/output"
+	"github.com/liteldev/lip/utils/logger"
+)
+
+// FlagDict is a dictionary of flags.
+type FlagDict struct {
+	helpFlag bool
+}
+
+const helpMessage = `
+Usage:
+  lip workspaces [options]
+  lip workspaces <command> [subcommand options] ...
+
+Commands:
+  list                        List known workspaces.
+
+Options:
+  -h, --help                  Show help.`
+
+// Run is the entry point.
+func Run(args []string) {
+	flagSet := flag.NewFlagSet("workspaces", flag.ExitOnError)
+
+	// Rewrite the default usage message.
+	flagSet.Usage = func() {
+		logger.Info(helpMessage)
+	}
+
+	var flagDict FlagDict
+	flagSet.BoolVar(&flagDict.helpFlag, "help", false, "")
+	flagSet.BoolVar(&flagDict.helpFlag, "h", false, "")
+	flagSet.Parse(args)
+
+	// Help flag has the highest priority.
+	if flagDict.helpFlag {
+		logger.Info(helpMessage)
+		return
+	}
+
+	// If there is a subcommand, run it and exit.
+	if flagSet.NArg() >= 1 {
+		switch flagSet.Arg(0) {
+		case "list", "ls":
+			output.SetCommand("workspaces list")
+			cmdlipworkspaceslist.Run(flagSet.Args()[1:])
+			return
+		default:
+			output.Fail("Unknown command.")
+		}
+	}
+
+	// If there is no subcommand, print help message and exit.
+	logger.Info(helpMessage)
+}
@@ -18,6 +18,12 @@ const DefaultGoproxyURL = "https://goproxy.io"
 
 const DefaultRegistryURL = "https://registry.litebds.com"
 
+const DefaultReleaseURL = "https://github.com/LiteLDev/Lip/releases/download"
+
+// LipToothPath is the tooth path of Lip itself, whose versions are listed via
+// GOPROXY.
+const LipToothPath = "github.com/liteldev/lip"
+
 //------------------------------------------------------------------------------
 // Variables
 
@@ -27,9 +33,17 @@ var Version versions.Version
 // GoproxyList is the goproxy address.
 var GoproxyList []string
 
+// IsDirectGitEnabled is true if tooths may be fetched directly from their git
+// repositories when all GOPROXYs fail. It is enabled by "direct" in
+// LIP_GOPROXY, like GOPROXY of Go.
+var IsDirectGitEnabled bool
+
 // RegistryURL is the registry address.
 var RegistryURL string
 
+// ReleaseURL is the address that Lip releases are downloaded from.
+var ReleaseURL string
+
 //------------------------------------------------------------------------------
 // Functions
 
@@ -44,8 +58,16 @@ func Init() {
 	}
 
 	// Set Goproxy.
+	IsDirectGitEnabled = false
 	if goproxy := os.Getenv("LIP_GOPROXY"); goproxy != "" {
-		GoproxyList = strings.Split(goproxy, ",")
+		GoproxyList = make([]string, 0)
+		for _, goproxyURL := range strings.Split(goproxy, ",") {
+			if goproxyURL == "direct" {
+				IsDirectGitEnabled = true
+				continue
+			}
+			GoproxyList = append(GoproxyList, goproxyURL)
+		}
 	} else {
 		GoproxyList = []string{DefaultGoproxyURL}
 	}
@@ -56,4 +78,11 @@ func Init() {
 	} else {
 		RegistryURL = DefaultRegistryURL
 	}
+
+	// Set ReleaseURL.
+	if releaseURL := os.Getenv("LIP_RELEASE_URL"); releaseURL != "" {
+		ReleaseURL = releaseURL
+	} else {
+		ReleaseURL = DefaultReleaseURL
+	}
 }
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/liteldev/lip/context"
+	"github.com/liteldev/lip/utils/logger"
 	"github.com/schollz/progressbar/v3"
 )
 
@@ -23,6 +24,8 @@ const (
 
 // DownloadFile downloads a file from a url and saves it to a local path.
 func DownloadFile(url string, filePath string, progressBarStyle ProgressBarStyleType) error {
+	logger.With("url", url, "destination", filePath).Debug("Downloading " + url + "...")
+
 	req, _ := http.NewRequest("GET", url, nil)
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -41,6 +44,12 @@ func DownloadFile(url string, filePath string, progressBarStyle ProgressBarStyle
 	}
 	defer file.Close()
 
+	// Progress bars are printed to stderr, and only if it is a terminal and
+	// messages are printed for humans.
+	if !logger.IsTerminal(os.Stderr) || logger.GetFormat() != logger.TextFormat {
+		progressBarStyle = StyleNone
+	}
+
 	switch progressBarStyle {
 	case StyleNone:
 		_, err = io.Copy(file, resp.Body)
@@ -56,6 +65,7 @@ func DownloadFile(url string, filePath string, progressBarStyle ProgressBarStyle
 			progressbar.OptionSetElapsedTime(false),
 			progressbar.OptionSetPredictTime(false),
 			progressbar.OptionSetWidth(0),
+			progressbar.OptionSetWriter(os.Stderr),
 		)
 		io.Copy(io.MultiWriter(file, bar), resp.Body)
 		return nil
@@ -65,6 +75,7 @@ func DownloadFile(url string, filePath string, progressBarStyle ProgressBarStyle
 			progressbar.OptionClearOnFinish(),
 			progressbar.OptionShowBytes(true),
 			progressbar.OptionShowCount(),
+			progressbar.OptionSetWriter(os.Stderr),
 		)
 		io.Copy(io.MultiWriter(file, bar), resp.Body)
 		return nil
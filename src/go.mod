@@ -4,6 +4,8 @@ go 1.19
 
 require (
 	github.com/fatih/color v1.15.0
+	github.com/mattn/go-isatty v0.0.17
+	github.com/schollz/progressbar/v3 v3.13.0
 	github.com/xeipuuv/gojsonschema v1.2.0
 )
 
@@ -16,8 +18,6 @@ require (
 
 require (
 	github.com/mattn/go-colorable v0.1.13 // indirect
-	github.com/mattn/go-isatty v0.0.17 // indirect
-	github.com/schollz/progressbar/v3 v3.13.0
 	github.com/xeipuuv/gojsonpointer v0.0.0-20180127040702-4e3ac2762d5f // indirect
 	github.com/xeipuuv/gojsonreference v0.0.0-20180127040603-bd5ef7bd5415 // indirect
 	golang.org/x/sys v0.6.0 // indirect
@@ -0,0 +1,73 @@
+package lip
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/liteldev/lip/localfile"
+	"github.com/liteldev/lip/tooth/toothrecord"
+	"github.com/liteldev/lip/utils/platform"
+)
+
+// runDeferredCommands runs the post-install commands that were deferred because
+// tooths were installed for another platform, if Lip is now running on that
+// platform. It is run by operations modifying the workspace.
+func (s *session) runDeferredCommands() error {
+	recordList, err := toothrecord.ListAll()
+	if err != nil {
+		return err
+	}
+
+	for _, record := range recordList {
+		if !record.IsCommandDeferred {
+			continue
+		}
+
+		if !platform.IsNativePlatform(record.TargetOS, record.TargetArch) {
+			continue
+		}
+
+		toothLog := s.log.With("tooth", record.ToothPath, "version", record.Version.String())
+		toothLog.Info("Running deferred post-install commands of " + record.ToothPath + "...")
+
+		for _, commandItem := range record.Commands {
+			if commandItem.Type != "install" {
+				continue
+			}
+
+			if !platform.MatchPlatform(commandItem.GOOS, commandItem.GOARCH, record.TargetOS, record.TargetArch) {
+				continue
+			}
+
+			// Run the command. When error occurs, just report it and continue.
+			for _, command := range commandItem.Commands {
+				err := s.runCommand(command)
+				if err != nil {
+					toothLog.Error("failed to run command: " + command + ": " + err.Error())
+				}
+			}
+		}
+
+		// Commands are run only once, even if some of them failed.
+		record.IsCommandDeferred = false
+
+		recordJSON, err := record.JSON()
+		if err != nil {
+			return err
+		}
+
+		recordDir, err := localfile.RecordDir()
+		if err != nil {
+			return err
+		}
+
+		recordFilePath := filepath.Join(recordDir, localfile.GetRecordFileName(record.ToothPath))
+		err = os.WriteFile(recordFilePath, recordJSON, 0644)
+		if err != nil {
+			return errors.New("failed to write record file " + recordFilePath + " " + err.Error())
+		}
+	}
+
+	return nil
+}
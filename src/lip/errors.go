@@ -0,0 +1,79 @@
+package lip
+
+import (
+	"errors"
+)
+
+// ErrorKind is the kind of an error returned by operations.
+type ErrorKind int
+
+const (
+	// OtherError is an error of no other kind, e.g. a failure to read or write
+	// files in the workspace.
+	OtherError ErrorKind = iota
+
+	// InvalidArgumentError means the options are invalid, e.g. a malformed
+	// specifier or flags that cannot be used together.
+	InvalidArgumentError
+
+	// NotInstalledError means a tooth to uninstall is not installed.
+	NotInstalledError
+
+	// FetchError means a tooth or its versions cannot be fetched.
+	FetchError
+
+	// DependencyError means dependencies cannot be resolved, e.g. no version
+	// matches a requirement.
+	DependencyError
+
+	// ConflictError means tooths conflict with each other or with installed
+	// tooths or tools.
+	ConflictError
+
+	// IncompatibleError means a tooth requires a newer version of Lip.
+	IncompatibleError
+
+	// CancelledError means a prompt was declined or the context is done.
+	CancelledError
+)
+
+// Error is an error returned by operations.
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf returns the kind of an error returned by operations. Errors that are
+// not an *Error are of OtherError.
+func KindOf(err error) ErrorKind {
+	var lipErr *Error
+	if errors.As(err, &lipErr) {
+		return lipErr.Kind
+	}
+
+	return OtherError
+}
+
+// newError returns an *Error of the kind with the message.
+func newError(kind ErrorKind, message string) error {
+	return &Error{kind, errors.New(message)}
+}
+
+// wrapError returns err as an *Error of the kind. Errors that are already an
+// *Error keep their kind.
+func wrapError(kind ErrorKind, err error) error {
+	var lipErr *Error
+	if err == nil || errors.As(err, &lipErr) {
+		return err
+	}
+
+	return &Error{kind, err}
+}
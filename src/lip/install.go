@@ -0,0 +1,631 @@
+package lip
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	lipcontext "github.com/liteldev/lip/context"
+	"github.com/liteldev/lip/download"
+	"github.com/liteldev/lip/localfile"
+	"github.com/liteldev/lip/specifiers"
+	"github.com/liteldev/lip/tooth/toothgit"
+	"github.com/liteldev/lip/tooth/toothrecord"
+	"github.com/liteldev/lip/tooth/toothrepo"
+	"github.com/liteldev/lip/tooth/toothshim"
+	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/utils/paths"
+	"github.com/liteldev/lip/utils/platform"
+	"github.com/liteldev/lip/utils/versions"
+)
+
+// InstallOptions are the options of Install.
+type InstallOptions struct {
+	ResolveOptions
+
+	// AssumeYes lets tooths place files outside the workspace without asking.
+	// Confirmations declared by tooths are still asked.
+	AssumeYes bool
+}
+
+// InstalledTooth is a tooth installed by Install.
+type InstalledTooth struct {
+	ToothPath           string
+	Version             versions.Version
+	IsManuallyInstalled bool
+	Extras              []string
+}
+
+// InstallResult is the changes made by Install.
+type InstallResult struct {
+	Installed []InstalledTooth
+
+	// Uninstalled are the tooths uninstalled to be reinstalled, upgraded or
+	// replaced.
+	Uninstalled []ToothVersion
+
+	// ExtrasAdded are extras newly selected for installed tooths.
+	// Tooth path -> extras
+	ExtrasAdded map[string][]string
+}
+
+// Install installs tooths and their dependencies into the workspace. Tooths
+// already installed are skipped unless Upgrade or ForceReinstall is set.
+func Install(ctx context.Context, options InstallOptions) (InstallResult, error) {
+	s, err := enter(ctx, "install", options.Options)
+	if err != nil {
+		return InstallResult{}, err
+	}
+	defer s.leave()
+
+	err = s.runDeferredCommands()
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	resolveResult, err := s.resolve(options.ResolveOptions)
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	result := InstallResult{
+		Installed:   make([]InstalledTooth, 0),
+		Uninstalled: make([]ToothVersion, 0),
+		ExtrasAdded: make(map[string][]string),
+	}
+
+	// 3. Deal with force reinstall flag and upgrade flag.
+	//    This process will check if the force reinstall flag is set. If it is set, all
+	//    installed tooth specified by the specifiers will be reinstalled. If it is not
+	//    set, it will check if the upgrade flag is set. If it is set, all installed tooth
+	//    specified by the specifiers will be upgraded. If it is not set, all installed
+	//    tooth specified by the specifiers will be skipped.
+
+	if options.ForceReinstall || options.Upgrade {
+		if options.ForceReinstall {
+			s.log.Info("--force-reinstall flag is set, Lip will reinstall all installed tooths specified by the specifiers...")
+		} else if options.Upgrade {
+			s.log.Info("--upgrade flag is set, Lip will upgrade all installed tooths specified by the specifiers...")
+		}
+
+		s.log.Info("Uninstalling tooths to be reinstalled or upgraded...")
+
+		for _, specifier := range resolveResult.specifierList {
+			// If the specifier is not a requirement specifier, skip.
+			if specifier.Type() != specifiers.RequirementKind {
+				s.log.Error("The specifier " + specifier.String() + " is not a requirement specifier. It cannot be used with the --force-reinstall flag or the --upgrade flag")
+				continue
+			}
+
+			var tooth ResolvedTooth
+			isFetched := false
+			for _, resolvedTooth := range resolveResult.Tooths {
+				if resolvedTooth.specifierString == specifier.String() {
+					tooth = resolvedTooth
+					isFetched = true
+					break
+				}
+			}
+			if !isFetched {
+				// Already installed and skipped.
+				continue
+			}
+
+			err = s.check()
+			if err != nil {
+				return result, err
+			}
+
+			toothLog := s.log.With("tooth", tooth.ToothPath, "version", tooth.Version.String())
+			toothLog.Info("  Resolving " + specifier.String() + "...")
+
+			// If the tooth file of the specifier is not installed, skip.
+			isInstalled, err := toothrecord.IsToothInstalled(tooth.ToothPath)
+			if err != nil {
+				return result, err
+			}
+			if !isInstalled {
+				continue
+			}
+
+			toothRecord, err := toothrecord.Get(tooth.ToothPath)
+			if err != nil {
+				return result, err
+			}
+
+			// Compare the version of the tooth file and the version of the tooth record.
+			// If the version of the tooth file is not greater than the version of the tooth
+			// record, skip.
+			if !options.ForceReinstall && !versions.GreaterThan(tooth.Version, toothRecord.Version) {
+				continue
+			}
+
+			// If the tooth file of the specifier is installed, uninstall it.
+			toothLog.Info("    Uninstalling " + tooth.ToothPath + "...")
+
+			err = s.uninstall(localfile.GetRecordFileName(tooth.ToothPath), tooth.toothFile.Metadata().Possession, nil)
+			if err != nil {
+				return result, err
+			}
+
+			result.Uninstalled = append(result.Uninstalled, ToothVersion{toothRecord.ToothPath, toothRecord.Version})
+		}
+	}
+
+	// 3.1. Find replaced tooths.
+	//    Installed tooths replaced by tooths to install are uninstalled after the
+	//    replacing tooth is installed, so that they are kept if the installation
+	//    fails. Files placed by the replacing tooth and files in its possession
+	//    are kept. If the replaced tooth was manually installed, the replacing
+	//    tooth will be recorded as manually installed as well.
+
+	// Tooth path of the replacing tooth -> records of the tooths it replaces
+	replacedRecordMap := make(map[string][]toothrecord.Record)
+
+	// Tooth path of the replacing tooth -> whether any replaced tooth was manually installed
+	migratedManuallyInstalledMap := make(map[string]bool)
+
+	for _, replacedRecord := range resolveResult.Replaced {
+		for _, tooth := range resolveResult.Tooths {
+			if !isReplacedBy(replacedRecord.ToothPath, tooth.toothFile.Metadata().Replaces) {
+				continue
+			}
+
+			replacedRecordMap[tooth.ToothPath] = append(replacedRecordMap[tooth.ToothPath], replacedRecord)
+
+			if replacedRecord.IsManuallyInstalled {
+				migratedManuallyInstalledMap[tooth.ToothPath] = true
+			}
+
+			break
+		}
+	}
+
+	// 4. Install tooth files.
+	//    This process will install all downloaded tooth files in topological order.
+	//    If the tooth file is already installed, it will be skipped.
+
+	s.log.Info("Installing tooths...")
+
+	for _, tooth := range resolveResult.Tooths {
+		err = s.check()
+		if err != nil {
+			return result, err
+		}
+
+		toothLog := s.log.With("tooth", tooth.ToothPath, "version", tooth.Version.String())
+		toothLog.Info("  Resolving " + tooth.ToothPath + "@" + tooth.Version.String() + "...")
+
+		// If the tooth file is already installed, skip.
+		isInstalled, err := toothrecord.IsToothInstalled(tooth.ToothPath)
+		if err != nil {
+			return result, err
+		}
+		if isInstalled {
+			toothLog.Info("    " + tooth.ToothPath + " is already installed.")
+		} else {
+			// Install the tooth file.
+			toothLog.Info("    Installing " + tooth.ToothPath + "@" + tooth.Version.String() + "...")
+
+			isManuallyInstalled := tooth.IsRequested || migratedManuallyInstalledMap[tooth.ToothPath]
+
+			err = s.install(tooth, isManuallyInstalled, options.AssumeYes)
+			if err != nil {
+				return result, err
+			}
+
+			result.Installed = append(result.Installed, InstalledTooth{
+				ToothPath:           tooth.ToothPath,
+				Version:             tooth.Version,
+				IsManuallyInstalled: isManuallyInstalled,
+				Extras:              tooth.Extras,
+			})
+		}
+
+		// Uninstall tooths replaced by the tooth now that its files are placed.
+		if len(replacedRecordMap[tooth.ToothPath]) == 0 {
+			continue
+		}
+
+		toothRecord, err := toothrecord.Get(tooth.ToothPath)
+		if err != nil {
+			return result, err
+		}
+
+		keptFileList := placedFileList(toothRecord)
+		for _, replacedRecord := range replacedRecordMap[tooth.ToothPath] {
+			s.log.With("tooth", replacedRecord.ToothPath, "version", replacedRecord.Version.String(), "replaced_by", tooth.ToothPath).Info(
+				"    Uninstalling " + replacedRecord.ToothPath + " (replaced by " + tooth.ToothPath + ")...")
+
+			err = s.uninstall(localfile.GetRecordFileName(replacedRecord.ToothPath), toothRecord.Possession, keptFileList)
+			if err != nil {
+				return result, err
+			}
+
+			result.Uninstalled = append(result.Uninstalled, ToothVersion{replacedRecord.ToothPath, replacedRecord.Version})
+		}
+	}
+
+	// 5. Record extras newly selected for installed tooths.
+
+	for toothPath, extras := range resolveResult.ExtrasToAdd {
+		err = addExtrasToRecord(toothPath, extras)
+		if err != nil {
+			return result, err
+		}
+
+		result.ExtrasAdded[toothPath] = extras
+	}
+
+	s.log.Info("Successfully installed all tooth files.")
+
+	return result, nil
+}
+
+// GetTooth gets the tooth file path of a tooth specifier either from the cache or from the tooth repository.
+// If the tooth file is downloaded, it will be cached.
+// If the specifier is local tooth file, it will return the path of the local tooth file.
+// toothFilePath is the absolute path of the tooth file. Fetching is cancelled
+// with the context.
+func GetTooth(ctx context.Context, specifier specifiers.Specifier, progressBarStyle download.ProgressBarStyleType) (isCached bool, toothFilePath string, err error) {
+	// Git refs like branches move, so tooths are cached by commit.
+	specifier, err = specifier.ResolveGitCommit(ctx)
+	if err != nil {
+		return false, "", err
+	}
+
+	// For local tooth directory, return the path directly.
+	if specifier.Type() == specifiers.ToothDirKind {
+		toothDirPath, err := filepath.Abs(specifier.ToothDirPath())
+		if err != nil {
+			return false, "", errors.New("cannot get full path of tooth directory: " + specifier.ToothDirPath())
+		}
+
+		return false, toothDirPath, nil
+	}
+
+	// For local tooth file, return the path directly.
+	if specifier.Type() == specifiers.ToothFileKind {
+		// Get full path of the tooth file.
+		toothFilePath, err := filepath.Abs(specifier.ToothFilePath())
+		if err != nil {
+			return false, "", errors.New("cannot get full path of tooth file: " + specifier.ToothFilePath())
+		}
+
+		return false, toothFilePath, nil
+	}
+
+	// Get the path to the cache tooth file.
+	cacheFileName := localfile.GetCachedToothFileName(specifier.String())
+	cacheDirectory, err := localfile.CacheDir()
+	if err != nil {
+		return false, "", err
+	}
+	cacheFilePath := filepath.Join(cacheDirectory, cacheFileName)
+
+	// Directly return the cached tooth file path if it exists.
+	isCacheExist, err := localfile.IsCachedToothFileExist(specifier.String())
+	if err != nil {
+		return false, "", err
+	}
+	if isCacheExist {
+		return true, cacheFilePath, nil
+	}
+
+	// Download the tooth file to the cache.
+	err = downloadTooth(ctx, specifier, cacheFilePath, progressBarStyle)
+	if err != nil {
+		return false, "", err
+	}
+
+	return false, cacheFilePath, nil
+}
+
+// downloadTooth downloads a tooth file from a tooth repository, a tooth url,
+// or a local path and returns the path of the downloaded tooth file.
+// If the specifier is a requirement specifier, it should contain version.
+func downloadTooth(ctx context.Context, specifier specifiers.Specifier, destination string, progressBarStyle download.ProgressBarStyleType) error {
+	switch specifier.Type() {
+	case specifiers.ToothFileKind, specifiers.ToothDirKind:
+		// Local tooth file is not accepted here.
+		return errors.New("local tooth file is not able to be downloaded")
+
+	case specifiers.ToothURLKind:
+		// For tooth url, download the tooth file and return the path.
+
+		tempFilePath := destination + ".tmp"
+
+		err := download.DownloadFile(specifier.ToothURL(), tempFilePath, progressBarStyle)
+		if err != nil {
+			return err
+		}
+
+		// Move the downloaded file to the destination.
+		os.Rename(tempFilePath, destination)
+
+		return nil
+
+	case specifiers.RequirementKind:
+		// For requirement specifier, download the tooth via GOPROXY and return the path.
+
+		tempFilePath := destination + ".tmp"
+
+		// The tooth may be published under a /vN module path or as an
+		// +incompatible version.
+		urlPath, err := toothrepo.ResolveVersionURLPath(specifier.ToothRepo(), specifier.ToothVersion())
+		if err != nil && !lipcontext.IsDirectGitEnabled {
+			return err
+		}
+		if err != nil {
+			// Fall back to the git repository directly if all GOPROXYs fail.
+			gitURL, gitTag := toothrepo.DirectGitSource(specifier.ToothRepo(), specifier.ToothVersion())
+			logger.With("tooth", specifier.ToothRepo(), "version", specifier.ToothVersion().String(), "url", gitURL).Warning(
+				"    Cannot access the tooth via GOPROXY. Fetching from " + gitURL + " directly...")
+
+			gitCommit, directErr := toothgit.ResolveCommit(ctx, gitURL, gitTag)
+			if directErr != nil {
+				return errors.New(err.Error() + "; " + directErr.Error())
+			}
+
+			return toothgit.Archive(ctx, gitURL, gitCommit, destination)
+		}
+
+		err = download.DownloadGoproxyFile(urlPath+".zip", tempFilePath, progressBarStyle)
+		if err != nil {
+			return err
+		}
+
+		// Move the downloaded file to the destination.
+		os.Rename(tempFilePath, destination)
+
+		return nil
+
+	case specifiers.GitKind:
+		// For git specifier, build the tooth archive from the resolved commit.
+		return toothgit.Archive(ctx, specifier.GitURL(), specifier.GitCommit(), destination)
+	}
+
+	// Default to unknown error.
+	return errors.New("unknown error")
+}
+
+// install installs the .tth file or the tooth directory of a resolved tooth.
+// If the tooth is editable, files of the tooth directory are linked instead of
+// copied.
+func (s *session) install(tooth ResolvedTooth, isManuallyInstalled bool, isYes bool) error {
+	t := tooth.toothFile
+	toothLog := s.log.With("tooth", t.Metadata().ToothPath, "version", t.Metadata().Version.String())
+
+	// 1. Check if the tooth is already installed.
+
+	recordDir, err := localfile.RecordDir()
+	if err != nil {
+		return err
+	}
+
+	recordFilePath := recordDir + "/" +
+		localfile.GetRecordFileName(t.Metadata().ToothPath)
+
+	// If the record file already exists, return an error.
+	if _, err := os.Stat(recordFilePath); err == nil {
+		return newError(ConflictError, "the tooth is already installed")
+	}
+
+	// 1.1. If the tooth is a tool, check if a tool with the same name is already
+	// installed. Tools with the same name but different owners are told apart
+	// by owner/tool.
+
+	if t.Metadata().IsTool() {
+		installedToolRecordList, err := toothrecord.ListAll()
+		if err != nil {
+			return errors.New("cannot list installed tools: " + err.Error())
+		}
+
+		toolRecord := toothrecord.Record{
+			ToothPath: t.Metadata().ToothPath,
+			Tool:      toothrecord.ToolStruct{Name: t.Metadata().Tool.Name},
+		}
+		for _, installedToolRecord := range installedToolRecordList {
+			// Tooths replaced by the tooth are uninstalled after it.
+			if isReplacedBy(installedToolRecord.ToothPath, t.Metadata().Replaces) {
+				continue
+			}
+
+			if installedToolRecord.MatchToolName(toolRecord.QualifiedToolName()) {
+				return newError(ConflictError, "a tool named "+toolRecord.QualifiedToolName()+" is already installed by "+
+					installedToolRecord.ToothPath)
+			}
+		}
+	}
+
+	// 2. Ask for confirmation if the tooth requires confirmation.
+
+	if len(t.Metadata().Confirmation) > 0 {
+		for _, confirmation := range t.Metadata().Confirmation {
+			if confirmation.Type != "install" {
+				continue
+			}
+
+			if !platform.Match(confirmation.GOOS, confirmation.GOARCH) {
+				continue
+			}
+
+			err = s.ask(Prompt{
+				Kind:      ConfirmationPrompt,
+				ToothPath: t.Metadata().ToothPath,
+				Message:   confirmation.Message,
+				Default:   true,
+			}, "installation cancelled")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// 3. Place the files to the right place in the workspace.
+
+	workSpaceDir, err := localfile.WorkspaceDir()
+	if err != nil {
+		return err
+	}
+
+	for _, placement := range t.Metadata().Placement {
+		if !platform.Match(placement.GOOS, placement.GOARCH) {
+			continue
+		}
+
+		destination := placement.Destination
+
+		if !isYes {
+			if !paths.IsAncesterOf(workSpaceDir, destination) {
+				err = s.ask(Prompt{
+					Kind:      OutsideWorkspacePrompt,
+					ToothPath: t.Metadata().ToothPath,
+					Message: "This tooth is placing files to " + destination +
+						", which is not in current workspace. Do you want to continue?",
+					Default: false,
+				}, "installation aborted")
+				if err != nil {
+					return err
+				}
+				isYes = true
+			}
+		}
+	}
+
+	if t.IsDir() {
+		err = placeFilesFromDir(t, tooth.IsEditable, s.log.With("tooth", t.Metadata().ToothPath))
+	} else {
+		err = placeFilesFromArchive(t)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 4. Run the post-install script. Commands for another platform cannot run
+	//    here, so they are deferred to the next install, uninstall or autoremove on
+	//    that platform.
+	isCommandDeferred := false
+	for _, commandItem := range t.Metadata().Commands {
+		if commandItem.Type != "install" {
+			continue
+		}
+
+		// Validate GOOS and GOARCH. If GOARCH is empty, it is valid for all GOARCH.
+		if !platform.Match(commandItem.GOOS, commandItem.GOARCH) {
+			continue
+		}
+
+		if !platform.IsNative() {
+			isCommandDeferred = true
+			continue
+		}
+
+		// Run the command. When error occurs, just report it and continue.
+		for _, command := range commandItem.Commands {
+			err := s.runCommand(command)
+			if err != nil {
+				toothLog.Error("failed to run command: " + command + ": " + err.Error())
+			}
+		}
+	}
+
+	if isCommandDeferred {
+		toothLog.Warning("Deferred post-install commands of " + t.Metadata().ToothPath + " for " + platform.String() +
+			" because Lip is running on " + platform.Native() + ". They will run on the next install, uninstall or autoremove on " +
+			platform.String() + ".")
+	}
+
+	// 5. Install the record file.
+
+	// Create a record object from the metadata.
+	record := toothrecord.NewFromMetadata(t.Metadata(), isManuallyInstalled)
+	record.Extras = mergeExtras(record.Extras, tooth.Extras)
+	record.GitURL = tooth.GitURL
+	record.GitCommit = tooth.GitCommit
+	if tooth.IsEditable {
+		record.IsEditable = true
+		record.SourceDir = t.FilePath()
+	}
+	record.TargetOS = platform.GOOS()
+	record.TargetArch = platform.GOARCH()
+	record.IsCommandDeferred = isCommandDeferred
+
+	// Encode the record object to JSON.
+	recordJSON, err := record.JSON()
+	if err != nil {
+		return err
+	}
+
+	// Write the metadata bytes to the record file.
+	err = os.WriteFile(recordFilePath, recordJSON, 0644)
+	if err != nil {
+		return errors.New("failed to write record file " + recordFilePath + " " + err.Error())
+	}
+
+	// 6. Generate the shim of the tool.
+	err = toothshim.Sync()
+	if err != nil {
+		s.log.Warning("failed to update shims: " + err.Error())
+	}
+
+	return nil
+}
+
+// isReplacedBy returns true if the tooth path is in the list of tooths replaced
+// by a tooth.
+func isReplacedBy(toothPath string, replacedToothPathList []string) bool {
+	for _, replacedToothPath := range replacedToothPathList {
+		if replacedToothPath == toothPath {
+			return true
+		}
+	}
+
+	return false
+}
+
+// placedFileList returns the destinations of the placements of an installed
+// tooth for the platform that it was installed for.
+func placedFileList(record toothrecord.Record) []string {
+	goos, goarch := record.Platform()
+
+	fileList := make([]string, 0, len(record.Placement))
+	for _, placement := range record.Placement {
+		if !platform.MatchPlatform(placement.GOOS, placement.GOARCH, goos, goarch) {
+			continue
+		}
+
+		fileList = append(fileList, placement.Destination)
+	}
+
+	return fileList
+}
+
+// addExtrasToRecord adds extras to the record of an installed tooth.
+func addExtrasToRecord(toothPath string, extras []string) error {
+	record, err := toothrecord.New(toothPath)
+	if err != nil {
+		return err
+	}
+
+	record.Extras = mergeExtras(record.Extras, extras)
+
+	recordJSON, err := record.JSON()
+	if err != nil {
+		return err
+	}
+
+	recordDir, err := localfile.RecordDir()
+	if err != nil {
+		return err
+	}
+
+	recordFilePath := filepath.Join(recordDir, localfile.GetRecordFileName(toothPath))
+	err = os.WriteFile(recordFilePath, recordJSON, 0644)
+	if err != nil {
+		return errors.New("failed to write record file " + recordFilePath + " " + err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+// Package lip is the Go API of Lip. It installs, uninstalls, lists and shows
+// tooths like the lip command does, but returns results and typed errors
+// instead of printing them and exiting, and asks a PromptHandler instead of
+// reading answers from the standard input.
+//
+// Operations work on the workspace in the current directory, the one in
+// Options.WorkspaceDir or the global workspace. They change the current
+// directory and the default target platform of the process while running and
+// restore them when done. Operations wait for each other instead of running
+// concurrently. Progress is reported with the logger package.
+package lip
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+
+	lipcontext "github.com/liteldev/lip/context"
+	"github.com/liteldev/lip/download"
+	"github.com/liteldev/lip/localfile"
+	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/utils/platform"
+	"github.com/liteldev/lip/utils/versions"
+	"github.com/liteldev/lip/workspace"
+)
+
+// Options are the options common to all operations.
+type Options struct {
+	// WorkspaceDir is the workspace to work on. It defaults to the current
+	// directory.
+	WorkspaceDir string
+
+	// Global works on the global workspace in ~/.lip/global instead, where
+	// tools are installed for all workspaces. WorkspaceDir is ignored.
+	Global bool
+
+	// Prompt is asked when a tooth requires confirmation. If it is nil, the
+	// default answer of each prompt is taken.
+	Prompt PromptHandler
+
+	// Stdin, Stdout and Stderr are connected to commands of tooths. Nil values
+	// connect them to the null device.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// ProgressBarStyle is the style of progress bars of downloads.
+	ProgressBarStyle download.ProgressBarStyleType
+}
+
+// ToothVersion is a tooth at a version.
+type ToothVersion struct {
+	ToothPath string
+	Version   versions.Version
+}
+
+// session is a running operation in a workspace.
+type session struct {
+	ctx     context.Context
+	options Options
+
+	// log attaches the name of the operation to messages.
+	log logger.Entry
+
+	// workingDir is the directory that relative paths are resolved against.
+	workingDir string
+
+	// previousDir is the current directory before entering the workspace.
+	previousDir string
+
+	// previousDefaultOS and previousDefaultArch are the default target
+	// platform before entering the workspace.
+	previousDefaultOS   string
+	previousDefaultArch string
+}
+
+// sessionMutex allows one session at a time, since sessions change the current
+// directory and the default target platform of the process.
+var sessionMutex sync.Mutex
+
+// enter checks the context, initializes Lip if needed and changes the current
+// directory to the workspace of the options. The target platform defaults to
+// the platform of the workspace. command is the name of the operation, e.g.
+// install, attached to messages. It waits for running sessions to leave, and
+// leave should be called when done.
+func enter(ctx context.Context, command string, options Options) (*session, error) {
+	if ctx.Err() != nil {
+		return nil, &Error{CancelledError, ctx.Err()}
+	}
+
+	sessionMutex.Lock()
+
+	// Lip may be embedded without running the lip command.
+	if lipcontext.GoproxyList == nil {
+		lipcontext.Init()
+	}
+
+	previousDefaultOS, previousDefaultArch := platform.DefaultTarget()
+	s := &session{
+		ctx:                 ctx,
+		options:             options,
+		log:                 logger.With("command", command),
+		previousDefaultOS:   previousDefaultOS,
+		previousDefaultArch: previousDefaultArch,
+	}
+
+	previousDir, err := localfile.WorkspaceDir()
+	if err != nil {
+		sessionMutex.Unlock()
+		return nil, err
+	}
+	s.previousDir = previousDir
+
+	s.workingDir, err = localfile.WorkingDir()
+	if err != nil {
+		s.leave()
+		return nil, err
+	}
+
+	if options.Global {
+		err = workspace.EnterGlobal()
+		if err != nil {
+			s.leave()
+			return nil, err
+		}
+	} else {
+		if options.WorkspaceDir != "" {
+			err = os.Chdir(options.WorkspaceDir)
+			if err != nil {
+				s.leave()
+				return nil, &Error{InvalidArgumentError, err}
+			}
+		}
+
+		workspaceConfig, err := workspace.LoadConfig()
+		if err != nil {
+			s.leave()
+			return nil, err
+		}
+		platform.SetDefaultTarget(workspaceConfig.TargetOS, workspaceConfig.TargetArch)
+	}
+
+	err = localfile.Init()
+	if err != nil {
+		s.leave()
+		return nil, err
+	}
+
+	// Only operations modifying the workspace create ./.lip.
+	if command == "install" || command == "uninstall" || command == "autoremove" {
+		err = localfile.InitWorkspace()
+		if err != nil {
+			s.leave()
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// leave changes the current directory and the default target platform back,
+// and lets the next session enter.
+func (s *session) leave() {
+	os.Chdir(s.previousDir)
+	platform.SetDefaultTarget(s.previousDefaultOS, s.previousDefaultArch)
+	sessionMutex.Unlock()
+}
+
+// check returns an error if the context is done.
+func (s *session) check() error {
+	if s.ctx.Err() != nil {
+		return &Error{CancelledError, s.ctx.Err()}
+	}
+
+	return nil
+}
+
+// runCommand runs a command of a tooth in the shell of the platform.
+func (s *session) runCommand(command string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.CommandContext(s.ctx, "cmd", "/C", command)
+	default:
+		cmd = exec.CommandContext(s.ctx, "sh", "-c", command)
+	}
+	cmd.Stdin = s.options.Stdin
+	cmd.Stdout = s.options.Stdout
+	cmd.Stderr = s.options.Stderr
+
+	return cmd.Run()
+}
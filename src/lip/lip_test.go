@@ -0,0 +1,376 @@
+package lip
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	lipcontext "github.com/liteldev/lip/context"
+	"github.com/liteldev/lip/utils/platform"
+)
+
+// setUp creates a home directory, a workspace and a tooth directory, and
+// returns the paths of the workspace and the tooth directory.
+func setUp(t *testing.T) (string, string) {
+	rootDir := t.TempDir()
+	homeDir := filepath.Join(rootDir, "home")
+	workspaceDir := filepath.Join(rootDir, "workspace")
+	toothDir := filepath.Join(rootDir, "tooth")
+	os.MkdirAll(homeDir, 0755)
+	os.MkdirAll(workspaceDir, 0755)
+	os.MkdirAll(toothDir, 0755)
+	t.Setenv("HOME", homeDir)
+	t.Setenv("USERPROFILE", homeDir)
+
+	fileMap := map[string]string{
+		"tooth.json": `{
+    "format_version": 1,
+    "tooth": "example.com/test/test",
+    "version": "1.0.0",
+    "confirmation": [
+        {
+            "type": "install",
+            "message": "Do you accept the license?"
+        }
+    ],
+    "placement": [
+        {
+            "source": "a.txt",
+            "destination": "plugins/a.txt"
+        }
+    ]
+}`,
+		"a.txt": "a",
+	}
+
+	for file, content := range fileMap {
+		err := os.WriteFile(filepath.Join(toothDir, file), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return workspaceDir, toothDir
+}
+
+func TestInstallAndUninstall(t *testing.T) {
+	workspaceDir, toothDir := setUp(t)
+	ctx := context.Background()
+
+	promptList := make([]Prompt, 0)
+	options := Options{
+		WorkspaceDir: workspaceDir,
+		Prompt: func(ctx context.Context, prompt Prompt) (bool, error) {
+			promptList = append(promptList, prompt)
+			return true, nil
+		},
+	}
+
+	installResult, err := Install(ctx, InstallOptions{
+		ResolveOptions: ResolveOptions{
+			Options:    options,
+			Specifiers: []string{toothDir},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(installResult.Installed) != 1 || installResult.Installed[0].ToothPath != "example.com/test/test" ||
+		!installResult.Installed[0].IsManuallyInstalled {
+		t.Errorf("wrong installed tooths: %v", installResult.Installed)
+	}
+
+	if len(promptList) != 1 || promptList[0].Kind != ConfirmationPrompt ||
+		promptList[0].Message != "Do you accept the license?" || !promptList[0].Default {
+		t.Errorf("wrong prompts: %v", promptList)
+	}
+
+	if _, err := os.Stat(filepath.Join(workspaceDir, "plugins", "a.txt")); err != nil {
+		t.Errorf("file not placed: %s", err.Error())
+	}
+
+	itemList, err := List(ctx, ListOptions{Options: options})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(itemList) != 1 || itemList[0].Record.Version.String() != "1.0.0" {
+		t.Errorf("wrong list: %v", itemList)
+	}
+
+	showResult, err := Show(ctx, ShowOptions{Options: options, ToothPath: "example.com/test/test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !showResult.IsInstalled || !showResult.Record.IsManuallyInstalled {
+		t.Errorf("wrong show result: %v", showResult)
+	}
+
+	uninstallResult, err := Uninstall(ctx, UninstallOptions{
+		Options:    options,
+		ToothPaths: []string{"example.com/test/test"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uninstallResult.Uninstalled) != 1 || uninstallResult.Uninstalled[0].ToothPath != "example.com/test/test" {
+		t.Errorf("wrong uninstalled tooths: %v", uninstallResult.Uninstalled)
+	}
+
+	if _, err := os.Stat(filepath.Join(workspaceDir, "plugins", "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("file not removed")
+	}
+
+	_, err = Uninstall(ctx, UninstallOptions{
+		Options:    options,
+		ToothPaths: []string{"example.com/test/test"},
+	})
+	if KindOf(err) != NotInstalledError {
+		t.Errorf("wrong error when uninstalling a tooth not installed: %v", err)
+	}
+}
+
+func TestInstallCancelled(t *testing.T) {
+	workspaceDir, toothDir := setUp(t)
+
+	_, err := Install(context.Background(), InstallOptions{
+		ResolveOptions: ResolveOptions{
+			Options: Options{
+				WorkspaceDir: workspaceDir,
+				Prompt: func(ctx context.Context, prompt Prompt) (bool, error) {
+					return false, nil
+				},
+			},
+			Specifiers: []string{toothDir},
+		},
+	})
+	if KindOf(err) != CancelledError {
+		t.Errorf("wrong error when the prompt is declined: %v", err)
+	}
+
+	itemList, err := List(context.Background(), ListOptions{Options: Options{WorkspaceDir: workspaceDir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(itemList) != 0 {
+		t.Errorf("tooths installed after cancelling: %v", itemList)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = Install(ctx, InstallOptions{
+		ResolveOptions: ResolveOptions{
+			Options:    Options{WorkspaceDir: workspaceDir},
+			Specifiers: []string{toothDir},
+		},
+	})
+	if KindOf(err) != CancelledError || !errors.Is(err, context.Canceled) {
+		t.Errorf("wrong error when the context is cancelled: %v", err)
+	}
+}
+
+func TestListUpgradableFetchFailed(t *testing.T) {
+	workspaceDir, toothDir := setUp(t)
+	ctx := context.Background()
+	options := Options{WorkspaceDir: workspaceDir}
+
+	_, err := Install(ctx, InstallOptions{
+		ResolveOptions: ResolveOptions{
+			Options:    options,
+			Specifiers: []string{toothDir},
+		},
+		AssumeYes: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// No GOPROXY is listening on this address.
+	lipcontext.Init()
+	goproxyList := lipcontext.GoproxyList
+	lipcontext.GoproxyList = []string{"http://127.0.0.1:1"}
+	defer func() { lipcontext.GoproxyList = goproxyList }()
+
+	itemList, err := List(ctx, ListOptions{Options: options, Upgradable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(itemList) != 0 {
+		t.Errorf("tooths whose latest version cannot be fetched are upgradable: %v", itemList)
+	}
+}
+
+func TestResolveInvalidArgument(t *testing.T) {
+	workspaceDir, toothDir := setUp(t)
+
+	testList := []ResolveOptions{
+		{},
+		{Specifiers: []string{toothDir}, Upgrade: true, ForceReinstall: true},
+		{Specifiers: []string{toothDir + ".tth"}, Editable: true},
+	}
+
+	for index, test := range testList {
+		test.WorkspaceDir = workspaceDir
+
+		_, err := Resolve(context.Background(), test)
+		if KindOf(err) != InvalidArgumentError {
+			t.Errorf("wrong error at test %d: %v", index, err)
+		}
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	err := wrapError(FetchError, newError(ConflictError, "conflict"))
+	if KindOf(err) != ConflictError {
+		t.Errorf("kind of an *Error is changed when wrapped")
+	}
+
+	if KindOf(errors.New("other")) != OtherError {
+		t.Errorf("wrong kind of an error other than *Error")
+	}
+
+	if wrapError(FetchError, nil) != nil {
+		t.Errorf("nil is wrapped")
+	}
+}
+
+func TestInstallReplaces(t *testing.T) {
+	workspaceDir, toothDir := setUp(t)
+	ctx := context.Background()
+
+	// A tooth replacing example.com/test/test, placing a.txt as well.
+	replacingToothDir := filepath.Join(filepath.Dir(toothDir), "replacing")
+	os.MkdirAll(replacingToothDir, 0755)
+	os.WriteFile(filepath.Join(replacingToothDir, "tooth.json"), []byte(`{
+    "format_version": 1,
+    "tooth": "example.com/test/replacing",
+    "version": "1.0.0",
+    "replaces": ["example.com/test/test"],
+    "confirmation": [
+        {
+            "type": "install",
+            "message": "Replace?"
+        }
+    ],
+    "placement": [
+        {
+            "source": "a.txt",
+            "destination": "plugins/a.txt"
+        }
+    ]
+}`), 0644)
+	os.WriteFile(filepath.Join(replacingToothDir, "a.txt"), []byte("replacing"), 0644)
+
+	isAccepted := true
+	options := Options{
+		WorkspaceDir: workspaceDir,
+		Prompt: func(ctx context.Context, prompt Prompt) (bool, error) {
+			return isAccepted, nil
+		},
+	}
+
+	install := func(toothDir string) (InstallResult, error) {
+		return Install(ctx, InstallOptions{
+			ResolveOptions: ResolveOptions{
+				Options:    options,
+				Specifiers: []string{toothDir},
+			},
+		})
+	}
+
+	_, err := install(toothDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The replaced tooth is kept if the replacing tooth fails to install.
+	isAccepted = false
+	_, err = install(replacingToothDir)
+	if KindOf(err) != CancelledError {
+		t.Errorf("wrong error when the installation is cancelled: %v", err)
+	}
+
+	itemList, err := List(ctx, ListOptions{Options: options})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(itemList) != 1 || itemList[0].Record.ToothPath != "example.com/test/test" {
+		t.Errorf("wrong list after the cancelled installation: %v", itemList)
+	}
+
+	// The replaced tooth is uninstalled after the replacing tooth is installed,
+	// keeping files placed by the replacing tooth.
+	isAccepted = true
+	installResult, err := install(replacingToothDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(installResult.Uninstalled) != 1 || installResult.Uninstalled[0].ToothPath != "example.com/test/test" {
+		t.Errorf("wrong uninstalled tooths: %v", installResult.Uninstalled)
+	}
+
+	itemList, err = List(ctx, ListOptions{Options: options})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(itemList) != 1 || itemList[0].Record.ToothPath != "example.com/test/replacing" ||
+		!itemList[0].Record.IsManuallyInstalled {
+		t.Errorf("wrong list after replacing: %v", itemList)
+	}
+
+	content, err := os.ReadFile(filepath.Join(workspaceDir, "plugins", "a.txt"))
+	if err != nil || string(content) != "replacing" {
+		t.Errorf("wrong file placed by the replacing tooth: %q %v", content, err)
+	}
+}
+
+func TestSessionRestoresState(t *testing.T) {
+	workspaceDir, _ := setUp(t)
+	os.MkdirAll(filepath.Join(workspaceDir, ".lip"), 0755)
+	os.WriteFile(filepath.Join(workspaceDir, ".lip", "workspace.json"),
+		[]byte(`{"target_os": "windows", "target_arch": "amd64"}`), 0644)
+
+	previousDir, _ := os.Getwd()
+	platform.SetDefaultTarget("linux", "arm64")
+	defer platform.SetDefaultTarget("", "")
+
+	s, err := enter(context.Background(), "test", Options{WorkspaceDir: workspaceDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if goos, goarch := platform.DefaultTarget(); goos != "windows" || goarch != "amd64" {
+		t.Errorf("default target in the session = %s/%s", goos, goarch)
+	}
+
+	// Another session waits until the first one leaves.
+	isEntered := make(chan bool)
+	go func() {
+		s, err := enter(context.Background(), "test", Options{WorkspaceDir: workspaceDir})
+		if err == nil {
+			s.leave()
+		}
+		isEntered <- true
+	}()
+
+	select {
+	case <-isEntered:
+		t.Errorf("sessions ran concurrently")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	s.leave()
+	<-isEntered
+
+	if currentDir, _ := os.Getwd(); currentDir != previousDir {
+		t.Errorf("current directory after leaving = %s, want %s", currentDir, previousDir)
+	}
+
+	if goos, goarch := platform.DefaultTarget(); goos != "linux" || goarch != "arm64" {
+		t.Errorf("default target after leaving = %s/%s", goos, goarch)
+	}
+}
@@ -0,0 +1,75 @@
+package lip
+
+import (
+	"context"
+
+	"github.com/liteldev/lip/specifiers"
+	"github.com/liteldev/lip/tooth/toothrecord"
+	"github.com/liteldev/lip/utils/versions"
+)
+
+// ListOptions are the options of List.
+type ListOptions struct {
+	Options
+
+	// Upgradable lists only tooths with a newer version available.
+	Upgradable bool
+}
+
+// ListItem is an installed tooth.
+type ListItem struct {
+	Record toothrecord.Record
+
+	// LatestVersion is the latest version available. It is set only if
+	// Upgradable is set.
+	LatestVersion versions.Version
+}
+
+// List lists installed tooths sorted by tooth path.
+func List(ctx context.Context, options ListOptions) ([]ListItem, error) {
+	s, err := enter(ctx, "list", options.Options)
+	if err != nil {
+		return nil, err
+	}
+	defer s.leave()
+
+	// Get the sorted list of records.
+	recordList, err := toothrecord.ListAll()
+	if err != nil {
+		return nil, err
+	}
+
+	itemList := make([]ListItem, 0, len(recordList))
+
+	if !options.Upgradable {
+		for _, record := range recordList {
+			itemList = append(itemList, ListItem{Record: record})
+		}
+
+		return itemList, nil
+	}
+
+	s.log.Info("Checking for upgradable tooths... (this may take a while)")
+
+	for _, record := range recordList {
+		err = s.check()
+		if err != nil {
+			return nil, err
+		}
+
+		// Get the latest version.
+		specifier, err := specifiers.New(s.ctx, record.ToothPath)
+		if err != nil {
+			s.log.With("tooth", record.ToothPath).Error("failed to get the latest version of " + record.ToothPath + ": " + err.Error())
+			continue
+		}
+		latestVersion := specifier.ToothVersion()
+		if versions.Equal(latestVersion, record.Version) {
+			continue
+		}
+
+		itemList = append(itemList, ListItem{Record: record, LatestVersion: latestVersion})
+	}
+
+	return itemList, nil
+}
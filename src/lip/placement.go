@@ -0,0 +1,180 @@
+package lip
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/liteldev/lip/tooth/toothfile"
+	"github.com/liteldev/lip/tooth/toothpack"
+	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/utils/platform"
+)
+
+// placeFilesFromArchive copies the files of a .tth file to their destinations.
+func placeFilesFromArchive(t toothfile.ToothFile) error {
+	// Open the .tth file.
+	r, err := zip.OpenReader(t.FilePath())
+	if err != nil {
+		return errors.New("failed to open tooth file " + t.FilePath())
+	}
+	defer r.Close()
+
+	// Get the file prefix.
+	filePrefix := toothfile.GetFilePrefix(r)
+
+	for _, placement := range t.Metadata().Placement {
+		if !platform.Match(placement.GOOS, placement.GOARCH) {
+			continue
+		}
+
+		source := placement.Source
+		destination := placement.Destination
+
+		// Create the parent directory of the destination.
+		os.MkdirAll(filepath.Dir(destination), 0755)
+
+		// Iterate through the files in the archive,
+		// and find the source file.
+		for _, f := range r.File {
+			// Do not copy directories.
+			if strings.HasSuffix(f.Name, "/") {
+				continue
+			}
+
+			if f.Name == filePrefix+source {
+				// Open the source file.
+				rc, err := f.Open()
+				if err != nil {
+					return errors.New("failed to open " + source + " in " + t.FilePath())
+				}
+
+				// Directly copy the source file to the destination.
+				fw, err := os.Create(destination)
+				if err != nil {
+					return errors.New("failed to create " + destination)
+				}
+
+				io.Copy(fw, rc)
+
+				rc.Close()
+				fw.Close()
+
+				// Keep executables like tool entrypoints executable.
+				if f.Mode()&0111 != 0 {
+					os.Chmod(destination, 0755)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// placeFilesFromDir copies or links the files of a tooth directory to their
+// destinations. If a file cannot be linked, it is copied with a warning.
+func placeFilesFromDir(t toothfile.ToothFile, isEditable bool, log logger.Entry) error {
+	// Only files that would be packed are part of the tooth.
+	fileList, err := t.FileList()
+	if err != nil {
+		return err
+	}
+	fileMap := make(map[string]bool)
+	for _, file := range fileList {
+		fileMap[file] = true
+	}
+
+	for _, placement := range t.Metadata().Placement {
+		if !platform.Match(placement.GOOS, placement.GOARCH) {
+			continue
+		}
+
+		source := filepath.Join(t.FilePath(), filepath.FromSlash(placement.Source))
+		destination := placement.Destination
+
+		// Do not copy directories.
+		fileInfo, err := os.Stat(source)
+		if err != nil {
+			return errors.New("failed to access " + placement.Source + " in " + t.FilePath())
+		}
+		if fileInfo.IsDir() {
+			continue
+		}
+		if !fileMap[placement.Source] {
+			return errors.New(placement.Source + " in " + t.FilePath() + " is ignored by " + toothpack.IgnoreFileName)
+		}
+
+		// Create the parent directory of the destination.
+		os.MkdirAll(filepath.Dir(destination), 0755)
+
+		if isEditable {
+			err = linkFile(source, destination)
+			if err != nil {
+				log.Warning("%s. The file is copied instead, so edits do not show up until the tooth is reinstalled", err.Error())
+				err = copyFile(source, destination)
+			}
+		} else {
+			err = copyFile(source, destination)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyFile copies a file from source to destination.
+func copyFile(source string, destination string) error {
+	fr, err := os.Open(source)
+	if err != nil {
+		return errors.New("failed to open " + source)
+	}
+	defer fr.Close()
+
+	fw, err := os.Create(destination)
+	if err != nil {
+		return errors.New("failed to create " + destination)
+	}
+	defer fw.Close()
+
+	_, err = io.Copy(fw, fr)
+	if err != nil {
+		return errors.New("failed to copy " + source + " to " + destination)
+	}
+
+	// Keep executables like tool entrypoints executable.
+	if fileInfo, err := fr.Stat(); err == nil && fileInfo.Mode()&0111 != 0 {
+		os.Chmod(destination, 0755)
+	}
+
+	return nil
+}
+
+// linkFile links the destination to the source file with a symbolic link so
+// that edits of the source file show up immediately. It fails if symbolic links
+// are not allowed, e.g. on Windows without developer mode.
+func linkFile(source string, destination string) error {
+	source, err := filepath.Abs(source)
+	if err != nil {
+		return errors.New("failed to get full path of " + source)
+	}
+
+	// Remove the existing file, since links cannot overwrite files.
+	if _, err := os.Lstat(destination); err == nil {
+		err = os.Remove(destination)
+		if err != nil {
+			return errors.New("failed to remove " + destination)
+		}
+	}
+
+	err = os.Symlink(source, destination)
+	if err != nil {
+		return errors.New("failed to link " + destination + " to " + source + ": " + err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package lip
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/liteldev/lip/tooth/toothfile"
+	"github.com/liteldev/lip/tooth/toothpack"
+	"github.com/liteldev/lip/utils/logger"
+)
+
+func TestPlaceFilesKeepExecutable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on Windows")
+	}
+
+	rootDir := t.TempDir()
+	toothDir := filepath.Join(rootDir, "tooth")
+	os.MkdirAll(toothDir, 0755)
+	os.WriteFile(filepath.Join(toothDir, "tooth.json"), []byte(`{
+    "format_version": 1,
+    "tooth": "example.com/test/test",
+    "version": "1.0.0",
+    "placement": [
+        {
+            "source": "tool",
+            "destination": "bin/tool"
+        },
+        {
+            "source": "a.txt",
+            "destination": "a.txt"
+        }
+    ]
+}`), 0644)
+	os.WriteFile(filepath.Join(toothDir, "tool"), []byte("#!/bin/sh\n"), 0755)
+	os.WriteFile(filepath.Join(toothDir, "a.txt"), []byte("a"), 0644)
+
+	toothFilePath := filepath.Join(rootDir, "test.tth")
+	_, err := toothpack.Pack(toothDir, toothFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workingDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(workingDir)
+
+	for _, filePath := range []string{toothDir, toothFilePath} {
+		toothFile, err := toothfile.New(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		workspaceDir := t.TempDir()
+		os.Chdir(workspaceDir)
+
+		if toothFile.IsDir() {
+			err = placeFilesFromDir(toothFile, false, logger.With())
+		} else {
+			err = placeFilesFromArchive(toothFile)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fileInfo, err := os.Stat(filepath.Join(workspaceDir, "bin", "tool"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fileInfo.Mode()&0111 == 0 {
+			t.Errorf("executable placed from %s is not executable", filePath)
+		}
+
+		fileInfo, err = os.Stat(filepath.Join(workspaceDir, "a.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fileInfo.Mode()&0111 != 0 {
+			t.Errorf("regular file placed from %s is executable", filePath)
+		}
+	}
+}
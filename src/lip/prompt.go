@@ -0,0 +1,52 @@
+package lip
+
+import "context"
+
+// PromptKind is the kind of a prompt.
+type PromptKind int
+
+const (
+	// ConfirmationPrompt asks to confirm a message declared in the
+	// confirmation field of tooth.json before installing or uninstalling.
+	// It is asked even if AssumeYes is set.
+	ConfirmationPrompt PromptKind = iota
+
+	// OutsideWorkspacePrompt asks whether a tooth may place files outside the
+	// workspace. It is not asked if AssumeYes is set.
+	OutsideWorkspacePrompt
+)
+
+// Prompt is a question asked during an operation.
+type Prompt struct {
+	Kind      PromptKind
+	ToothPath string
+	Message   string
+
+	// Default is the answer taken if the question is not answered.
+	Default bool
+}
+
+// PromptHandler answers a prompt. Returning false or an error cancels the
+// operation.
+type PromptHandler func(ctx context.Context, prompt Prompt) (bool, error)
+
+// ask asks the prompt handler of the session and returns an error of
+// CancelledError if the prompt is declined.
+func (s *session) ask(prompt Prompt, cancelMessage string) error {
+	if s.options.Prompt == nil {
+		if !prompt.Default {
+			return newError(CancelledError, cancelMessage)
+		}
+		return nil
+	}
+
+	isAccepted, err := s.options.Prompt(s.ctx, prompt)
+	if err != nil {
+		return wrapError(CancelledError, err)
+	}
+	if !isAccepted {
+		return newError(CancelledError, cancelMessage)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package lip
+
+import (
+	"errors"
+
+	"github.com/liteldev/lip/tooth/toothfile"
+	"github.com/liteldev/lip/tooth/toothrecord"
+	"github.com/liteldev/lip/utils/versions"
+	"github.com/liteldev/lip/utils/versions/versionmatch"
+)
+
+// relationEntry is a tooth in the resulting set of installed tooths, either
+// already installed or to be installed.
+type relationEntry struct {
+	toothPath string
+	version   versions.Version
+	conflicts map[string]([][]versionmatch.VersionMatch)
+
+	// satisfies is Satisfies of the record or the metadata of the tooth.
+	satisfies func(toothPath string, versionRange [][]versionmatch.VersionMatch) bool
+}
+
+// checkRelationships checks if any two tooths in the resulting set of installed
+// tooths conflict with each other and returns the records of installed tooths
+// to be replaced by the tooth files.
+func checkRelationships(toothFileList []toothfile.ToothFile) ([]toothrecord.Record, error) {
+	recordList, err := toothrecord.ListAll()
+	if err != nil {
+		return nil, err
+	}
+
+	toInstallMap := make(map[string]bool)
+	replacingMap := make(map[string]string)
+	for _, toothFile := range toothFileList {
+		toInstallMap[toothFile.Metadata().ToothPath] = true
+
+		for _, replacedToothPath := range toothFile.Metadata().Replaces {
+			replacingMap[replacedToothPath] = toothFile.Metadata().ToothPath
+		}
+	}
+
+	// Build the resulting set of installed tooths.
+	entryList := make([]relationEntry, 0)
+	replacedRecordList := make([]toothrecord.Record, 0)
+	for _, record := range recordList {
+		// Tooths to be reinstalled or upgraded are represented by the tooth files.
+		if toInstallMap[record.ToothPath] {
+			continue
+		}
+
+		if _, ok := replacingMap[record.ToothPath]; ok {
+			replacedRecordList = append(replacedRecordList, record)
+			continue
+		}
+
+		entryList = append(entryList, relationEntry{
+			toothPath: record.ToothPath,
+			version:   record.Version,
+			conflicts: record.Conflicts,
+			satisfies: record.Satisfies,
+		})
+	}
+
+	for _, toothFile := range toothFileList {
+		if replacingToothPath, ok := replacingMap[toothFile.Metadata().ToothPath]; ok {
+			return nil, errors.New(toothFile.Metadata().ToothPath + " cannot be installed because it is replaced by " +
+				replacingToothPath)
+		}
+
+		entryList = append(entryList, relationEntry{
+			toothPath: toothFile.Metadata().ToothPath,
+			version:   toothFile.Metadata().Version,
+			conflicts: toothFile.Metadata().Conflicts,
+			satisfies: toothFile.Metadata().Satisfies,
+		})
+	}
+
+	// Check conflicts.
+	for _, entry := range entryList {
+		for conflictToothPath, versionRange := range entry.conflicts {
+			for _, otherEntry := range entryList {
+				if otherEntry.toothPath == entry.toothPath {
+					continue
+				}
+
+				if otherEntry.satisfies(conflictToothPath, versionRange) {
+					return nil, errors.New(entry.toothPath + "@" + entry.version.String() + " conflicts with " +
+						otherEntry.toothPath + "@" + otherEntry.version.String() + " (" + conflictToothPath + " " +
+						versionmatch.RangeString(versionRange) + ")")
+				}
+			}
+		}
+	}
+
+	return replacedRecordList, nil
+}
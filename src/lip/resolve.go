@@ -0,0 +1,566 @@
+package lip
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/liteldev/lip/download"
+	"github.com/liteldev/lip/localfile"
+	"github.com/liteldev/lip/specifiers"
+	"github.com/liteldev/lip/tooth/toothfile"
+	"github.com/liteldev/lip/tooth/toothmetadata"
+	"github.com/liteldev/lip/tooth/toothrecord"
+	"github.com/liteldev/lip/tooth/toothrepo"
+	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/utils/versions"
+	"github.com/liteldev/lip/utils/versions/versionmatch"
+)
+
+// ResolveOptions are the options of Resolve.
+type ResolveOptions struct {
+	Options
+
+	// Specifiers are the tooths to install, in any form accepted by lip
+	// install. Local paths are relative to the working directory set by
+	// localfile.SetWorkingDir, or the current directory if it is not set.
+	Specifiers []string
+
+	// Upgrade upgrades installed tooths specified by the specifiers.
+	Upgrade bool
+
+	// ForceReinstall reinstalls installed tooths specified by the specifiers.
+	ForceReinstall bool
+
+	// NoDependencies does not resolve dependencies.
+	NoDependencies bool
+
+	// Editable links files from local tooth directories instead of copying
+	// them. All specifiers should be local tooth directories.
+	Editable bool
+}
+
+// ResolvedTooth is a tooth fetched to be installed.
+type ResolvedTooth struct {
+	ToothPath string
+	Version   versions.Version
+
+	// FilePath is the path of the .tth file or the tooth directory.
+	FilePath string
+
+	// Extras are the extras selected for the tooth.
+	Extras []string
+
+	// IsRequested is true if the tooth is specified by a specifier rather than
+	// required as a dependency.
+	IsRequested bool
+
+	IsEditable bool
+	GitURL     string
+	GitCommit  string
+
+	specifierString string
+	toothFile       toothfile.ToothFile
+}
+
+// ResolveResult is the tooths to install and the changes to installed tooths.
+type ResolveResult struct {
+	// Tooths are the tooths fetched, with dependencies before dependents.
+	// Those already installed are skipped by Install unless they are to be
+	// reinstalled or upgraded.
+	Tooths []ResolvedTooth
+
+	// Replaced are the records of installed tooths replaced by the tooths.
+	Replaced []toothrecord.Record
+
+	// ExtrasToAdd are extras newly selected for installed tooths that are not
+	// reinstalled. Tooth path -> extras
+	ExtrasToAdd map[string][]string
+
+	specifierList []specifiers.Specifier
+}
+
+// dependencyListType is a list of dependencies of a tooth to resolve.
+type dependencyListType struct {
+	dependentToothPath string
+	dependencies       map[string]([][]versionmatch.VersionMatch)
+}
+
+// Resolve fetches the tooths to install and their dependencies, and checks
+// conflicts and the Lip versions they require, without changing the
+// workspace.
+func Resolve(ctx context.Context, options ResolveOptions) (ResolveResult, error) {
+	s, err := enter(ctx, "resolve", options.Options)
+	if err != nil {
+		return ResolveResult{}, err
+	}
+	defer s.leave()
+
+	return s.resolve(options)
+}
+
+// resolve resolves the tooths to install in the workspace of the session.
+func (s *session) resolve(options ResolveOptions) (ResolveResult, error) {
+	var err error
+
+	if len(options.Specifiers) == 0 {
+		return ResolveResult{}, newError(InvalidArgumentError, "no specifiers to install")
+	}
+
+	if options.ForceReinstall && options.Upgrade {
+		return ResolveResult{}, newError(InvalidArgumentError,
+			"the force-reinstall flag and the upgrade flag cannot be used together")
+	}
+
+	// 1. Validate the requirement specifier or tooth url/path.
+	//    This process will check if the tooth file exists or the tooth url can be accessed
+	//    and if the requirement specifier syntax is valid. For requirement specifier, it
+	//    will also check if the tooth repository can be accessed via GOPROXY.
+
+	s.log.Info("Validating specifiers...")
+
+	// Local paths in specifiers are relative to the working directory rather
+	// than the workspace.
+	workspaceDir, err := localfile.WorkspaceDir()
+	if err != nil {
+		return ResolveResult{}, err
+	}
+	err = os.Chdir(s.workingDir)
+	if err != nil {
+		return ResolveResult{}, err
+	}
+
+	// Make requirementSpecifierList.
+	var requirementSpecifierList []specifiers.Specifier
+	for _, specifierString := range options.Specifiers {
+		s.log.Info("  Validating " + specifierString + "...")
+
+		specifier, err := specifiers.New(s.ctx, specifierString)
+		if err == nil && options.Editable && specifier.Type() != specifiers.ToothDirKind {
+			err = errors.New("only local tooth directories can be installed in editable mode: " + specifierString)
+		}
+		if err != nil {
+			os.Chdir(workspaceDir)
+			return ResolveResult{}, &Error{InvalidArgumentError, err}
+		}
+
+		requirementSpecifierList = append(requirementSpecifierList, specifier)
+	}
+
+	err = os.Chdir(workspaceDir)
+	if err != nil {
+		return ResolveResult{}, err
+	}
+
+	// 2. Parse dependency and download tooth files.
+	//    This process will maintain an array of tooth files to be downloaded. For each
+	//    specifier at the beginning, it will be added to the array. Then, for each
+	//    specifier in the array, if it is downloaded, it will be removed from the array.
+	//    If it is not downloaded, it will be parsed to get its dependencies and add them
+	//    to the array. This process will continue until the array is empty.
+
+	s.log.Info("Resolving dependencies and downloading tooths...")
+
+	// An queue of tooth files to be downloaded.
+	var specifiersToFetch list.List
+
+	// Add all specifiers to the queue.
+	for _, specifier := range requirementSpecifierList {
+		specifiersToFetch.PushBack(specifier)
+	}
+
+	// An array of downloaded tooth files.
+	// Specifier string -> downloaded tooth file path
+	downloadedToothFilePathMap := make(map[string]string)
+
+	// Tooth files fetched so far in the order they are fetched, used to find
+	// tooths satisfying dependencies.
+	fetchedToothList := make([]ResolvedTooth, 0)
+	fetchedToothFileList := make([]toothfile.ToothFile, 0)
+
+	// Extras selected for tooths to install.
+	// Tooth path -> extras
+	selectedExtrasMap := make(map[string][]string)
+
+	// Extras newly selected for installed tooths that are not reinstalled.
+	// Tooth path -> extras
+	extrasToAddMap := make(map[string][]string)
+
+	progressBarStyle := options.ProgressBarStyle
+	if logger.GetLevel() > logger.InfoLevel {
+		progressBarStyle = download.StyleNone
+	}
+
+	for specifiersToFetch.Len() > 0 {
+		// Dependencies to be resolved once the queue is empty. Resolving them later
+		// makes tooths providing virtual tooths visible to dependencies of other
+		// tooths in the same batch.
+		dependencyListToResolve := make([]dependencyListType, 0)
+
+		for specifiersToFetch.Len() > 0 {
+			err = s.check()
+			if err != nil {
+				return ResolveResult{}, err
+			}
+
+			// Get the first specifier to fetch
+			specifier := specifiersToFetch.Front().Value.(specifiers.Specifier)
+			specifiersToFetch.Remove(specifiersToFetch.Front())
+
+			// If the tooth file of the specifier is already downloaded, skip.
+			if downloadedToothFilePath, ok := downloadedToothFilePathMap[specifier.String()]; ok {
+				// However, the specifier may select more extras.
+				toothFile, err := toothfile.New(downloadedToothFilePath)
+				if err != nil {
+					return ResolveResult{}, err
+				}
+
+				toothPath := toothFile.Metadata().ToothPath
+				if len(subtractExtras(specifier.Extras(), selectedExtrasMap[toothPath])) == 0 {
+					continue
+				}
+
+				selectedExtrasMap[toothPath] = mergeExtras(selectedExtrasMap[toothPath], specifier.Extras())
+
+				if !options.NoDependencies {
+					dependencies, err := toothFile.Metadata().DependenciesWithExtras(selectedExtrasMap[toothPath])
+					if err != nil {
+						return ResolveResult{}, &Error{InvalidArgumentError, err}
+					}
+
+					dependencyListToResolve = append(dependencyListToResolve, dependencyListType{toothPath, dependencies})
+				}
+
+				continue
+			}
+
+			// Specifiers are identified by their string before git refs are resolved.
+			specifierString := specifier.String()
+
+			fetchLog := s.log.With("specifier", specifierString)
+			fetchLog.Info("  Fetching " + specifierString + "...")
+
+			// Resolve git refs to commits to record them.
+			specifier, err = specifier.ResolveGitCommit(s.ctx)
+			if err != nil {
+				return ResolveResult{}, &Error{FetchError, err}
+			}
+
+			// Get tooth file
+			isCached, downloadedToothFilePath, err := GetTooth(s.ctx, specifier, progressBarStyle)
+			if err != nil {
+				return ResolveResult{}, &Error{FetchError, err}
+			}
+			if isCached {
+				fetchLog.Info("    Cached.")
+			}
+
+			// Parse the tooth file
+			toothFile, err := toothfile.New(downloadedToothFilePath)
+			var lipVersionErr *toothmetadata.LipVersionError
+			if errors.As(err, &lipVersionErr) {
+				return ResolveResult{}, &Error{IncompatibleError, err}
+			} else if err != nil {
+				return ResolveResult{}, &Error{FetchError, err}
+			}
+
+			// Validate the tooth file.
+			toothPath := toothFile.Metadata().ToothPath
+			if specifier.Type() == specifiers.RequirementKind &&
+				toothPath != specifier.ToothRepo() {
+				// Remove the downloaded tooth file.
+				err = os.Remove(downloadedToothFilePath)
+				if err != nil {
+					fetchLog.Error("Failed to remove the downloaded tooth file: " + err.Error())
+				}
+				return ResolveResult{}, newError(FetchError,
+					"the tooth path of the downloaded tooth file does not match the requirement specifier")
+			}
+
+			fetchLog = fetchLog.With("tooth", toothPath, "version", toothFile.Metadata().Version.String())
+
+			isToothInstalled, err := toothrecord.IsToothInstalled(toothPath)
+			if err != nil {
+				return ResolveResult{}, err
+			}
+			// Extras selected by the specifier.
+			extras := specifier.Extras()
+
+			if isToothInstalled {
+				record, err := toothrecord.Get(toothPath)
+				if err != nil {
+					return ResolveResult{}, err
+				}
+
+				isSkipped := false
+				if !options.ForceReinstall && !options.Upgrade {
+					fetchLog.Info("    Already installed.")
+					isSkipped = true
+				} else if !options.ForceReinstall && options.Upgrade {
+					if versions.Equal(record.Version, toothFile.Metadata().Version) {
+						fetchLog.Info("    Already installed.")
+						isSkipped = true
+					} else if versions.GreaterThan(record.Version, toothFile.Metadata().Version) {
+						fetchLog.Info("    A newer version already installed.")
+						isSkipped = true
+					}
+				} else {
+					fetchLog.Info("    Already installed, reinstalling...")
+				}
+
+				if isSkipped {
+					// Extras newly selected for the installed tooth are added without
+					// reinstalling the tooth.
+					newExtras := subtractExtras(extras, mergeExtras(record.Extras, extrasToAddMap[toothPath]))
+					if len(newExtras) == 0 {
+						continue
+					}
+
+					fetchLog.Info("    Adding extras " + strings.Join(newExtras, ", ") + "...")
+
+					dependencies := make(map[string]([][]versionmatch.VersionMatch))
+					for _, extra := range newExtras {
+						optionalDependencies, ok := record.OptionalDependencies[extra]
+						if !ok {
+							return ResolveResult{}, newError(InvalidArgumentError,
+								"extra "+extra+" is not declared by the installed "+toothPath)
+						}
+
+						for dependencyToothPath, versionRange := range optionalDependencies {
+							dependencies[dependencyToothPath] = versionRange
+						}
+					}
+
+					extrasToAddMap[toothPath] = mergeExtras(extrasToAddMap[toothPath], newExtras)
+
+					if !options.NoDependencies {
+						dependencyListToResolve = append(dependencyListToResolve, dependencyListType{toothPath, dependencies})
+					}
+
+					continue
+				}
+
+				// Keep extras selected for the installed tooth when reinstalling or upgrading.
+				extras = mergeExtras(record.Extras, extras)
+			}
+
+			// Merge extras selected by other specifiers of the same tooth.
+			extras = mergeExtras(selectedExtrasMap[toothPath], extras)
+
+			dependencies, err := toothFile.Metadata().DependenciesWithExtras(extras)
+			if err != nil {
+				return ResolveResult{}, &Error{InvalidArgumentError, err}
+			}
+
+			selectedExtrasMap[toothPath] = extras
+
+			resolvedTooth := ResolvedTooth{
+				ToothPath:       toothPath,
+				Version:         toothFile.Metadata().Version,
+				FilePath:        toothFile.FilePath(),
+				specifierString: specifierString,
+				toothFile:       toothFile,
+			}
+
+			for _, requirementSpecifier := range requirementSpecifierList {
+				if requirementSpecifier.String() == specifierString {
+					resolvedTooth.IsRequested = true
+					break
+				}
+			}
+
+			if specifier.Type() == specifiers.GitKind {
+				resolvedTooth.GitURL = specifier.GitURL()
+				resolvedTooth.GitCommit = specifier.GitCommit()
+			}
+
+			if specifier.Type() == specifiers.ToothDirKind && options.Editable {
+				resolvedTooth.IsEditable = true
+			}
+
+			// Add the downloaded path to the downloaded tooth files.
+			downloadedToothFilePathMap[specifierString] = downloadedToothFilePath
+			fetchedToothList = append(fetchedToothList, resolvedTooth)
+			fetchedToothFileList = append(fetchedToothFileList, toothFile)
+
+			// If the no-dependencies flag is set, skip.
+			if !options.NoDependencies {
+				dependencyListToResolve = append(dependencyListToResolve, dependencyListType{toothPath, dependencies})
+			}
+		}
+
+		for _, dependencyList := range dependencyListToResolve {
+			s.log.Info("  Resolving dependencies of " + dependencyList.dependentToothPath + "...")
+
+			// Get proper version of each dependency and add them to the queue.
+			dependencySpecifierList, err := resolveDependencies(s.ctx, dependencyList, fetchedToothFileList)
+			if err != nil {
+				return ResolveResult{}, err
+			}
+
+			for _, dependencySpecifier := range dependencySpecifierList {
+				specifiersToFetch.PushBack(dependencySpecifier)
+			}
+		}
+	}
+
+	// 2.1. Check relationships between tooths.
+	//    This process will refuse to continue if any two tooths in the resulting set
+	//    of installed tooths conflict with each other. Tooths replaced by tooths to
+	//    install are not considered part of the resulting set.
+
+	s.log.Info("Checking conflicts...")
+
+	replacedRecordList, err := checkRelationships(fetchedToothFileList)
+	if err != nil {
+		return ResolveResult{}, &Error{ConflictError, err}
+	}
+
+	// 2.2. Check that tool entrypoints can be run by shims. The minimum Lip
+	//    version required by tooths is checked when their tooth.json is read.
+
+	for _, toothFile := range fetchedToothFileList {
+		err = toothFile.Metadata().CheckToolEntrypoints()
+		if err != nil {
+			return ResolveResult{}, err
+		}
+	}
+
+	// 2.3. Topological sort the tooths so that dependencies are installed
+	//    before dependents.
+
+	sortedToothFileList, err := sortToothFiles(fetchedToothFileList)
+	if err != nil {
+		return ResolveResult{}, &Error{DependencyError, errors.New("failed to sort the downloaded tooth files: " + err.Error())}
+	}
+
+	sortedToothList := make([]ResolvedTooth, 0, len(sortedToothFileList))
+	for _, toothFile := range sortedToothFileList {
+		for _, fetchedTooth := range fetchedToothList {
+			if fetchedTooth.ToothPath != toothFile.Metadata().ToothPath {
+				continue
+			}
+
+			fetchedTooth.Extras = selectedExtrasMap[fetchedTooth.ToothPath]
+			if fetchedTooth.Extras == nil {
+				fetchedTooth.Extras = []string{}
+			}
+			sortedToothList = append(sortedToothList, fetchedTooth)
+			break
+		}
+	}
+
+	return ResolveResult{
+		Tooths:        sortedToothList,
+		Replaced:      replacedRecordList,
+		ExtrasToAdd:   extrasToAddMap,
+		specifierList: requirementSpecifierList,
+	}, nil
+}
+
+// resolveDependencies resolves a list of dependencies and returns the specifiers
+// of tooths to fetch. Dependencies already satisfied by fetched tooth files or
+// installed tooths, including those providing the dependency, are skipped.
+func resolveDependencies(ctx context.Context, dependencyList dependencyListType, fetchedToothFileList []toothfile.ToothFile) ([]specifiers.Specifier, error) {
+	specifierList := make([]specifiers.Specifier, 0)
+
+	for toothPath, versionRange := range dependencyList.dependencies {
+		logger.Info("    Resolving " + toothPath + "...")
+
+		// Check if any fetched tooth file satisfies the dependency.
+		isSatisfied := false
+		for _, fetchedToothFile := range fetchedToothFileList {
+			if fetchedToothFile.Metadata().Satisfies(toothPath, versionRange) {
+				logger.Info("      Satisfied by " + fetchedToothFile.Metadata().ToothPath + "@" +
+					fetchedToothFile.Metadata().Version.String() + " to be installed.")
+				isSatisfied = true
+				break
+			}
+		}
+		if isSatisfied {
+			continue
+		}
+
+		// If the dependency or any tooth providing it is installed, check if the
+		// installed version matches the requirement.
+		providerList, err := toothrecord.ListProviders(toothPath)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, provider := range providerList {
+			if provider.Satisfies(toothPath, versionRange) {
+				logger.Info("      Installed " + provider.ToothPath + "@" + provider.Version.String() + " matches the requirement.")
+				isSatisfied = true
+				break
+			}
+		}
+		if isSatisfied {
+			continue
+		}
+
+		for _, provider := range providerList {
+			if provider.ToothPath == toothPath {
+				return nil, newError(DependencyError, "the installed version of "+toothPath+"("+provider.Version.String()+
+					") does not match the requirement of "+dependencyList.dependentToothPath+"("+
+					versionmatch.RangeString(versionRange)+")")
+			}
+		}
+
+		// Select the newest version matching the requirement.
+		versionList, err := toothrepo.FetchModuleVersionList(ctx, toothPath)
+		if err != nil {
+			return nil, &Error{FetchError, err}
+		}
+
+		isMatched := false
+		for _, version := range versionList {
+			if !versionmatch.MatchRange(version, versionRange) {
+				continue
+			}
+
+			specifier, err := specifiers.New(ctx, toothPath+"@"+version.String())
+			if err != nil {
+				return nil, &Error{FetchError, err}
+			}
+			specifierList = append(specifierList, specifier)
+			isMatched = true
+			break
+		}
+
+		if !isMatched {
+			return nil, newError(DependencyError, "no version of "+toothPath+" matches the requirement of "+
+				dependencyList.dependentToothPath)
+		}
+	}
+
+	return specifierList, nil
+}
+
+// mergeExtras returns extras in either list without duplication.
+func mergeExtras(extras1 []string, extras2 []string) []string {
+	result := make([]string, 0, len(extras1)+len(extras2))
+	result = append(result, extras1...)
+	result = append(result, subtractExtras(extras2, extras1)...)
+
+	return result
+}
+
+// subtractExtras returns extras in the first list but not in the second list.
+func subtractExtras(extras1 []string, extras2 []string) []string {
+	result := make([]string, 0, len(extras1))
+
+ForEachExtra:
+	for _, extra := range extras1 {
+		for _, excludedExtra := range extras2 {
+			if extra == excludedExtra {
+				continue ForEachExtra
+			}
+		}
+		result = append(result, extra)
+	}
+
+	return result
+}
@@ -0,0 +1,148 @@
+package lip
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	lipcontext "github.com/liteldev/lip/context"
+	"github.com/liteldev/lip/utils/versions/versionmatch"
+)
+
+func TestResolveDependenciesMajorVersion(t *testing.T) {
+	workspaceDir, _ := setUp(t)
+
+	// A GOPROXY serving the base, /v2 and /v3 module paths of a tooth.
+	versionListMap := map[string]string{
+		"/example.com/foo/@v/list":    "v1.0.0\nv1.1.0\n",
+		"/example.com/foo/v2/@v/list": "v2.0.0\nv2.1.0\n",
+		"/example.com/foo/v3/@v/list": "v3.0.0\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if content, ok := versionListMap[r.URL.Path]; ok {
+			w.Write([]byte(content))
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, ".info") {
+			w.Write([]byte("{}"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	lipcontext.Init()
+	goproxyList := lipcontext.GoproxyList
+	lipcontext.GoproxyList = []string{server.URL}
+	defer func() { lipcontext.GoproxyList = goproxyList }()
+
+	s, err := enter(context.Background(), "resolve", Options{WorkspaceDir: workspaceDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.leave()
+
+	testList := []struct {
+		toothPath    string
+		versionRange string
+		output       string
+	}{
+		{"example.com/foo/v2", ">=2.0.0", "example.com/foo/v2@2.1.0"},
+		{"example.com/foo/v2", "*", "example.com/foo/v2@2.1.0"},
+		{"example.com/foo", ">=1.0.0", "example.com/foo@1.1.0"},
+		{"example.com/foo/v3", "3.x", "example.com/foo/v3@3.0.0"},
+	}
+
+	for index, test := range testList {
+		versionRange, err := versionmatch.NewRangeFromString(test.versionRange)
+		if err != nil {
+			t.Fatalf("error at test %d: %s", index, err.Error())
+		}
+
+		specifierList, err := resolveDependencies(context.Background(), dependencyListType{
+			dependentToothPath: "example.com/test/test",
+			dependencies:       map[string]([][]versionmatch.VersionMatch){test.toothPath: versionRange},
+		}, nil)
+		if err != nil {
+			t.Fatalf("error at test %d: %s", index, err.Error())
+		}
+
+		if len(specifierList) != 1 || specifierList[0].String() != test.output {
+			t.Errorf("wrong output at test %d: %v", index, specifierList)
+		}
+	}
+
+	// No version of the /v2 module path matches, although /v3 has one.
+	versionRange, _ := versionmatch.NewRangeFromString(">=3.0.0")
+	_, err = resolveDependencies(context.Background(), dependencyListType{
+		dependentToothPath: "example.com/test/test",
+		dependencies:       map[string]([][]versionmatch.VersionMatch){"example.com/foo/v2": versionRange},
+	}, nil)
+	if KindOf(err) != DependencyError {
+		t.Errorf("wrong error when no version of the module path matches: %v", err)
+	}
+}
+
+func TestResolveMajorVersionSpecifier(t *testing.T) {
+	workspaceDir, _ := setUp(t)
+
+	// A GOPROXY serving version 2.0.0 both from the /v2 module path and as an
+	// +incompatible version of the base module path.
+	toothZipMap := map[string][]byte{
+		"/example.com/foo/v2/@v/v2.0.0.zip":           newGoproxyZip(t, "example.com/foo/v2@v2.0.0", "example.com/foo/v2"),
+		"/example.com/foo/@v/v2.0.0+incompatible.zip": newGoproxyZip(t, "example.com/foo@v2.0.0+incompatible", "example.com/foo"),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if content, ok := toothZipMap[r.URL.Path]; ok {
+			w.Write(content)
+			return
+		}
+		if _, ok := toothZipMap[strings.TrimSuffix(r.URL.Path, ".info")+".zip"]; ok {
+			w.Write([]byte("{}"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	lipcontext.Init()
+	goproxyList := lipcontext.GoproxyList
+	lipcontext.GoproxyList = []string{server.URL}
+	defer func() { lipcontext.GoproxyList = goproxyList }()
+
+	for index, toothPath := range []string{"example.com/foo", "example.com/foo/v2"} {
+		result, err := Resolve(context.Background(), ResolveOptions{
+			Options:        Options{WorkspaceDir: workspaceDir},
+			Specifiers:     []string{toothPath + "@2.0.0"},
+			NoDependencies: true,
+		})
+		if err != nil {
+			t.Fatalf("error at test %d: %s", index, err.Error())
+		}
+
+		if len(result.Tooths) != 1 || result.Tooths[0].ToothPath != toothPath {
+			t.Errorf("wrong output at test %d: %v", index, result.Tooths)
+		}
+	}
+}
+
+// newGoproxyZip returns a module zip served by GOPROXY containing tooth.json of
+// the tooth path.
+func newGoproxyZip(t *testing.T, prefix string, toothPath string) []byte {
+	buffer := new(bytes.Buffer)
+	writer := zip.NewWriter(buffer)
+	fileWriter, err := writer.Create(prefix + "/tooth.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileWriter.Write([]byte(`{"format_version": 1, "tooth": "` + toothPath + `", "version": "2.0.0"}`))
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
@@ -0,0 +1,97 @@
+package lip
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/liteldev/lip/localfile"
+	"github.com/liteldev/lip/registry"
+	"github.com/liteldev/lip/tooth/toothrecord"
+	"github.com/liteldev/lip/tooth/toothrepo"
+	"github.com/liteldev/lip/utils/versions"
+)
+
+// ShowOptions are the options of Show.
+type ShowOptions struct {
+	Options
+
+	// ToothPath is the tooth to show. An alias is looked up in the registry.
+	ToothPath string
+
+	// AvailableVersions fetches the versions available in the tooth
+	// repository.
+	AvailableVersions bool
+}
+
+// ShowResult is the information about a tooth.
+type ShowResult struct {
+	// ToothPath is the tooth path after looking up the alias.
+	ToothPath string
+
+	IsInstalled bool
+
+	// Record is the record of the tooth if it is installed.
+	Record toothrecord.Record
+
+	// AvailableVersions are the versions available in the tooth repository if
+	// AvailableVersions is set, newest first.
+	AvailableVersions []versions.Version
+}
+
+// Show shows information about a tooth. If it is not installed, only the
+// available versions are shown.
+func Show(ctx context.Context, options ShowOptions) (ShowResult, error) {
+	s, err := enter(ctx, "show", options.Options)
+	if err != nil {
+		return ShowResult{}, err
+	}
+	defer s.leave()
+
+	// If the input is an alias, convert it to the repo path.
+	toothPath := strings.ToLower(options.ToothPath)
+	if toothPath == "" {
+		return ShowResult{}, newError(InvalidArgumentError, "no tooth to show")
+	}
+	if !strings.Contains(toothPath, "/") {
+		toothPath, err = registry.LookupAlias(toothPath)
+		if err != nil {
+			return ShowResult{}, &Error{InvalidArgumentError, err}
+		}
+		s.log.Info("The alias is converted to the repo path: " + toothPath)
+	}
+
+	result := ShowResult{ToothPath: toothPath}
+
+	recordDir, err := localfile.RecordDir()
+	if err != nil {
+		return ShowResult{}, err
+	}
+	recordFilePath := filepath.Join(recordDir, localfile.GetRecordFileName(toothPath))
+
+	// Check if the record file exists.
+	if _, err := os.Stat(recordFilePath); err == nil {
+		result.Record, err = toothrecord.NewFromFile(recordFilePath)
+		if err != nil {
+			return ShowResult{}, err
+		}
+		result.IsInstalled = true
+	}
+
+	if options.AvailableVersions {
+		err = s.check()
+		if err != nil {
+			return ShowResult{}, err
+		}
+
+		s.log.Info("Fetching available versions...")
+
+		result.AvailableVersions, err = toothrepo.FetchVersionList(s.ctx, toothPath)
+		if err != nil {
+			return ShowResult{}, newError(FetchError, "failed to fetch available versions: "+err.Error())
+		}
+	}
+
+	return result, nil
+}
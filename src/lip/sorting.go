@@ -1,4 +1,4 @@
-package cmdlipinstall
+package lip
 
 import (
 	"errors"
@@ -17,6 +17,17 @@ func sortToothFiles(toothFileList []toothfile.ToothFile) ([]toothfile.ToothFile,
 		toothFileMap[toothFile.Metadata().ToothPath] = toothFile
 	}
 
+	// Tooth files providing other tooth paths are also regarded as the tooth
+	// files of the provided tooth paths, unless the provided tooth paths are
+	// in the list.
+	for _, toothFile := range toothFileList {
+		for providedToothPath := range toothFile.Metadata().Provides {
+			if _, ok := toothFileMap[providedToothPath]; !ok {
+				toothFileMap[providedToothPath] = toothFile
+			}
+		}
+	}
+
 	preVisited := make(map[string]bool)
 	visited := make(map[string]bool)
 	sorted := make([]toothfile.ToothFile, 0, len(toothFileList))
@@ -47,8 +58,20 @@ func visit(toothFile toothfile.ToothFile, toothFileMap map[string]toothfile.Toot
 		return errors.New("circular dependency detected")
 	}
 
-	preVisited[toothFile.Metadata().ToothPath] = true
+	// Optional dependencies are also considered, since the tooth files of the
+	// optional dependencies are only in the list when the extras are selected.
+	depToothPathMap := make(map[string]bool)
 	for depToothPath := range toothFile.Metadata().Dependencies {
+		depToothPathMap[depToothPath] = true
+	}
+	for _, optionalDependencies := range toothFile.Metadata().OptionalDependencies {
+		for depToothPath := range optionalDependencies {
+			depToothPathMap[depToothPath] = true
+		}
+	}
+
+	preVisited[toothFile.Metadata().ToothPath] = true
+	for depToothPath := range depToothPathMap {
 		// Find the tooth file of the dependency.
 		dep, ok := toothFileMap[depToothPath]
 		if !ok {
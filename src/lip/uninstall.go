@@ -0,0 +1,419 @@
+package lip
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/liteldev/lip/localfile"
+	"github.com/liteldev/lip/registry"
+	"github.com/liteldev/lip/tooth/toothrecord"
+	"github.com/liteldev/lip/tooth/toothshim"
+	"github.com/liteldev/lip/utils/paths"
+	"github.com/liteldev/lip/utils/platform"
+)
+
+// UninstallOptions are the options of Uninstall.
+type UninstallOptions struct {
+	Options
+
+	// ToothPaths are the tooths to uninstall. Aliases are looked up in the
+	// registry.
+	ToothPaths []string
+
+	// KeepPossession keeps files that tooths specified them to occupy, e.g.
+	// configuration files and data files.
+	KeepPossession bool
+}
+
+// AutoremoveOptions are the options of Autoremove.
+type AutoremoveOptions struct {
+	Options
+
+	// KeepPossession keeps files that tooths specified them to occupy, e.g.
+	// configuration files and data files.
+	KeepPossession bool
+}
+
+// UninstallResult is the tooths uninstalled.
+type UninstallResult struct {
+	Uninstalled []ToothVersion
+}
+
+// Uninstall uninstalls tooths from the workspace, sorted by tooth path. No
+// tooth is uninstalled if any of them is not installed.
+func Uninstall(ctx context.Context, options UninstallOptions) (UninstallResult, error) {
+	s, err := enter(ctx, "uninstall", options.Options)
+	if err != nil {
+		return UninstallResult{}, err
+	}
+	defer s.leave()
+
+	if len(options.ToothPaths) == 0 {
+		return UninstallResult{}, newError(InvalidArgumentError, "no tooths to uninstall")
+	}
+
+	err = s.runDeferredCommands()
+	if err != nil {
+		return UninstallResult{}, err
+	}
+
+	// 1. Check if all tooth paths are installed.
+
+	s.log.Info("Checking if all tooth paths are installed...")
+
+	// Convert all aliases to tooth paths.
+	toothPathList := make([]string, 0, len(options.ToothPaths))
+	toothPathMap := make(map[string]bool)
+	for _, toothPath := range options.ToothPaths {
+		if !strings.Contains(toothPath, "/") {
+			toothPath, err = registry.LookupAlias(toothPath)
+			if err != nil {
+				return UninstallResult{}, &Error{InvalidArgumentError, err}
+			}
+		}
+
+		toothPath = strings.ToLower(toothPath)
+		if !toothPathMap[toothPath] {
+			toothPathMap[toothPath] = true
+			toothPathList = append(toothPathList, toothPath)
+		}
+	}
+	sort.Strings(toothPathList)
+
+	recordList, err := toothrecord.ListAll()
+	if err != nil {
+		return UninstallResult{}, err
+	}
+
+	// Tooth path -> record
+	recordMap := make(map[string]toothrecord.Record)
+	for _, record := range recordList {
+		recordMap[record.ToothPath] = record
+	}
+
+	// Check if all tooths to uninstall are installed.
+	for _, toothPath := range toothPathList {
+		if _, ok := recordMap[toothPath]; !ok {
+			return UninstallResult{}, newError(NotInstalledError, "the tooth "+toothPath+" is not installed")
+		}
+	}
+
+	// 2. Uninstall tooths.
+
+	s.log.Info("Uninstalling tooths...")
+
+	result := UninstallResult{Uninstalled: make([]ToothVersion, 0, len(toothPathList))}
+	for _, toothPath := range toothPathList {
+		err = s.check()
+		if err != nil {
+			return result, err
+		}
+
+		record := recordMap[toothPath]
+
+		s.log.With("tooth", toothPath, "version", record.Version.String()).Info("  Uninstalling " + toothPath + "...")
+
+		possessionList := make([]string, 0)
+		if options.KeepPossession {
+			possessionList = record.Possession
+		}
+
+		err = s.uninstall(localfile.GetRecordFileName(toothPath), possessionList, nil)
+		if err != nil {
+			return result, err
+		}
+
+		result.Uninstalled = append(result.Uninstalled, ToothVersion{record.ToothPath, record.Version})
+	}
+
+	s.log.Info("Successfully uninstalled all tooths.")
+
+	return result, nil
+}
+
+// Autoremove uninstalls tooths that are neither manually installed nor
+// depended by any other tooths.
+func Autoremove(ctx context.Context, options AutoremoveOptions) (UninstallResult, error) {
+	s, err := enter(ctx, "autoremove", options.Options)
+	if err != nil {
+		return UninstallResult{}, err
+	}
+	defer s.leave()
+
+	err = s.runDeferredCommands()
+	if err != nil {
+		return UninstallResult{}, err
+	}
+
+	s.log.Info("Discovering tooths not depended by any other tooths...")
+
+	// 1. Gets all installed tooths.
+	recordList, err := toothrecord.ListAll()
+	if err != nil {
+		return UninstallResult{}, err
+	}
+
+	// 2. Marks all manually installed tooths.
+	toothsToKeep := make(map[string]bool)
+	for _, record := range recordList {
+		if record.IsManuallyInstalled {
+			toothsToKeep[record.ToothPath] = true
+		}
+	}
+
+	// 3. Marks all tooths that are depended by other tooths.
+	markCount := 0
+	for {
+		for _, record := range recordList {
+			if !toothsToKeep[record.ToothPath] {
+				continue
+			}
+
+			// Optional dependencies of selected extras are also considered.
+			for dep, versionRange := range record.ActiveDependencies() {
+				if !toothsToKeep[dep] {
+					toothsToKeep[dep] = true
+					markCount++
+				}
+
+				// Tooths providing the dependency are also depended.
+				for _, provider := range recordList {
+					if !toothsToKeep[provider.ToothPath] && provider.Satisfies(dep, versionRange) {
+						toothsToKeep[provider.ToothPath] = true
+						markCount++
+					}
+				}
+			}
+		}
+
+		if markCount == 0 {
+			break
+		}
+
+		markCount = 0
+	}
+
+	s.log.Info("Uninstalling tooths not depended by any other tooths...")
+
+	// 4. Uninstalls all unmarked tooths.
+	result := UninstallResult{Uninstalled: make([]ToothVersion, 0)}
+	for _, record := range recordList {
+		if toothsToKeep[record.ToothPath] {
+			continue
+		}
+
+		err = s.check()
+		if err != nil {
+			return result, err
+		}
+
+		s.log.With("tooth", record.ToothPath, "version", record.Version.String()).Info("  Uninstalling " + record.ToothPath + "...")
+
+		possessionList := make([]string, 0)
+		if options.KeepPossession {
+			possessionList = record.Possession
+		}
+
+		err = s.uninstall(localfile.GetRecordFileName(record.ToothPath), possessionList, nil)
+		if err != nil {
+			return result, err
+		}
+
+		result.Uninstalled = append(result.Uninstalled, ToothVersion{record.ToothPath, record.Version})
+	}
+
+	s.log.Info("Successfully uninstalled all tooths not depended by any other tooths.")
+
+	return result, nil
+}
+
+// uninstall uninstalls a tooth.
+// It deletes the files and folders specified in the record file.
+// It also deletes the record file.
+// However, when files are in both the possession of the record file
+// and one in the possession list, the file is not deleted. Files in
+// keptFileList, which are placed by other tooths, are not deleted either.
+func (s *session) uninstall(recordFileName string, possessionList []string, keptFileList []string) error {
+	// Read the record file.
+	recordDir, err := localfile.RecordDir()
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(recordDir + "/" + recordFileName)
+	if err != nil {
+		return errors.New("cannot read the record file " + recordDir + "/" + recordFileName + ": " + err.Error())
+	}
+
+	// Parse the record file.
+	currentRecord, err := toothrecord.NewFromJSON(content)
+	if err != nil {
+		return errors.New(err.Error())
+	}
+	toothLog := s.log.With("tooth", currentRecord.ToothPath, "version", currentRecord.Version.String())
+
+	// Files and commands are selected by the platform that the tooth was
+	// installed for. Records without one are regarded as installed for the
+	// target platform.
+	goos, goarch := currentRecord.Platform()
+
+	// 2. Ask for confirmation if the tooth requires confirmation.
+
+	if len(currentRecord.Confirmation) > 0 {
+		for _, confirmation := range currentRecord.Confirmation {
+			if confirmation.Type != "uninstall" {
+				continue
+			}
+
+			if !platform.MatchPlatform(confirmation.GOOS, confirmation.GOARCH, goos, goarch) {
+				continue
+			}
+
+			err = s.ask(Prompt{
+				Kind:      ConfirmationPrompt,
+				ToothPath: currentRecord.ToothPath,
+				Message:   confirmation.Message,
+				Default:   true,
+			}, "uninstallation cancelled")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// 2. Run pre-uninstall commands.
+	//    Iterate over the commands and run the commands that are
+	//    for the current OS and architecture.
+	for _, commandItem := range currentRecord.Commands {
+		if commandItem.Type != "uninstall" {
+			continue
+		}
+
+		// Validate GOOS and GOARCH. If GOARCH is empty, it is valid for all GOARCH.
+		if !platform.MatchPlatform(commandItem.GOOS, commandItem.GOARCH, goos, goarch) {
+			continue
+		}
+
+		// Commands for other platforms cannot run here.
+		if !platform.IsNativePlatform(goos, goarch) {
+			toothLog.Warning("Skipped pre-uninstall commands of " + currentRecord.ToothPath + " for " + goos + "/" + goarch +
+				" because Lip is running on " + platform.Native() + ".")
+			continue
+		}
+
+		// Run the command.
+		for _, command := range commandItem.Commands {
+			err := s.runCommand(command)
+			if err != nil {
+				return errors.New("failed to run command: " + command + ": " + err.Error())
+			}
+		}
+	}
+
+	// 3. Delete files and folders.
+	//    Iterate over the placements and delete files specified
+	//    in the destinations. Iterate over the possessions and delete the folders
+	//    as well as the files in the folders but keep the files in the specific possession
+	//    list to preserved.
+
+	//    Interate over the placements and delete files specified
+	//    in the destinations.
+	for _, placement := range currentRecord.Placement {
+		if !platform.MatchPlatform(placement.GOOS, placement.GOARCH, goos, goarch) {
+			continue
+		}
+
+		destination := placement.Destination
+		destination = filepath.FromSlash(destination)
+		destination = filepath.Clean(destination)
+
+		// Get the absolute path of the destination.
+		destination, err = filepath.Abs(destination)
+		if err != nil {
+			return errors.New("cannot get the absolute path of the destination " + destination + ": " + err.Error())
+		}
+
+		// Continue if the destination does not exist.
+		if _, err := os.Stat(destination); os.IsNotExist(err) {
+			continue
+		}
+
+		// Continue if another tooth placed the file.
+		isKept := false
+		for _, keptFile := range keptFileList {
+			if paths.IsIdentical(keptFile, destination) {
+				isKept = true
+				break
+			}
+		}
+		if isKept {
+			continue
+		}
+
+		err = os.Remove(destination)
+		if err != nil {
+			toothLog.Error("cannot delete the file " + destination + ": " + err.Error() + ". Please delete it manually.")
+		}
+
+		// Delete all ancestor directories if they are empty until the workspace directory.
+		workspaceDir, err := localfile.WorkspaceDir()
+		if err != nil {
+			return err
+		}
+
+		for parentDir := filepath.Dir(destination); parentDir != workspaceDir && paths.IsAncesterOf(workspaceDir, parentDir); parentDir = filepath.Dir(parentDir) {
+			files, err := os.ReadDir(parentDir)
+			if err != nil {
+				return errors.New("cannot read the directory " + parentDir + ": " + err.Error())
+			}
+
+			if len(files) == 0 {
+				err = os.Remove(parentDir)
+				if err != nil {
+					toothLog.Error("cannot delete the directory " + parentDir + ": " + err.Error() + ". Please delete it manually.")
+				}
+			} else {
+				break
+			}
+		}
+	}
+
+	// Iterate over the possessions and delete the folders as well as
+	// the files in the folders.
+ForEachOldPossession:
+	for _, possession := range currentRecord.Possession {
+		// Continue if the possession is in the new possession list.
+		for _, newPossession := range possessionList {
+			if paths.IsIdentical(newPossession, possession) {
+				continue ForEachOldPossession
+			}
+		}
+
+		// Remove the folder.
+		err = os.RemoveAll(possession)
+		if err != nil {
+			toothLog.Error("cannot delete " + possession + ": " + err.Error() + ". Please delete it manually.")
+		}
+	}
+
+	// 4. Delete the record file.
+	//    The record file is deleted after the files and folders are deleted
+	//    so that the record file is not deleted if the files and folders
+	//    cannot be deleted.
+	err = os.Remove(recordDir + "/" + recordFileName)
+	if err != nil {
+		toothLog.Error("cannot delete the record file " + recordDir + "/" + recordFileName + ": " + err.Error() + ". Please delete it manually.")
+	}
+
+	// 5. Remove the shim of the tool.
+	err = toothshim.Sync()
+	if err != nil {
+		s.log.Warning("failed to update shims: " + err.Error())
+	}
+
+	return nil
+}
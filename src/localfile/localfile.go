@@ -9,7 +9,10 @@ import (
 	"path/filepath"
 )
 
-// Init initializes the ~/.lip and ./.lip directories.
+// workingDir is the directory where Lip was started.
+var workingDir string
+
+// Init initializes the ~/.lip directory.
 // It should be called before any other functions in this package.
 func Init() error {
 	// Initialize the ~/.lip directory.
@@ -24,7 +27,12 @@ func Init() error {
 	os.MkdirAll(homeLipDir, 0755)
 	os.MkdirAll(cacheDir, 0755)
 
-	// Initialize the ./.lip directory.
+	return nil
+}
+
+// InitWorkspace initializes the ./.lip directory, which makes the current
+// directory a workspace.
+func InitWorkspace() error {
 	workspaceLipDir, err := WorkspaceLipDir()
 	if err != nil {
 		return err
@@ -78,6 +86,26 @@ func HomeLipDir() (string, error) {
 	return homeLipDir, nil
 }
 
+// GlobalBinDir returns the path to the ~/.lip/bin directory, which contains
+// shims of globally installed tools.
+func GlobalBinDir() (string, error) {
+	homeLipDir, err := HomeLipDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeLipDir, "bin"), nil
+}
+
+// GlobalDir returns the path to the ~/.lip/global directory, which is the
+// workspace of globally installed tools.
+func GlobalDir() (string, error) {
+	homeLipDir, err := HomeLipDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeLipDir, "global"), nil
+}
+
 // IsCachedToothFileExist returns true if the cached tooth file exists.
 func IsCachedToothFileExist(fullSpecifier string) (bool, error) {
 	// Get the path to the cached tooth file.
@@ -107,7 +135,25 @@ func RecordDir() (string, error) {
 	return recordDir, nil
 }
 
-// WorkspaceDir returns the absolute path to the current working directory.
+// SetWorkingDir sets the directory where Lip was started. Lip changes its
+// working directory to the workspace, so relative paths given in the command
+// line should be resolved against this directory.
+func SetWorkingDir(dir string) {
+	workingDir = dir
+}
+
+// WorkingDir returns the absolute path to the directory where Lip was started.
+// It is the current working directory if SetWorkingDir is not called.
+func WorkingDir() (string, error) {
+	if workingDir != "" {
+		return workingDir, nil
+	}
+
+	return WorkspaceDir()
+}
+
+// WorkspaceDir returns the absolute path to the current working directory,
+// which is the workspace after Lip changes to it.
 func WorkspaceDir() (string, error) {
 	dirname, err := os.Getwd()
 	if err != nil {
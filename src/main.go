@@ -1,37 +1,81 @@
 package main
 
 import (
+	"errors"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
 	"runtime"
+	"strings"
+	"syscall"
 
 	cmdlip "github.com/liteldev/lip/cmd"
+	"github.com/liteldev/lip/context"
 	"github.com/liteldev/lip/localfile"
+	"github.com/liteldev/lip/selfupdate"
 	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/utils/versions"
+	"github.com/liteldev/lip/workspace"
 )
 
 func main() {
 	var err error
 
-	// Initialize the ~/.lip and ./.lip directories.
-	err = localfile.Init()
+	// Lip is started in the working directory. When redirected, it is passed by
+	// the parent process because the working directory is changed to the
+	// workspace.
+	workingDir := os.Getenv("LIP_WORKING_DIR")
+	os.Unsetenv("LIP_WORKING_DIR")
+	if workingDir == "" {
+		workingDir, err = localfile.WorkspaceDir()
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+	}
+	localfile.SetWorkingDir(workingDir)
+
+	// Change the working directory to the workspace.
+	workspaceDir, err := resolveWorkspaceDir(workingDir, os.Args[1:])
 	if err != nil {
 		logger.Error(err.Error())
 		os.Exit(1)
 	}
-
-	// Change the working directory to the project root.
-	workspaceDir, err := localfile.WorkspaceDir()
+	err = os.Chdir(workspaceDir)
 	if err != nil {
 		logger.Error(err.Error())
 		os.Exit(1)
 	}
-	err = os.Chdir(workspaceDir)
+
+	// Only existing workspaces and workspaces modified by the command get
+	// ./.lip, so that read-only commands leave other directories untouched.
+	_, commandArgs := parseLipArgs(os.Args[1:])
+	fileInfo, err := os.Stat(".lip")
+	isWorkspace := (err == nil && fileInfo.IsDir()) || isModifyingCommand(commandArgs)
+
+	// Initialize the ~/.lip directory.
+	err = localfile.Init()
 	if err != nil {
 		logger.Error(err.Error())
 		os.Exit(1)
 	}
 
+	if isWorkspace {
+		// Initialize the ./.lip directory.
+		err = localfile.InitWorkspace()
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		// Remember the workspace for lip workspaces list.
+		err = workspace.Register(workspaceDir)
+		if err != nil {
+			logger.Warning("failed to register the workspace: " + err.Error())
+		}
+	}
+
 	// Attempt to execute .lip/tools/lip or .lip/tools/lip.exe if it exists.
 	if os.Getenv("LIP_REDIRECTED") == "" { // Prevent infinite redirection.
 		lipExeName := "lip"
@@ -73,17 +117,36 @@ func main() {
 				logger.Error("failed to move new Lip version: " + err.Error())
 				os.Exit(1)
 			}
+
+			// The version of the new {lipExeName} is unknown.
+			os.Remove(".lip/tools/lip/lip.version")
+		}
+
+		// Fetch the Lip version pinned by the workspace into .lip/tools/lip. If
+		// this Lip is of that version, it runs without redirection.
+		isPinnedVersion, err := ensurePinnedLip()
+		if err != nil {
+			logger.Error("failed to use the Lip version pinned by the workspace: " + err.Error())
+			os.Exit(1)
+		}
+		if isPinnedVersion {
+			cmdlip.Run(os.Args[1:])
+			return
 		}
 
 		if _, err := os.Stat(".lip/tools/lip/" + lipExeName); err == nil {
 			logger.Debug("Redirecting to .lip/tools/lip/" + lipExeName)
 			cmd := exec.Command(".lip/tools/lip/"+lipExeName, os.Args[1:]...)
-			cmd.Env = append(os.Environ(), "LIP_REDIRECTED=1")
+			cmd.Env = append(os.Environ(), "LIP_REDIRECTED=1", "LIP_WORKING_DIR="+workingDir)
 			cmd.Stdout = os.Stdout
 			cmd.Stderr = os.Stderr
 			cmd.Stdin = os.Stdin
-			err = cmd.Run()
-			if err != nil {
+			err = runRedirected(cmd)
+			if exitError, ok := err.(*exec.ExitError); ok {
+				// The redirected Lip ran but failed, e.g. lip exec passing
+				// through the exit code of a tool.
+				os.Exit(exitError.ExitCode())
+			} else if err != nil {
 				logger.Error("redirection failed, falling back: " + err.Error())
 				cmdlip.Run(os.Args[1:])
 				return
@@ -94,3 +157,155 @@ func main() {
 
 	cmdlip.Run(os.Args[1:])
 }
+
+// runRedirected runs the redirected Lip and waits for it. SIGINT and SIGTERM
+// must not make this process exit before the redirected Lip, which handles them
+// itself, e.g. lip exec forwarding them to a tool. SIGINT from the terminal
+// reaches the redirected Lip as well, and SIGTERM is forwarded to it.
+func runRedirected(cmd *exec.Cmd) error {
+	err := cmd.Start()
+	if err != nil {
+		return err
+	}
+
+	signalChannel := make(chan os.Signal, 1)
+	signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for sig := range signalChannel {
+			if sig == syscall.SIGTERM {
+				cmd.Process.Signal(sig)
+			}
+		}
+	}()
+
+	err = cmd.Wait()
+	signal.Stop(signalChannel)
+	close(signalChannel)
+
+	return err
+}
+
+// ensurePinnedLip makes sure that .lip/tools/lip is of the Lip version pinned
+// by lip_version in .lip/workspace.json. It returns true if this Lip is already
+// of the pinned version.
+func ensurePinnedLip() (bool, error) {
+	config, err := workspace.LoadConfig()
+	if err != nil {
+		return false, err
+	}
+
+	if config.LipVersion == "" {
+		return false, nil
+	}
+
+	pinnedVersion, err := versions.NewFromString(strings.TrimPrefix(config.LipVersion, "v"))
+	if err != nil {
+		return false, errors.New("invalid lip_version in workspace.json: " + config.LipVersion)
+	}
+
+	context.Init()
+	if versions.Equal(pinnedVersion, context.Version) {
+		return true, nil
+	}
+
+	logger.Debug("Using Lip " + pinnedVersion.String() + " pinned by the workspace")
+
+	return false, selfupdate.EnsureLocal(pinnedVersion)
+}
+
+// resolveWorkspaceDir returns the workspace selected by the --workspace option,
+// the LIP_WORKSPACE environment variable or the nearest directory containing a
+// .lip directory, in this order. If none of them is found, the working
+// directory becomes a new workspace.
+func resolveWorkspaceDir(workingDir string, args []string) (string, error) {
+	workspaceDir, _ := parseLipArgs(args)
+	if workspaceDir == "" {
+		workspaceDir = os.Getenv("LIP_WORKSPACE")
+	}
+
+	if workspaceDir == "" {
+		foundDir, found, err := workspace.Find(workingDir)
+		if err != nil {
+			return "", err
+		}
+
+		if !found {
+			return workingDir, nil
+		}
+
+		return foundDir, nil
+	}
+
+	if !filepath.IsAbs(workspaceDir) {
+		workspaceDir = filepath.Join(workingDir, workspaceDir)
+	}
+
+	fileInfo, err := os.Stat(workspaceDir)
+	if err != nil || !fileInfo.IsDir() {
+		return "", errors.New("workspace directory does not exist: " + workspaceDir)
+	}
+
+	return filepath.Clean(workspaceDir), nil
+}
+
+// parseLipArgs returns the value of the --workspace option given before the
+// command, or an empty string if it is not given, and the arguments beginning
+// with the command.
+func parseLipArgs(args []string) (string, []string) {
+	workspaceDir := ""
+	for i := 0; i < len(args); i++ {
+		// The command ends the options of lip.
+		if !strings.HasPrefix(args[i], "-") {
+			return workspaceDir, args[i:]
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		switch name {
+		case "workspace":
+			if hasValue {
+				workspaceDir = value
+			} else if i+1 < len(args) {
+				workspaceDir = args[i+1]
+				i++
+			}
+
+		case "target-os", "target-arch", "log-format", "log-file", "color", "format":
+			// Skip the value of other options.
+			if !hasValue {
+				i++
+			}
+		}
+	}
+
+	return workspaceDir, nil
+}
+
+// isModifyingCommand returns true if the command modifies the workspace, e.g.
+// installs tooths or sets its platform.
+func isModifyingCommand(commandArgs []string) bool {
+	if len(commandArgs) == 0 {
+		return false
+	}
+
+	subcommand := ""
+	if len(commandArgs) >= 2 {
+		subcommand = commandArgs[1]
+	}
+
+	switch commandArgs[0] {
+	case "install", "i", "add", "uninstall", "un", "remove", "rm", "r", "autoremove":
+		// Global tools are installed in the global workspace instead.
+		for _, arg := range commandArgs[1:] {
+			if arg == "--global" || arg == "-global" || arg == "--g" || arg == "-g" {
+				return false
+			}
+		}
+		return true
+	case "platform":
+		return subcommand == "set" || subcommand == "unset"
+	case "self":
+		return subcommand == "update"
+	}
+
+	return false
+}
@@ -0,0 +1,174 @@
+// Package output prints the result of a command as one document on stdout.
+//
+// In table format, commands print their results for humans with the logger and
+// no document is printed. In JSON and YAML format, all messages of the logger
+// are printed to stderr, and each command prints exactly one document on
+// stdout, either with its result or with the error that stopped it.
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/liteldev/lip/utils/logger"
+)
+
+// SchemaVersion is the version of the schema of documents. It is increased
+// when the data of any command changes in an incompatible way.
+const SchemaVersion = 1
+
+type FormatType int
+
+const (
+	// TableFormat prints results for humans.
+	TableFormat FormatType = iota
+	JSONFormat
+	YAMLFormat
+)
+
+// Document is the document printed on stdout in JSON and YAML format.
+type Document struct {
+	SchemaVersion int         `json:"schema_version"`
+	Command       string      `json:"command"`
+	Success       bool        `json:"success"`
+	Data          interface{} `json:"data,omitempty"`
+	Error         string      `json:"error,omitempty"`
+}
+
+var format FormatType = TableFormat
+var command = "lip"
+var isPrinted = false
+
+// ParseFormat parses table, json or yaml.
+func ParseFormat(formatString string) (FormatType, error) {
+	switch formatString {
+	case "table":
+		return TableFormat, nil
+	case "json":
+		return JSONFormat, nil
+	case "yaml":
+		return YAMLFormat, nil
+	default:
+		return TableFormat, errors.New("unknown output format: " + formatString + ". Use table, json or yaml")
+	}
+}
+
+// GetFormat returns the output format.
+func GetFormat() FormatType {
+	return format
+}
+
+// SetFormat sets the output format. In JSON and YAML format, all messages of
+// the logger are printed to stderr so that stdout only contains the document.
+func SetFormat(newFormat FormatType) {
+	format = newFormat
+
+	if IsMachineReadable() {
+		logger.SetInfoOutput(os.Stderr)
+	} else {
+		logger.SetInfoOutput(os.Stdout)
+	}
+}
+
+// IsMachineReadable returns true if the output format is JSON or YAML.
+func IsMachineReadable() bool {
+	return format != TableFormat
+}
+
+// CommandStdout returns the writer that commands run by Lip, such as install
+// commands of tooths, print their output to. It is stderr in JSON and YAML
+// format to keep stdout for the document.
+func CommandStdout() io.Writer {
+	if IsMachineReadable() {
+		return os.Stderr
+	}
+
+	return os.Stdout
+}
+
+// SetCommand sets the name of the running command shown in the document, e.g.
+// "install" or "tooth lint".
+func SetCommand(name string) {
+	command = name
+}
+
+// Print prints the document with the data of a successful command. It does
+// nothing in table format, where commands print their results with the logger.
+// Only the first document is printed.
+func Print(data interface{}) {
+	printDocument(Document{
+		SchemaVersion: SchemaVersion,
+		Command:       command,
+		Success:       true,
+		Data:          data,
+	})
+}
+
+// Fail logs the error, prints the document with the error in JSON and YAML
+// format, and exits with code 1.
+func Fail(format string, a ...interface{}) {
+	logger.With("command", command).Error(format, a...)
+
+	printDocument(Document{
+		SchemaVersion: SchemaVersion,
+		Command:       command,
+		Success:       false,
+		Error:         fmt.Sprintf(format, a...),
+	})
+
+	os.Exit(1)
+}
+
+// FailWith is like Fail, but the document carries the data as well, e.g. the
+// problems found by a check that failed.
+func FailWith(data interface{}, format string, a ...interface{}) {
+	logger.With("command", command).Error(format, a...)
+
+	printDocument(Document{
+		SchemaVersion: SchemaVersion,
+		Command:       command,
+		Success:       false,
+		Data:          data,
+		Error:         fmt.Sprintf(format, a...),
+	})
+
+	os.Exit(1)
+}
+
+// printDocument prints the document on stdout in the output format.
+func printDocument(document Document) {
+	if !IsMachineReadable() || isPrinted {
+		return
+	}
+	isPrinted = true
+
+	content, err := Marshal(document, format)
+	if err != nil {
+		logger.Error("failed to encode the output: " + err.Error())
+		os.Exit(1)
+	}
+
+	os.Stdout.Write(content)
+}
+
+// Marshal encodes the value in JSON or YAML format, ending with a new line.
+// Values are encoded in YAML as they are in JSON, i.e. with the same keys.
+func Marshal(value interface{}, format FormatType) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if format != YAMLFormat {
+		return buffer.Bytes(), nil
+	}
+
+	return jsonToYAML(buffer.Bytes())
+}
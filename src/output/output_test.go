@@ -0,0 +1,88 @@
+package output
+
+import (
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+	for formatString, expectedFormat := range map[string]FormatType{
+		"table": TableFormat,
+		"json":  JSONFormat,
+		"yaml":  YAMLFormat,
+	} {
+		parsedFormat, err := ParseFormat(formatString)
+		if err != nil || parsedFormat != expectedFormat {
+			t.Errorf("ParseFormat(%s) = %v, %v", formatString, parsedFormat, err)
+		}
+	}
+
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("an unknown format is accepted")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	document := Document{
+		SchemaVersion: SchemaVersion,
+		Command:       "install",
+		Success:       true,
+		Data: map[string]interface{}{
+			"installed": []interface{}{
+				map[string]interface{}{
+					"tooth":   "github.com/tooth/example",
+					"version": "1.0.0",
+					"extras":  []string{},
+				},
+			},
+			"note": "a <note>: yes",
+			"flag": "true",
+		},
+	}
+
+	content, err := Marshal(document, JSONFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedJSON := `{"schema_version":1,"command":"install","success":true,"data":{"flag":"true",` +
+		`"installed":[{"extras":[],"tooth":"github.com/tooth/example","version":"1.0.0"}],"note":"a <note>: yes"}}` + "\n"
+	if string(content) != expectedJSON {
+		t.Errorf("JSON = %s, want %s", content, expectedJSON)
+	}
+
+	content, err = Marshal(document, YAMLFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedYAML := `schema_version: 1
+command: install
+success: true
+data:
+  flag: "true"
+  installed:
+    - extras: []
+      tooth: github.com/tooth/example
+      version: "1.0.0"
+  note: "a <note>: yes"
+`
+	if string(content) != expectedYAML {
+		t.Errorf("YAML = %s, want %s", content, expectedYAML)
+	}
+}
+
+func TestMarshalYAMLNested(t *testing.T) {
+	content, err := Marshal([]interface{}{[]interface{}{"a", nil}, map[string]interface{}{}, 1.5}, YAMLFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedYAML := `- - a
+  - null
+- {}
+- 1.5
+`
+	if string(content) != expectedYAML {
+		t.Errorf("YAML = %s, want %s", content, expectedYAML)
+	}
+}
@@ -0,0 +1,169 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// yamlNode is a JSON value kept in order, to be written in YAML.
+type yamlNode struct {
+	// scalar is the YAML text of a string, number, boolean or null.
+	scalar string
+	// isMapping and isSequence tell the kind of a collection.
+	isMapping  bool
+	isSequence bool
+	keyList    []string
+	valueList  []*yamlNode
+}
+
+// plainScalarRegex matches strings that can be written in YAML without quotes.
+var plainScalarRegex = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_./@+-]*$`)
+
+// jsonToYAML converts a JSON value to YAML, keeping the order of keys.
+func jsonToYAML(content []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	node, err := readYAMLNode(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	var builder strings.Builder
+	if node.isInline() {
+		builder.WriteString(node.inline() + "\n")
+	} else {
+		writeYAMLNode(&builder, node, 0)
+	}
+
+	return []byte(builder.String()), nil
+}
+
+// readYAMLNode reads the next JSON value from the decoder.
+func readYAMLNode(decoder *json.Decoder) (*yamlNode, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch value := token.(type) {
+	case json.Delim:
+		node := &yamlNode{isMapping: value == '{', isSequence: value == '['}
+		for decoder.More() {
+			if node.isMapping {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, ok := keyToken.(string)
+				if !ok {
+					return nil, errors.New("invalid JSON key")
+				}
+				node.keyList = append(node.keyList, key)
+			}
+
+			child, err := readYAMLNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			node.valueList = append(node.valueList, child)
+		}
+
+		// Consume the closing delimiter.
+		_, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		return node, nil
+
+	case string:
+		return &yamlNode{scalar: quoteYAMLString(value)}, nil
+
+	case json.Number:
+		return &yamlNode{scalar: value.String()}, nil
+
+	case bool:
+		if value {
+			return &yamlNode{scalar: "true"}, nil
+		}
+		return &yamlNode{scalar: "false"}, nil
+
+	default:
+		return &yamlNode{scalar: "null"}, nil
+	}
+}
+
+// isInline returns true if the node is written on the same line as its key.
+func (node *yamlNode) isInline() bool {
+	return len(node.valueList) == 0
+}
+
+// inline returns the YAML text of a scalar or an empty collection.
+func (node *yamlNode) inline() string {
+	switch {
+	case node.isMapping:
+		return "{}"
+	case node.isSequence:
+		return "[]"
+	default:
+		return node.scalar
+	}
+}
+
+// writeYAMLNode writes a non-empty collection with the indent.
+func writeYAMLNode(builder *strings.Builder, node *yamlNode, indent int) {
+	prefix := strings.Repeat(" ", indent)
+
+	for i, child := range node.valueList {
+		var head string
+		if node.isMapping {
+			head = prefix + quoteYAMLString(node.keyList[i]) + ":"
+		} else {
+			head = prefix + "-"
+		}
+
+		if child.isInline() {
+			builder.WriteString(head + " " + child.inline() + "\n")
+			continue
+		}
+
+		if node.isMapping {
+			builder.WriteString(head + "\n")
+			writeYAMLNode(builder, child, indent+2)
+			continue
+		}
+
+		// Items of sequences start on the line of the dash.
+		var childBuilder strings.Builder
+		writeYAMLNode(&childBuilder, child, indent+2)
+		builder.WriteString(head + " " + strings.TrimPrefix(childBuilder.String(), prefix+"  "))
+	}
+}
+
+// quoteYAMLString returns the string as is if it is a plain YAML scalar that
+// is read back as the same string, or in double quotes otherwise.
+func quoteYAMLString(value string) string {
+	switch strings.ToLower(value) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~":
+		return quoteJSONString(value)
+	}
+
+	if plainScalarRegex.MatchString(value) {
+		return value
+	}
+
+	return quoteJSONString(value)
+}
+
+// quoteJSONString quotes the string as JSON does, which is also valid in YAML.
+func quoteJSONString(value string) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
@@ -0,0 +1,214 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestJSONToYAMLRoundTrip(t *testing.T) {
+	testList := []string{
+		`"plain"`,
+		`{"key": "a: b", "comment": "a #b", "hash": "#a", "dash": "-a", "dash space": "- a", "dash only": "-"}`,
+		`{"yes": "yes", "no": "No", "on": "ON", "off": "off", "y": "y", "null": "null", "tilde": "~", "true": "True"}`,
+		`{"int": "10", "octal": "010", "float": "1.5", "exp": "1e3", "sexagesimal": "1:20", "inf": ".inf", "nan": ".NaN"}`,
+		`{"multiline": "line 1\nline 2\n", "tab": "a\tb", "empty": "", "spaces": " a ", "quote": "a\"b", "single": "'a'"}`,
+		`{"indicator": "@a", "anchor": "&a", "alias": "*a", "tag": "!a", "block": "|", "folded": ">", "percent": "%a", "backquote": "` + "`a`" + `"}`,
+		`{"flow": "[a]", "flow mapping": "{a: b}", "question": "? a", "comma": "a, b", "unicode": "工具", "url": "https://example.com/a?b=c"}`,
+		`{"a: b": 1, "#": 2, "-": 3, "yes": true, "": null, "multi\nline": 1.5}`,
+		`{"list": [[], {}, ["a", ["b", "c"]], {"d": [1, 2.5, -3], "e": {"f": false}}], "empty": {}}`,
+		`[{"tooth": "github.com/tooth/example", "version": "1.0.0", "extras": ["web", "db"]}, "-1", -1, null]`,
+	}
+
+	for index, test := range testList {
+		content, err := jsonToYAML([]byte(test))
+		if err != nil {
+			t.Fatalf("error at test %d: %s", index, err.Error())
+		}
+
+		var expected interface{}
+		decoder := json.NewDecoder(strings.NewReader(test))
+		decoder.UseNumber()
+		if err := decoder.Decode(&expected); err != nil {
+			t.Fatalf("invalid JSON at test %d: %s", index, err.Error())
+		}
+
+		value, err := readYAML(string(content))
+		if err != nil {
+			t.Errorf("invalid YAML at test %d: %s\n%s", index, err.Error(), content)
+			continue
+		}
+
+		if !reflect.DeepEqual(value, expected) {
+			t.Errorf("wrong value at test %d: %#v, want %#v\n%s", index, value, expected, content)
+		}
+	}
+}
+
+// yamlLine is a line of YAML with its indent.
+type yamlLine struct {
+	indent int
+	text   string
+}
+
+// readYAML reads the block YAML written by jsonToYAML, resolving plain scalars
+// by the rules of YAML 1.1 like common YAML libraries do. Plain scalars that
+// YAML reads differently, e.g. with a comment or an indicator, are errors.
+func readYAML(content string) (interface{}, error) {
+	lineList := make([]yamlLine, 0)
+	for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		text := strings.TrimLeft(line, " ")
+		lineList = append(lineList, yamlLine{len(line) - len(text), text})
+	}
+
+	index := 0
+	value, err := readYAMLBlock(lineList, &index, 0)
+	if err != nil {
+		return nil, err
+	}
+	if index != len(lineList) {
+		return nil, errors.New("unexpected line: " + lineList[index].text)
+	}
+
+	return value, nil
+}
+
+// readYAMLBlock reads the value beginning at the line of the index.
+func readYAMLBlock(lineList []yamlLine, index *int, indent int) (interface{}, error) {
+	if *index >= len(lineList) || lineList[*index].indent != indent {
+		return nil, errors.New("missing value")
+	}
+	text := lineList[*index].text
+
+	// A sequence.
+	if text == "-" || strings.HasPrefix(text, "- ") {
+		sequence := make([]interface{}, 0)
+		for *index < len(lineList) && lineList[*index].indent == indent &&
+			strings.HasPrefix(lineList[*index].text, "- ") {
+			// The item begins on the line of the dash.
+			lineList[*index] = yamlLine{indent + 2, lineList[*index].text[2:]}
+
+			item, err := readYAMLBlock(lineList, index, indent+2)
+			if err != nil {
+				return nil, err
+			}
+			sequence = append(sequence, item)
+		}
+		return sequence, nil
+	}
+
+	// A scalar.
+	if _, rest, err := readYAMLKey(text); err != nil || rest == "" {
+		*index++
+		return readYAMLScalar(text)
+	}
+
+	// A mapping.
+	mapping := make(map[string]interface{})
+	for *index < len(lineList) && lineList[*index].indent == indent {
+		key, rest, err := readYAMLKey(lineList[*index].text)
+		if err != nil {
+			return nil, err
+		}
+		*index++
+
+		var value interface{}
+		if rest == ":" {
+			value, err = readYAMLBlock(lineList, index, indent+2)
+		} else if strings.HasPrefix(rest, ": ") {
+			value, err = readYAMLScalar(rest[2:])
+		} else {
+			err = errors.New("invalid mapping entry: " + key + rest)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := mapping[key]; ok {
+			return nil, errors.New("duplicated key: " + key)
+		}
+		mapping[key] = value
+	}
+	return mapping, nil
+}
+
+// readYAMLKey reads the key at the beginning of the text and returns the rest,
+// which begins with ":" for a mapping entry.
+func readYAMLKey(text string) (string, string, error) {
+	if strings.HasPrefix(text, "\"") {
+		decoder := json.NewDecoder(strings.NewReader(text))
+		var key string
+		if err := decoder.Decode(&key); err != nil {
+			return "", "", err
+		}
+		return key, text[decoder.InputOffset():], nil
+	}
+
+	index := strings.Index(text, ":")
+	if index == -1 {
+		return "", "", errors.New("missing key")
+	}
+	key, err := readYAMLScalar(text[:index])
+	if err != nil {
+		return "", "", err
+	}
+	keyString, ok := key.(string)
+	if !ok {
+		return "", "", errors.New("key is not a string: " + text[:index])
+	}
+
+	return keyString, text[index:], nil
+}
+
+// readYAMLScalar reads a double-quoted or plain scalar, or an empty collection.
+func readYAMLScalar(text string) (interface{}, error) {
+	switch text {
+	case "{}":
+		return map[string]interface{}{}, nil
+	case "[]":
+		return []interface{}{}, nil
+	}
+
+	if strings.HasPrefix(text, "\"") {
+		var value string
+		if err := json.Unmarshal([]byte(text), &value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+
+	numberRegex := regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9][0-9_:]*(\.[0-9]*)?)([eE][-+]?[0-9]+)?$|^[-+]?\.(inf|Inf|INF)$|^\.(nan|NaN|NAN)$`)
+	if numberRegex.MatchString(text) {
+		return json.Number(text), nil
+	}
+
+	if text == "" || strings.ContainsAny(text[:1], "-?:,[]{}#&*!|>'\"%@`") ||
+		strings.Contains(text, ": ") || strings.Contains(text, " #") ||
+		strings.HasSuffix(text, ":") || strings.TrimSpace(text) != text {
+		return nil, errors.New("invalid plain scalar: " + text)
+	}
+
+	switch text {
+	case "~", "null", "Null", "NULL":
+		return nil, nil
+	case "y", "Y", "yes", "Yes", "YES", "true", "True", "TRUE", "on", "On", "ON":
+		return true, nil
+	case "n", "N", "no", "No", "NO", "false", "False", "FALSE", "off", "Off", "OFF":
+		return false, nil
+	}
+
+	return text, nil
+}
+
+func TestReadYAMLScalarInvalid(t *testing.T) {
+	// The reader must reject what YAML reads differently for the round trip
+	// test to be meaningful.
+	for index, test := range []string{"a: b", "a #b", "- a", "#a", "@a", " a", "a:"} {
+		if value, err := readYAMLScalar(test); err == nil {
+			t.Errorf("no error at test %d: %s: %#v", index, test, value)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+// Package selfupdate fetches releases of Lip and stages them in .lip/tools/lip
+// of the workspace, where they replace the workspace-local Lip on the next run.
+package selfupdate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	lipcontext "github.com/liteldev/lip/context"
+	"github.com/liteldev/lip/download"
+	"github.com/liteldev/lip/localfile"
+	"github.com/liteldev/lip/tooth/toothrepo"
+	"github.com/liteldev/lip/utils/versions"
+)
+
+// AssetName returns the file name of the release of Lip for the platform, e.g.
+// lip-linux-amd64 or lip-windows-amd64.exe.
+func AssetName(goos string, goarch string) string {
+	assetName := "lip-" + goos + "-" + goarch
+	if goos == "windows" {
+		assetName += ".exe"
+	}
+
+	return assetName
+}
+
+// AssetURL returns the URL of the release of Lip of the version for the
+// platform. Its SHA-256 checksum is published at the same URL with the suffix
+// .sha256.
+func AssetURL(version versions.Version, goos string, goarch string) string {
+	return strings.TrimSuffix(lipcontext.ReleaseURL, "/") + "/v" + version.String() + "/" + AssetName(goos, goarch)
+}
+
+// LatestVersion returns the latest stable version of Lip listed via GOPROXY.
+func LatestVersion() (versions.Version, error) {
+	versionList, err := toothrepo.FetchModuleVersionList(context.Background(), lipcontext.LipToothPath)
+	if err != nil {
+		return versions.Version{}, err
+	}
+
+	// The version list is in descending order.
+	for _, version := range versionList {
+		if version.IsStable() {
+			return version, nil
+		}
+	}
+
+	return versions.Version{}, errors.New("no stable version of Lip is found")
+}
+
+// LocalPath returns the path to the workspace-local Lip, i.e.
+// .lip/tools/lip/lip or .lip/tools/lip/lip.exe.
+func LocalPath() (string, error) {
+	workspaceLipDir, err := localfile.WorkspaceLipDir()
+	if err != nil {
+		return "", err
+	}
+
+	lipExeName := "lip"
+	if runtime.GOOS == "windows" {
+		lipExeName = "lip.exe"
+	}
+
+	return filepath.Join(workspaceLipDir, "tools", "lip", lipExeName), nil
+}
+
+// StagingPath returns the path to .lip/tools/lip/lip.update, which replaces the
+// workspace-local Lip on the next run.
+func StagingPath() (string, error) {
+	workspaceLipDir, err := localfile.WorkspaceLipDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(workspaceLipDir, "tools", "lip", "lip.update"), nil
+}
+
+// EnsureLocal makes sure that the workspace-local Lip is of the version, and
+// fetches the release of the version into .lip/tools/lip otherwise. The version
+// of the workspace-local Lip is remembered in .lip/tools/lip/lip.version.
+func EnsureLocal(version versions.Version) error {
+	localPath, err := LocalPath()
+	if err != nil {
+		return err
+	}
+
+	versionFilePath := filepath.Join(filepath.Dir(localPath), "lip.version")
+
+	content, err := os.ReadFile(versionFilePath)
+	if err == nil && strings.TrimSpace(string(content)) == version.String() {
+		if _, err := os.Stat(localPath); err == nil {
+			return nil
+		}
+	}
+
+	err = os.MkdirAll(filepath.Dir(localPath), 0755)
+	if err != nil {
+		return errors.New("failed to create " + filepath.Dir(localPath) + ": " + err.Error())
+	}
+
+	err = Fetch(version, localPath)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(versionFilePath, []byte(version.String()+"\n"), 0644)
+	if err != nil {
+		return errors.New("failed to write " + versionFilePath + ": " + err.Error())
+	}
+
+	return nil
+}
+
+// Stage fetches the release of the version and stages it as
+// .lip/tools/lip/lip.update.
+func Stage(version versions.Version) error {
+	stagingPath, err := StagingPath()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(stagingPath), 0755)
+	if err != nil {
+		return errors.New("failed to create " + filepath.Dir(stagingPath) + ": " + err.Error())
+	}
+
+	return Fetch(version, stagingPath)
+}
+
+// Fetch downloads the release of Lip of the version for the platform Lip is
+// running on to filePath, after verifying its SHA-256 checksum.
+func Fetch(version versions.Version, filePath string) error {
+	assetURL := AssetURL(version, runtime.GOOS, runtime.GOARCH)
+
+	checksumContent, err := getContent(assetURL + ".sha256")
+	if err != nil {
+		return errors.New("failed to get the checksum of Lip " + version.String() + ": " + err.Error())
+	}
+
+	// The checksum file is in the format of sha256sum, i.e. the checksum
+	// optionally followed by the file name.
+	checksumFields := strings.Fields(string(checksumContent))
+	if len(checksumFields) == 0 {
+		return errors.New("the checksum of Lip " + version.String() + " is empty")
+	}
+	expectedChecksum := strings.ToLower(checksumFields[0])
+
+	// Download to a temporary file so that a broken download is never used.
+	downloadPath := filePath + ".download"
+	defer os.Remove(downloadPath)
+
+	err = download.DownloadFile(assetURL, downloadPath, download.StyleDefault)
+	if err != nil {
+		return errors.New("failed to download Lip " + version.String() + ": " + err.Error())
+	}
+
+	actualChecksum, err := fileChecksum(downloadPath)
+	if err != nil {
+		return err
+	}
+
+	if actualChecksum != expectedChecksum {
+		return errors.New("checksum mismatch of Lip " + version.String() + ": expected " + expectedChecksum +
+			", got " + actualChecksum)
+	}
+
+	err = os.Chmod(downloadPath, 0755)
+	if err != nil {
+		return errors.New("failed to make " + downloadPath + " executable: " + err.Error())
+	}
+
+	err = os.Rename(downloadPath, filePath)
+	if err != nil {
+		return errors.New("failed to move " + downloadPath + " to " + filePath + ": " + err.Error())
+	}
+
+	return nil
+}
+
+// fileChecksum returns the SHA-256 checksum of the file in hex.
+func fileChecksum(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", errors.New("failed to open " + filePath + ": " + err.Error())
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", errors.New("failed to read " + filePath + ": " + err.Error())
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// getContent gets the content of a URL.
+func getContent(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("cannot get " + url + " (HTTP " + resp.Status + ")")
+	}
+
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,137 @@
+package selfupdate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/liteldev/lip/context"
+	"github.com/liteldev/lip/utils/versions"
+)
+
+func TestFetch(t *testing.T) {
+	content := []byte("new lip")
+	hash := sha256.Sum256(content)
+	checksum := hex.EncodeToString(hash[:])
+
+	assetPath := "/v1.2.3/" + AssetName(runtime.GOOS, runtime.GOARCH)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case assetPath:
+			w.Write(content)
+		case assetPath + ".sha256":
+			w.Write([]byte(checksum + "  " + AssetName(runtime.GOOS, runtime.GOARCH) + "\n"))
+		case "/v1.2.4/" + AssetName(runtime.GOOS, runtime.GOARCH):
+			w.Write([]byte("tampered lip"))
+		case "/v1.2.4/" + AssetName(runtime.GOOS, runtime.GOARCH) + ".sha256":
+			w.Write([]byte(checksum))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	releaseURL := context.ReleaseURL
+	defer func() { context.ReleaseURL = releaseURL }()
+	context.ReleaseURL = server.URL + "/"
+
+	filePath := filepath.Join(t.TempDir(), "lip.update")
+
+	// A release with a matching checksum is fetched.
+	version, _ := versions.NewFromString("1.2.3")
+	err := Fetch(version, filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetchedContent, _ := os.ReadFile(filePath)
+	if string(fetchedContent) != string(content) {
+		t.Errorf("fetched content = %q, want %q", fetchedContent, content)
+	}
+
+	// A release with a mismatched checksum is rejected and not kept.
+	os.Remove(filePath)
+	version, _ = versions.NewFromString("1.2.4")
+	err = Fetch(version, filePath)
+	if err == nil {
+		t.Errorf("a release with a mismatched checksum is accepted")
+	}
+
+	if _, err := os.Stat(filePath); err == nil {
+		t.Errorf("a release with a mismatched checksum is kept")
+	}
+
+	// A missing release is reported.
+	version, _ = versions.NewFromString("9.9.9")
+	err = Fetch(version, filePath)
+	if err == nil {
+		t.Errorf("a missing release is not reported")
+	}
+}
+
+func TestAssetName(t *testing.T) {
+	if name := AssetName("windows", "amd64"); name != "lip-windows-amd64.exe" {
+		t.Errorf("AssetName(windows, amd64) = %s", name)
+	}
+
+	if name := AssetName("linux", "arm64"); name != "lip-linux-arm64" {
+		t.Errorf("AssetName(linux, arm64) = %s", name)
+	}
+}
+
+func TestEnsureLocal(t *testing.T) {
+	content := []byte("pinned lip")
+	hash := sha256.Sum256(content)
+	checksum := hex.EncodeToString(hash[:])
+
+	requestCount := 0
+	assetPath := "/v1.2.3/" + AssetName(runtime.GOOS, runtime.GOARCH)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		switch r.URL.Path {
+		case assetPath:
+			w.Write(content)
+		case assetPath + ".sha256":
+			w.Write([]byte(checksum))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	releaseURL := context.ReleaseURL
+	defer func() { context.ReleaseURL = releaseURL }()
+	context.ReleaseURL = server.URL
+
+	workspaceDir := t.TempDir()
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	os.Chdir(workspaceDir)
+
+	version, _ := versions.NewFromString("1.2.3")
+	err := EnsureLocal(version)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	localPath, _ := LocalPath()
+	fetchedContent, _ := os.ReadFile(localPath)
+	if string(fetchedContent) != string(content) {
+		t.Errorf("workspace-local Lip = %q, want %q", fetchedContent, content)
+	}
+
+	// The workspace-local Lip is not fetched again if it is of the version.
+	requestCount = 0
+	err = EnsureLocal(version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requestCount != 0 {
+		t.Errorf("the workspace-local Lip of the version is fetched again")
+	}
+}
@@ -1,14 +1,17 @@
 package specifiers
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/liteldev/lip/registry"
+	"github.com/liteldev/lip/tooth/toothgit"
 	"github.com/liteldev/lip/tooth/toothrepo"
 	"github.com/liteldev/lip/utils/versions"
 )
@@ -20,23 +23,41 @@ const (
 	ToothFileKind SpecifierKind = iota
 	ToothURLKind
 	RequirementKind
+	GitKind
+	ToothDirKind
 )
 
 // Specifier is a type that can be used to specify a tooth url/file or a requirement.
 type Specifier struct {
 	specifierType SpecifierKind
 	toothFilePath string
+	toothDirPath  string
 	toothURL      string
 	toothRepo     string
 	toothVersion  versions.Version
+	gitURL        string
+	gitRef        string
+	gitCommit     string
+	extras        []string
 }
 
-// New creates a new specifier.
-func New(specifierString string) (Specifier, error) {
+// New creates a new specifier. Requirement specifiers are checked against the
+// tooth repository, which is cancelled with the context.
+func New(ctx context.Context, specifierString string) (Specifier, error) {
 	var err error
 
 	specifierType := getSpecifierType(specifierString)
 
+	// Extras are only selected for requirements. Brackets in paths, URLs and
+	// git refs are kept as they are.
+	extras := make([]string, 0)
+	if specifierType == RequirementKind {
+		specifierString, extras, err = splitExtras(specifierString)
+		if err != nil {
+			return Specifier{}, err
+		}
+	}
+
 	switch specifierType {
 	case ToothFileKind:
 		// Check if the tooth file exists.
@@ -46,9 +67,36 @@ func New(specifierString string) (Specifier, error) {
 			return Specifier{}, errors.New("cannot access tooth file: " + specifierString)
 		}
 
+		// The path is made absolute, so that it stays valid when the working
+		// directory is changed.
+		toothFilePath, err := filepath.Abs(specifierString)
+		if err != nil {
+			return Specifier{}, errors.New("cannot get full path of tooth file: " + specifierString)
+		}
+
+		return Specifier{
+			specifierType: specifierType,
+			toothFilePath: toothFilePath,
+			extras:        extras,
+		}, nil
+
+	case ToothDirKind:
+		// Check if the tooth directory contains tooth.json.
+		_, err := os.Stat(filepath.Join(specifierString, "tooth.json"))
+
+		if err != nil {
+			return Specifier{}, errors.New("cannot find tooth.json in tooth directory: " + specifierString)
+		}
+
+		toothDirPath, err := filepath.Abs(specifierString)
+		if err != nil {
+			return Specifier{}, errors.New("cannot get full path of tooth directory: " + specifierString)
+		}
+
 		return Specifier{
 			specifierType: specifierType,
-			toothFilePath: specifierString,
+			toothDirPath:  toothDirPath,
+			extras:        extras,
 		}, nil
 
 	case ToothURLKind:
@@ -62,13 +110,35 @@ func New(specifierString string) (Specifier, error) {
 		return Specifier{
 			specifierType: specifierType,
 			toothURL:      specifierString,
+			extras:        extras,
+		}, nil
+
+	case GitKind:
+		gitURL, gitRef := splitGitSpecifier(specifierString)
+		if gitURL == "" || strings.HasPrefix(gitURL, "-") || strings.HasPrefix(gitRef, "-") {
+			return Specifier{}, errors.New("invalid git specifier: " + specifierString)
+		}
+
+		// Other refs are resolved to commit hashes by ResolveGitCommit when
+		// fetching the tooth.
+		gitCommit := ""
+		if toothgit.IsCommitHash(gitRef) {
+			gitCommit = gitRef
+		}
+
+		return Specifier{
+			specifierType: specifierType,
+			gitURL:        gitURL,
+			gitRef:        gitRef,
+			gitCommit:     gitCommit,
+			extras:        extras,
 		}, nil
 
 	case RequirementKind:
 		// Specifier string should be lower case.
 		specifierString = strings.ToLower(specifierString)
 
-		reg := regexp.MustCompile(`^[a-z0-9][a-z0-9-_\.\/]*(@\d+\.\d+\.\d+(-[a-z]+(\.\d+)?)?)?$`)
+		reg := regexp.MustCompile(`^[a-z0-9][a-z0-9-_\.\/]*(@\d+\.\d+\.\d+(-[0-9a-z-\.]+)?(\+[0-9a-z-\.]+)?)?$`)
 
 		// If not matched or the matched string is not the same as the specifier, it is an
 		// invalid requirement specifier.
@@ -97,13 +167,13 @@ func New(specifierString string) (Specifier, error) {
 			}
 
 			// Check if the tooth version is valid.
-			err := toothrepo.ValidateVersion(toothRepo, toothVersion)
+			err := toothrepo.ValidateVersion(ctx, toothRepo, toothVersion)
 			if err != nil {
 				return Specifier{}, err
 			}
 		} else {
 			// Fetch the latest version of the tooth repo.
-			toothVersionList, err := toothrepo.FetchVersionList(toothRepo)
+			toothVersionList, err := toothrepo.FetchModuleVersionList(ctx, toothRepo)
 			if err != nil {
 				return Specifier{}, err
 			}
@@ -133,6 +203,7 @@ func New(specifierString string) (Specifier, error) {
 			specifierType: specifierType,
 			toothRepo:     toothRepo,
 			toothVersion:  toothVersion,
+			extras:        extras,
 		}, nil
 	}
 
@@ -145,25 +216,83 @@ func (s Specifier) Type() SpecifierKind {
 	return s.specifierType
 }
 
+// Extras returns the extras selected by the specifier.
+func (s Specifier) Extras() []string {
+	return s.extras
+}
+
 // String returns the string representation of the specifier.
+// Extras are not included.
 func (s Specifier) String() string {
 	switch s.specifierType {
 	case ToothFileKind:
 		return s.toothFilePath
+	case ToothDirKind:
+		return s.toothDirPath
 	case ToothURLKind:
 		return s.toothURL
 	case RequirementKind:
 		return s.toothRepo + "@" + s.toothVersion.String()
+	case GitKind:
+		if s.gitCommit != "" {
+			return "git+" + s.gitURL + "@" + s.gitCommit
+		}
+		if s.gitRef != "" {
+			return "git+" + s.gitURL + "@" + s.gitRef
+		}
+		return "git+" + s.gitURL
 	}
 
 	return ""
 }
 
+// GitURL returns the URL of the git repository.
+func (s Specifier) GitURL() string {
+	return s.gitURL
+}
+
+// GitRef returns the tag, branch or commit specified in the git specifier. It
+// is empty if the default branch is used.
+func (s Specifier) GitRef() string {
+	return s.gitRef
+}
+
+// GitCommit returns the full commit hash the git specifier is resolved to. It
+// is empty until ResolveGitCommit is called, unless the ref is a full commit
+// hash.
+func (s Specifier) GitCommit() string {
+	return s.gitCommit
+}
+
+// ResolveGitCommit returns the git specifier with the ref resolved to a full
+// commit hash, so that the tooth can be cached by commit. It fetches the git
+// repository, so it should only be called when fetching the tooth. Other
+// specifiers are returned as is.
+func (s Specifier) ResolveGitCommit(ctx context.Context) (Specifier, error) {
+	if s.specifierType != GitKind || s.gitCommit != "" {
+		return s, nil
+	}
+
+	gitCommit, err := toothgit.ResolveCommit(ctx, s.gitURL, s.gitRef)
+	if err != nil {
+		return Specifier{}, err
+	}
+
+	s.gitCommit = gitCommit
+
+	return s, nil
+}
+
 // ToothFilePath returns the path of the tooth file.
 func (s Specifier) ToothFilePath() string {
 	return s.toothFilePath
 }
 
+// ToothDirPath returns the path of the tooth directory.
+func (s Specifier) ToothDirPath() string {
+	return s.toothDirPath
+}
+
 // ToothRepo returns the tooth repo of the specifier.
 func (s Specifier) ToothRepo() string {
 	return s.toothRepo
@@ -181,7 +310,11 @@ func (s Specifier) ToothVersion() versions.Version {
 
 // getSpecifierType gets the type of the requirement specifier.
 func getSpecifierType(specifier string) SpecifierKind {
-	if strings.HasSuffix(specifier, ".tth") {
+	if strings.HasPrefix(specifier, "git+") {
+		return GitKind
+	} else if isLocalDirPath(specifier) {
+		return ToothDirKind
+	} else if strings.HasSuffix(specifier, ".tth") {
 		if strings.HasPrefix(specifier, "http://") || strings.HasPrefix(specifier, "https://") {
 			return ToothURLKind
 		} else {
@@ -191,3 +324,87 @@ func getSpecifierType(specifier string) SpecifierKind {
 		return RequirementKind
 	}
 }
+
+// isLocalDirPath returns true if the specifier is an explicit path, i.e. an
+// absolute path or a path beginning with "." or ending with a path separator,
+// to an existing directory. Paths like "example.com/tooth" are regarded as
+// requirements even if such a directory exists.
+func isLocalDirPath(specifier string) bool {
+	if !filepath.IsAbs(specifier) && !strings.HasPrefix(specifier, ".") &&
+		!strings.HasSuffix(specifier, "/") && !strings.HasSuffix(specifier, string(filepath.Separator)) {
+		return false
+	}
+
+	fileInfo, err := os.Stat(specifier)
+
+	return err == nil && fileInfo.IsDir()
+}
+
+// splitGitSpecifier splits a git specifier like "git+https://example.com/repo.git@v1.0.0"
+// into the URL of the git repository and the ref. The ref is after the last "@"
+// following the host, so that users in URLs like "git+ssh://git@example.com/repo.git"
+// are not regarded as refs. Local repositories like "git+/path/to/repo.git" or
+// "git+file:///path/to/repo.git" are also supported.
+func splitGitSpecifier(specifierString string) (string, string) {
+	url := strings.TrimPrefix(specifierString, "git+")
+
+	// Skip the scheme and the host.
+	pathBegin := 0
+	if index := strings.Index(url, "://"); index >= 0 {
+		pathBegin = index + len("://")
+		if slashIndex := strings.Index(url[pathBegin:], "/"); slashIndex >= 0 {
+			pathBegin += slashIndex
+		} else {
+			pathBegin = len(url)
+		}
+	} else if index := strings.Index(url, ":"); index > 0 && !strings.Contains(url[:index], "/") {
+		// SCP-like syntax, e.g. git@example.com:user/repo.git.
+		pathBegin = index
+	}
+
+	ref := ""
+	if index := strings.LastIndex(url[pathBegin:], "@"); index >= 0 {
+		ref = url[pathBegin+index+1:]
+		url = url[:pathBegin+index]
+	}
+
+	return url, ref
+}
+
+// splitExtras splits the extras from a specifier string, e.g. "tooth[web,db]@1.0.0"
+// is split into "tooth@1.0.0" and ["web", "db"].
+func splitExtras(specifierString string) (string, []string, error) {
+	extras := make([]string, 0)
+
+	begin := strings.Index(specifierString, "[")
+	end := strings.Index(specifierString, "]")
+	if begin == -1 && end == -1 {
+		return specifierString, extras, nil
+	}
+
+	if begin == -1 || end < begin || strings.Count(specifierString, "[") > 1 ||
+		strings.Count(specifierString, "]") > 1 {
+		return "", nil, errors.New("invalid extras in specifier: " + specifierString)
+	}
+
+	reg := regexp.MustCompile(`^[a-z0-9][a-z0-9-_]*$`)
+	for _, extra := range strings.Split(specifierString[begin+1:end], ",") {
+		extra = strings.ToLower(strings.TrimSpace(extra))
+		if !reg.MatchString(extra) {
+			return "", nil, errors.New("invalid extra " + extra + " in specifier: " + specifierString)
+		}
+
+		isDuplicated := false
+		for _, existingExtra := range extras {
+			if existingExtra == extra {
+				isDuplicated = true
+				break
+			}
+		}
+		if !isDuplicated {
+			extras = append(extras, extra)
+		}
+	}
+
+	return specifierString[:begin] + specifierString[end+1:], extras, nil
+}
@@ -0,0 +1,159 @@
+package specifiers
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitExtras(t *testing.T) {
+	testList := []struct {
+		input  string
+		output string
+		extras string
+	}{
+		{"example.com/tooth", "example.com/tooth", ""},
+		{"example.com/tooth@1.0.0", "example.com/tooth@1.0.0", ""},
+		{"example.com/tooth[web]", "example.com/tooth", "web"},
+		{"example.com/tooth[web,db]@1.0.0", "example.com/tooth@1.0.0", "web,db"},
+		{"example.com/tooth[Web,db,web]", "example.com/tooth", "web,db"},
+		{"./tooth.tth[web]", "./tooth.tth", "web"},
+	}
+
+	for index, test := range testList {
+		output, extras, err := splitExtras(test.input)
+		if err != nil {
+			t.Fatalf("error at test %d: %s", index, err.Error())
+		}
+
+		if output != test.output {
+			t.Errorf("wrong output at test %d: %s != %s", index, output, test.output)
+		}
+
+		if strings.Join(extras, ",") != test.extras {
+			t.Errorf("wrong extras at test %d: %s != %s", index, strings.Join(extras, ","), test.extras)
+		}
+	}
+
+	invalidList := []string{
+		"example.com/tooth[",
+		"example.com/tooth]",
+		"example.com/tooth[]",
+		"example.com/tooth][web",
+		"example.com/tooth[web][db]",
+		"example.com/tooth[web,,db]",
+	}
+
+	for index, input := range invalidList {
+		_, _, err := splitExtras(input)
+		if err == nil {
+			t.Errorf("no error at invalid test %d: %s", index, input)
+		}
+	}
+}
+
+func TestNewKeepsBracketsInPaths(t *testing.T) {
+	rootDir := t.TempDir()
+
+	toothFilePath := filepath.Join(rootDir, "tooth[1].tth")
+	os.WriteFile(toothFilePath, []byte{}, 0644)
+
+	toothDirPath := filepath.Join(rootDir, "tooth[web]")
+	os.MkdirAll(toothDirPath, 0755)
+	os.WriteFile(filepath.Join(toothDirPath, "tooth.json"), []byte("{}"), 0644)
+
+	specifier, err := New(context.Background(), toothFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if specifier.ToothFilePath() != toothFilePath || len(specifier.Extras()) != 0 {
+		t.Errorf("wrong tooth file specifier: %s %v", specifier.ToothFilePath(), specifier.Extras())
+	}
+
+	specifier, err = New(context.Background(), toothDirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if specifier.ToothDirPath() != toothDirPath || len(specifier.Extras()) != 0 {
+		t.Errorf("wrong tooth directory specifier: %s %v", specifier.ToothDirPath(), specifier.Extras())
+	}
+}
+
+func TestSplitGitSpecifier(t *testing.T) {
+	testList := []struct {
+		input string
+		url   string
+		ref   string
+	}{
+		{"git+https://example.com/user/repo.git", "https://example.com/user/repo.git", ""},
+		{"git+https://example.com/user/repo.git@v1.0.0", "https://example.com/user/repo.git", "v1.0.0"},
+		{"git+https://example.com/user/repo@feature/new", "https://example.com/user/repo", "feature/new"},
+		{"git+ssh://git@example.com/user/repo.git", "ssh://git@example.com/user/repo.git", ""},
+		{"git+ssh://git@example.com/user/repo.git@main", "ssh://git@example.com/user/repo.git", "main"},
+		{"git+git@example.com:user/repo.git", "git@example.com:user/repo.git", ""},
+		{"git+git@example.com:user/repo.git@1a2b3c4", "git@example.com:user/repo.git", "1a2b3c4"},
+		{"git+file:///srv/git/repo.git@main", "file:///srv/git/repo.git", "main"},
+		{"git+/srv/git/repo.git", "/srv/git/repo.git", ""},
+	}
+
+	for index, test := range testList {
+		url, ref := splitGitSpecifier(test.input)
+		if url != test.url || ref != test.ref {
+			t.Errorf("wrong output at test %d: %s %s", index, url, ref)
+		}
+	}
+}
+
+func TestNewGitSpecifier(t *testing.T) {
+	// Refs are not resolved when parsing, so the repository is not accessed.
+	specifier, err := New(context.Background(), "git+/nonexistent/repo.git@main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if specifier.GitCommit() != "" || specifier.String() != "git+/nonexistent/repo.git@main" {
+		t.Errorf("wrong specifier: %s %s", specifier.GitCommit(), specifier.String())
+	}
+
+	// URLs and refs beginning with "-" would be taken as options by git.
+	for index, input := range []string{"git+--upload-pack=touch /tmp/x", "git+https://example.com/repo.git@--all"} {
+		if _, err := New(context.Background(), input); err == nil {
+			t.Errorf("no error at invalid test %d: %s", index, input)
+		}
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not found in PATH")
+	}
+
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("USERPROFILE", homeDir)
+
+	repoDir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch=main"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "--allow-empty", "--message=init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %s", strings.Join(args, " "), output)
+		}
+	}
+
+	specifier, err = New(context.Background(), "git+"+repoDir+"@main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	specifier, err = specifier.ResolveGitCommit(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(specifier.GitCommit()) != 40 || specifier.String() != "git+"+repoDir+"@"+specifier.GitCommit() {
+		t.Errorf("wrong resolved specifier: %s", specifier.String())
+	}
+}
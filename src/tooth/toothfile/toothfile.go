@@ -5,18 +5,31 @@ import (
 	"archive/zip"
 	"errors"
 	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/liteldev/lip/tooth/toothmetadata"
+	"github.com/liteldev/lip/tooth/toothpack"
 )
 
-// ToothFile is the struct that contains the metadata of a .tth file.
+// ToothFile is the struct that contains the metadata of a .tth file or an
+// unpacked tooth directory.
 type ToothFile struct {
 	filePath string
 	metadata toothmetadata.Metadata
+	isDir    bool
 }
 
-// New creates a new ToothFile struct from a file path of a .tth file.
+// New creates a new ToothFile struct from a file path of a .tth file. If the
+// path is a directory containing tooth.json, it is regarded as an unpacked
+// tooth directory.
 func New(filePath string) (ToothFile, error) {
+	if fileInfo, err := os.Stat(filePath); err == nil && fileInfo.IsDir() {
+		return NewFromDir(filePath)
+	}
+
 	r, err := zip.OpenReader(filePath)
 	if err != nil {
 		return ToothFile{}, errors.New("Failed to open tooth file " + filePath)
@@ -50,9 +63,13 @@ func New(filePath string) (ToothFile, error) {
 			}
 
 			// Parse the wildcard placements.
-			metadata = parseMetadataPlacement(metadata, r, filePrefix)
+			fileNameList := make([]string, 0, len(r.File))
+			for _, file := range r.File {
+				fileNameList = append(fileNameList, strings.TrimPrefix(file.Name, filePrefix))
+			}
+			metadata = parseMetadataPlacement(metadata, fileNameList)
 
-			return ToothFile{filePath, metadata}, nil
+			return ToothFile{filePath, metadata, false}, nil
 		}
 	}
 
@@ -60,12 +77,79 @@ func New(filePath string) (ToothFile, error) {
 	return ToothFile{}, errors.New("tooth.json not found in " + filePath)
 }
 
-// FilePath returns the file path of the .tth file.
+// NewFromDir creates a new ToothFile struct from an unpacked tooth directory
+// containing tooth.json. Files are listed in the same way as by lip tooth pack,
+// so files ignored by .toothignore are not part of the tooth.
+func NewFromDir(dirPath string) (ToothFile, error) {
+	data, err := os.ReadFile(filepath.Join(dirPath, "tooth.json"))
+	if err != nil {
+		return ToothFile{}, errors.New("Failed to read tooth.json in " + dirPath)
+	}
+
+	// Decode tooth.json.
+	metadata, err := toothmetadata.NewFromJSON(data)
+	if err != nil {
+		return ToothFile{}, err
+	}
+
+	// Parse the wildcard placements.
+	fileNameList, err := toothpack.ListFiles(dirPath)
+	if err != nil {
+		return ToothFile{}, err
+	}
+	metadata = parseMetadataPlacement(metadata, fileNameList)
+
+	return ToothFile{dirPath, metadata, true}, nil
+}
+
+// FilePath returns the file path of the .tth file, or the path of the tooth
+// directory if it is an unpacked tooth directory.
 func (t ToothFile) FilePath() string {
 	return t.filePath
 }
 
+// IsDir returns true if the tooth file is an unpacked tooth directory.
+func (t ToothFile) IsDir() bool {
+	return t.isDir
+}
+
 // Metadata returns the metadata of the .tth file.
 func (t ToothFile) Metadata() toothmetadata.Metadata {
 	return t.metadata
 }
+
+// FileList returns the list of files in the .tth file or the tooth directory,
+// relative to the root of the tooth and separated by slashes, in ascending
+// order. Directories are not included.
+func (t ToothFile) FileList() ([]string, error) {
+	var fileNameList []string
+
+	if t.isDir {
+		var err error
+		fileNameList, err = toothpack.ListFiles(t.filePath)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		r, err := zip.OpenReader(t.filePath)
+		if err != nil {
+			return nil, errors.New("Failed to open tooth file " + t.filePath)
+		}
+		defer r.Close()
+
+		filePrefix := GetFilePrefix(r)
+		for _, f := range r.File {
+			fileNameList = append(fileNameList, strings.TrimPrefix(f.Name, filePrefix))
+		}
+	}
+
+	fileList := make([]string, 0, len(fileNameList))
+	for _, fileName := range fileNameList {
+		if fileName != "" && !strings.HasSuffix(fileName, "/") {
+			fileList = append(fileList, fileName)
+		}
+	}
+	sort.Strings(fileList)
+
+	return fileList, nil
+}
@@ -0,0 +1,56 @@
+package toothfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewFromDirHonoursToothIgnore(t *testing.T) {
+	dirPath := t.TempDir()
+
+	fileMap := map[string]string{
+		"tooth.json": `{
+    "format_version": 1,
+    "tooth": "example.com/org/repo",
+    "version": "1.0.0",
+    "placement": [
+        {
+            "source": "sub/*",
+            "destination": "out/*"
+        }
+    ]
+}`,
+		"sub/a.txt":    "a",
+		"sub/b.log":    "b",
+		".toothignore": "*.log\n",
+	}
+
+	for file, content := range fileMap {
+		filePath := filepath.Join(dirPath, filepath.FromSlash(file))
+		os.MkdirAll(filepath.Dir(filePath), 0755)
+		err := os.WriteFile(filePath, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	toothFile, err := NewFromDir(dirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileList, err := toothFile.FileList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(fileList, " ") != ".toothignore sub/a.txt tooth.json" {
+		t.Errorf("FileList() = %v", fileList)
+	}
+
+	placementList := toothFile.Metadata().Placement
+	if len(placementList) != 1 || placementList[0].Source != "sub/a.txt" {
+		t.Errorf("Placement = %v, want only sub/a.txt", placementList)
+	}
+}
@@ -8,34 +8,39 @@ import (
 )
 
 // parseMetadataPlacement parses the wildcard placements of a tooth metadata.
-func parseMetadataPlacement(metadata toothmetadata.Metadata, r *zip.ReadCloser, filePrefix string) toothmetadata.Metadata {
-	for i, placement := range metadata.Placement {
-		// If either source or destination is not a wildcard, skip.
+// fileNameList is the list of file names relative to the root of the tooth,
+// separated by slashes. Directories end with a slash.
+func parseMetadataPlacement(metadata toothmetadata.Metadata, fileNameList []string) toothmetadata.Metadata {
+	placementList := make([]toothmetadata.PlacementStruct, 0, len(metadata.Placement))
+
+	for _, placement := range metadata.Placement {
+		// If either source or destination is not a wildcard, keep it as is.
 		if !strings.HasSuffix(placement.Source, "*") ||
 			!strings.HasSuffix(placement.Destination, "*") {
+			placementList = append(placementList, placement)
 			continue
 		}
 
-		placement.Source = strings.TrimSuffix(placement.Source, "*")
-		placement.Destination = strings.TrimSuffix(placement.Destination, "*")
+		sourcePrefix := strings.TrimSuffix(placement.Source, "*")
+		destinationPrefix := strings.TrimSuffix(placement.Destination, "*")
 
-		// Find all files that match the source.
-		for _, file := range r.File {
-			fileName := strings.TrimPrefix(file.Name, filePrefix)
-			if strings.HasPrefix(fileName, placement.Source) &&
+		// Replace the wildcard placement with all files that match the source.
+		// The platform of the wildcard placement applies to each file.
+		for _, fileName := range fileNameList {
+			if strings.HasPrefix(fileName, sourcePrefix) &&
 				!strings.HasSuffix(fileName, "/") { // Skip directories.
-				// Add the file to the metadata.
-				metadata.Placement = append(metadata.Placement, toothmetadata.PlacementStruct{
+				placementList = append(placementList, toothmetadata.PlacementStruct{
 					Source:      fileName,
-					Destination: placement.Destination + strings.TrimPrefix(fileName, placement.Source),
+					Destination: destinationPrefix + strings.TrimPrefix(fileName, sourcePrefix),
+					GOOS:        placement.GOOS,
+					GOARCH:      placement.GOARCH,
 				})
 			}
 		}
-
-		// Remove the wildcard placement.
-		metadata.Placement = append(metadata.Placement[:i], metadata.Placement[i+1:]...)
 	}
 
+	metadata.Placement = placementList
+
 	return metadata
 }
 
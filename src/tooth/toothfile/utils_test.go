@@ -0,0 +1,38 @@
+package toothfile
+
+import (
+	"testing"
+
+	"github.com/liteldev/lip/tooth/toothmetadata"
+)
+
+func TestParseMetadataPlacement(t *testing.T) {
+	metadata := toothmetadata.Metadata{
+		Placement: []toothmetadata.PlacementStruct{
+			{Source: "a/*", Destination: "x/*", GOOS: "windows"},
+			{Source: "c.txt", Destination: "c.txt"},
+			{Source: "b/*", Destination: "y/b/*", GOARCH: "amd64"},
+		},
+	}
+
+	fileNameList := []string{"a/", "a/1.txt", "a/sub/", "a/sub/2.txt", "b/", "b/3.txt", "c.txt"}
+
+	metadata = parseMetadataPlacement(metadata, fileNameList)
+
+	expectedList := []toothmetadata.PlacementStruct{
+		{Source: "a/1.txt", Destination: "x/1.txt", GOOS: "windows"},
+		{Source: "a/sub/2.txt", Destination: "x/sub/2.txt", GOOS: "windows"},
+		{Source: "c.txt", Destination: "c.txt"},
+		{Source: "b/3.txt", Destination: "y/b/3.txt", GOARCH: "amd64"},
+	}
+
+	if len(metadata.Placement) != len(expectedList) {
+		t.Fatalf("parseMetadataPlacement() = %v, want %v", metadata.Placement, expectedList)
+	}
+
+	for i, expected := range expectedList {
+		if metadata.Placement[i] != expected {
+			t.Errorf("parseMetadataPlacement()[%d] = %v, want %v", i, metadata.Placement[i], expected)
+		}
+	}
+}
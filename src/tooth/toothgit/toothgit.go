@@ -0,0 +1,214 @@
+// Package toothgit fetches tooths directly from git repositories with the
+// system git.
+package toothgit
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/liteldev/lip/localfile"
+)
+
+// IsCommitHash returns true if the ref is a full commit hash.
+func IsCommitHash(ref string) bool {
+	reg := regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+	return reg.MatchString(ref)
+}
+
+// ResolveCommit fetches the git repository and returns the full commit hash of
+// the ref. The ref can be a tag, a branch or a (short) commit hash. If the ref
+// is empty, the default branch is used.
+func ResolveCommit(ctx context.Context, url string, ref string) (string, error) {
+	if strings.HasPrefix(ref, "-") {
+		return "", errors.New("invalid git ref: " + ref)
+	}
+
+	mirrorDir, err := fetch(ctx, url)
+	if err != nil {
+		return "", err
+	}
+
+	revision := "HEAD"
+	if ref != "" {
+		revision = ref
+	}
+
+	output, err := runGit(ctx, mirrorDir, "rev-parse", "--verify", "--quiet", revision+"^{commit}")
+	if err != nil {
+		return "", errors.New("cannot find " + revision + " in git repository " + url)
+	}
+
+	return strings.TrimSpace(output), nil
+}
+
+// Archive builds a tooth archive from the tree of a commit of the git
+// repository and saves it to the destination.
+func Archive(ctx context.Context, url string, commit string, destination string) error {
+	if !IsCommitHash(commit) {
+		return errors.New("invalid git commit: " + commit)
+	}
+
+	mirrorDir, err := fetch(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	tempFilePath := destination + ".tmp"
+
+	_, err = runGit(ctx, mirrorDir, "archive", "--format=zip", "--output="+tempFilePath, commit)
+	if err != nil {
+		os.Remove(tempFilePath)
+		return errors.New("cannot build tooth archive from git repository " + url + ": " + err.Error())
+	}
+
+	err = os.Rename(tempFilePath, destination)
+	if err != nil {
+		return errors.New("cannot move tooth archive to " + destination + ": " + err.Error())
+	}
+
+	return nil
+}
+
+// ListTags lists the tags of the git repository without fetching it.
+func ListTags(ctx context.Context, url string) ([]string, error) {
+	if strings.HasPrefix(url, "-") {
+		return nil, errors.New("invalid git repository URL: " + url)
+	}
+
+	output, err := runGit(ctx, "", "ls-remote", "--tags", "--refs", url)
+	if err != nil {
+		return nil, errors.New("cannot list tags of git repository " + url + ": " + err.Error())
+	}
+
+	tagList := make([]string, 0)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		tagList = append(tagList, strings.TrimPrefix(fields[1], "refs/tags/"))
+	}
+
+	return tagList, nil
+}
+
+// IsWorkTreeClean returns true if the git work tree of the directory has no
+// uncommitted changes or untracked files.
+func IsWorkTreeClean(dir string) (bool, error) {
+	output, err := runGit(context.Background(), dir, "status", "--porcelain")
+	if err != nil {
+		return false, errors.New("cannot get git status of " + dir + ": " + err.Error())
+	}
+
+	return strings.TrimSpace(output) == "", nil
+}
+
+// HasTag returns true if the tag exists in the git repository of the directory.
+func HasTag(dir string, tag string) bool {
+	_, err := runGit(context.Background(), dir, "rev-parse", "--verify", "--quiet", "refs/tags/"+tag)
+
+	return err == nil
+}
+
+// CommitAndTag commits the files in the git repository of the directory and
+// creates an annotated tag on the commit.
+func CommitAndTag(dir string, fileList []string, message string, tag string) error {
+	_, err := runGit(context.Background(), dir, append([]string{"add", "--"}, fileList...)...)
+	if err != nil {
+		return errors.New("cannot add files to git: " + err.Error())
+	}
+
+	_, err = runGit(context.Background(), dir, "commit", "--quiet", "--message", message)
+	if err != nil {
+		return errors.New("cannot create git commit: " + err.Error())
+	}
+
+	_, err = runGit(context.Background(), dir, "tag", "--annotate", "--message", tag, tag)
+	if err != nil {
+		return errors.New("cannot create git tag " + tag + ": " + err.Error())
+	}
+
+	return nil
+}
+
+// RepoURL returns the URL of the git repository of a tooth repository path,
+// which is used when fetching from GOPROXY fails.
+func RepoURL(repoPath string) string {
+	return "https://" + repoPath
+}
+
+// fetch clones the git repository as a mirror in the cache directory, or updates
+// the mirror if it already exists, and returns the path of the mirror.
+func fetch(ctx context.Context, url string) (string, error) {
+	// URLs beginning with "-" would be taken as options by git.
+	if strings.HasPrefix(url, "-") {
+		return "", errors.New("invalid git repository URL: " + url)
+	}
+
+	cacheDir, err := localfile.CacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	mirrorDir := filepath.Join(cacheDir, "git", base64.URLEncoding.EncodeToString([]byte(url)))
+
+	if _, err := os.Stat(mirrorDir); err == nil {
+		_, err = runGit(ctx, mirrorDir, "remote", "update", "--prune")
+		if err != nil {
+			return "", errors.New("cannot fetch git repository " + url + ": " + err.Error())
+		}
+
+		return mirrorDir, nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(mirrorDir), 0755)
+	if err != nil {
+		return "", errors.New("cannot create git cache directory: " + err.Error())
+	}
+
+	_, err = runGit(ctx, "", "clone", "--mirror", "--quiet", "--", url, mirrorDir)
+	if err != nil {
+		os.RemoveAll(mirrorDir)
+		return "", errors.New("cannot clone git repository " + url + ": " + err.Error())
+	}
+
+	return mirrorDir, nil
+}
+
+// runGit runs the system git in the directory and returns the standard output.
+// Git is killed if the context is cancelled.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", errors.New("git is not found in PATH")
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+
+	// Never wait for credentials on the terminal.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", errors.New(message)
+	}
+
+	return stdout.String(), nil
+}
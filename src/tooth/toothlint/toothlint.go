@@ -0,0 +1,454 @@
+// Package toothlint provides semantic checks of tooth.json.
+package toothlint
+
+import (
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/liteldev/lip/tooth/toothmetadata"
+	"github.com/liteldev/lip/utils/platform"
+	"github.com/liteldev/lip/utils/versions"
+	"github.com/liteldev/lip/utils/versions/versionmatch"
+)
+
+// SeverityType is the severity of a finding.
+type SeverityType string
+
+const (
+	SeverityError   SeverityType = "error"
+	SeverityWarning SeverityType = "warning"
+)
+
+// Finding is a problem found in tooth.json.
+type Finding struct {
+	// Pointer is the JSON pointer (RFC 6901) to the value with the problem.
+	Pointer    string       `json:"pointer"`
+	Severity   SeverityType `json:"severity"`
+	Message    string       `json:"message"`
+	Suggestion string       `json:"suggestion"`
+}
+
+// VersionListFetcher fetches the published versions of a tooth.
+type VersionListFetcher func(toothPath string) ([]versions.Version, error)
+
+// Lint checks tooth.json and returns the findings. If fetchVersionList is nil,
+// dependency ranges are not checked against published versions.
+func Lint(jsonData []byte, fetchVersionList VersionListFetcher) []Finding {
+	// Structure errors are reported first. Semantic checks need a valid structure.
+	schemaErrorList, err := toothmetadata.ValidateSchema(jsonData)
+	if err != nil {
+		return []Finding{{
+			Pointer:    "",
+			Severity:   SeverityError,
+			Message:    err.Error(),
+			Suggestion: "Make sure tooth.json is valid JSON.",
+		}}
+	}
+
+	if len(schemaErrorList) > 0 {
+		findingList := make([]Finding, 0, len(schemaErrorList))
+		for _, schemaError := range schemaErrorList {
+			findingList = append(findingList, Finding{
+				Pointer:    schemaError.Pointer,
+				Severity:   SeverityError,
+				Message:    schemaError.Message,
+				Suggestion: "See the tooth.json file reference for the expected structure.",
+			})
+		}
+		return findingList
+	}
+
+	metadata, err := toothmetadata.NewFromJSON(jsonData)
+	if err != nil {
+		return []Finding{{
+			Pointer:    "",
+			Severity:   SeverityError,
+			Message:    err.Error(),
+			Suggestion: "See the tooth.json file reference for the expected values.",
+		}}
+	}
+
+	// Keys in the metadata are lower case, so the raw map is used for pointers.
+	var metadataMap map[string]interface{}
+	json.Unmarshal(jsonData, &metadataMap)
+
+	findingList := make([]Finding, 0)
+	findingList = append(findingList, checkPlatforms(metadataMap)...)
+	findingList = append(findingList, checkPlacements(metadata)...)
+	findingList = append(findingList, checkPossessions(metadata)...)
+	findingList = append(findingList, checkToolEntrypoints(metadata)...)
+	if fetchVersionList != nil {
+		findingList = append(findingList, checkDependencies(metadataMap, metadata, fetchVersionList)...)
+	}
+
+	return findingList
+}
+
+// HasError returns true if any finding is an error.
+func HasError(findingList []Finding) bool {
+	for _, finding := range findingList {
+		if finding.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkPlatforms checks GOOS and GOARCH values of placements, commands,
+// confirmations and tool entrypoints.
+func checkPlatforms(metadataMap map[string]interface{}) []Finding {
+	findingList := make([]Finding, 0)
+
+	checkItemList := func(pointer string, itemList []interface{}) {
+		for i, item := range itemList {
+			itemMap := item.(map[string]interface{})
+			itemPointer := pointer + "/" + strconv.Itoa(i)
+
+			if goos, ok := itemMap["GOOS"].(string); ok && goos != "" && !platform.IsKnownGOOS(goos) {
+				findingList = append(findingList, Finding{
+					Pointer:    itemPointer + "/GOOS",
+					Severity:   SeverityError,
+					Message:    "unknown GOOS \"" + goos + "\"",
+					Suggestion: suggest(goos, platform.KnownGOOSList),
+				})
+			}
+
+			if goarch, ok := itemMap["GOARCH"].(string); ok && goarch != "" && !platform.IsKnownGOARCH(goarch) {
+				findingList = append(findingList, Finding{
+					Pointer:    itemPointer + "/GOARCH",
+					Severity:   SeverityError,
+					Message:    "unknown GOARCH \"" + goarch + "\"",
+					Suggestion: suggest(goarch, platform.KnownGOARCHList),
+				})
+			}
+		}
+	}
+
+	for _, key := range []string{"placement", "commands", "confirmation"} {
+		if itemList, ok := metadataMap[key].([]interface{}); ok {
+			checkItemList("/"+key, itemList)
+		}
+	}
+
+	if tool, ok := metadataMap["tool"].(map[string]interface{}); ok {
+		if entrypointList, ok := tool["entrypoints"].([]interface{}); ok {
+			checkItemList("/tool/entrypoints", entrypointList)
+		}
+
+		if subcommandMap, ok := tool["subcommands"].(map[string]interface{}); ok {
+			subcommandNameList := make([]string, 0, len(subcommandMap))
+			for name := range subcommandMap {
+				subcommandNameList = append(subcommandNameList, name)
+			}
+			sort.Strings(subcommandNameList)
+
+			for _, name := range subcommandNameList {
+				subcommand, ok := subcommandMap[name].(map[string]interface{})
+				if !ok {
+					continue
+				}
+
+				if entrypointList, ok := subcommand["entrypoints"].([]interface{}); ok {
+					checkItemList("/tool/subcommands/"+name+"/entrypoints", entrypointList)
+				}
+			}
+		}
+	}
+
+	return findingList
+}
+
+// checkPlacements checks wildcards and destinations of placements.
+func checkPlacements(metadata toothmetadata.Metadata) []Finding {
+	findingList := make([]Finding, 0)
+
+	for i, placement := range metadata.Placement {
+		pointer := "/placement/" + strconv.Itoa(i)
+
+		// Wildcards are only supported at the end of both source and destination.
+		isSourceWildcard := strings.HasSuffix(placement.Source, "*")
+		isDestinationWildcard := strings.HasSuffix(placement.Destination, "*")
+		if isSourceWildcard != isDestinationWildcard {
+			findingList = append(findingList, Finding{
+				Pointer:  pointer,
+				Severity: SeverityError,
+				Message:  "wildcard \"*\" is used on only one side of the placement",
+				Suggestion: "Add \"*\" to the end of both source and destination, e.g. \"plugins/*\" -> \"plugins/*\", " +
+					"or remove it from both.",
+			})
+		}
+
+		for _, field := range []string{"source", "destination"} {
+			value := placement.Source
+			if field == "destination" {
+				value = placement.Destination
+			}
+
+			if strings.Contains(strings.TrimSuffix(value, "*"), "*") {
+				findingList = append(findingList, Finding{
+					Pointer:    pointer + "/" + field,
+					Severity:   SeverityError,
+					Message:    "wildcard \"*\" is only supported at the end of the path",
+					Suggestion: "Use a trailing \"*\" to match all files under a directory.",
+				})
+			}
+		}
+
+		// Destinations must stay in the workspace.
+		if isOutsideWorkspace(placement.Destination) {
+			findingList = append(findingList, Finding{
+				Pointer:    pointer + "/destination",
+				Severity:   SeverityError,
+				Message:    "destination \"" + placement.Destination + "\" escapes the workspace",
+				Suggestion: "Use a relative path inside the workspace without \"..\".",
+			})
+		}
+	}
+
+	// Destinations must not be placed twice on the same platform.
+	for i, placement := range metadata.Placement {
+		for j := 0; j < i; j++ {
+			previousPlacement := metadata.Placement[j]
+			if path.Clean(placement.Destination) != path.Clean(previousPlacement.Destination) {
+				continue
+			}
+
+			if !isPlatformOverlapped(placement.GOOS, previousPlacement.GOOS) ||
+				!isPlatformOverlapped(placement.GOARCH, previousPlacement.GOARCH) {
+				continue
+			}
+
+			findingList = append(findingList, Finding{
+				Pointer:  "/placement/" + strconv.Itoa(i) + "/destination",
+				Severity: SeverityError,
+				Message: "destination \"" + placement.Destination + "\" is also used by /placement/" +
+					strconv.Itoa(j),
+				Suggestion: "Use different destinations or restrict the placements to different GOOS/GOARCH.",
+			})
+			break
+		}
+	}
+
+	return findingList
+}
+
+// checkPossessions checks that possessions are directories.
+func checkPossessions(metadata toothmetadata.Metadata) []Finding {
+	findingList := make([]Finding, 0)
+
+	for i, possession := range metadata.Possession {
+		pointer := "/possession/" + strconv.Itoa(i)
+
+		if !strings.HasSuffix(possession, "/") {
+			findingList = append(findingList, Finding{
+				Pointer:    pointer,
+				Severity:   SeverityError,
+				Message:    "possession \"" + possession + "\" does not end with \"/\"",
+				Suggestion: "Possessions must be directories. Use \"" + possession + "/\" instead.",
+			})
+		}
+
+		if isOutsideWorkspace(possession) {
+			findingList = append(findingList, Finding{
+				Pointer:    pointer,
+				Severity:   SeverityError,
+				Message:    "possession \"" + possession + "\" escapes the workspace",
+				Suggestion: "Use a relative path inside the workspace without \"..\".",
+			})
+		}
+	}
+
+	return findingList
+}
+
+// checkToolEntrypoints checks that entrypoints of the tool and its subcommands
+// can be run by shims.
+func checkToolEntrypoints(metadata toothmetadata.Metadata) []Finding {
+	findingList := make([]Finding, 0)
+
+	pointerMap := make(map[string][]toothmetadata.ToolEntrypointStruct)
+	pointerMap["/tool/entrypoints"] = metadata.Tool.Entrypoints
+	for name, subcommand := range metadata.Tool.Subcommands {
+		pointerMap["/tool/subcommands/"+name+"/entrypoints"] = subcommand.Entrypoints
+	}
+
+	pointerList := make([]string, 0, len(pointerMap))
+	for pointer := range pointerMap {
+		pointerList = append(pointerList, pointer)
+	}
+	sort.Strings(pointerList)
+
+	for _, pointer := range pointerList {
+		for i, entrypoint := range pointerMap[pointer] {
+			err := toothmetadata.CheckEntrypointPath(entrypoint.Path)
+			if err != nil {
+				findingList = append(findingList, Finding{
+					Pointer:    pointer + "/" + strconv.Itoa(i) + "/path",
+					Severity:   SeverityError,
+					Message:    "entrypoint " + err.Error(),
+					Suggestion: "Use a relative path inside the workspace without \"..\", quotes or shell operators.",
+				})
+			}
+		}
+	}
+
+	return findingList
+}
+
+// checkDependencies checks that each dependency range is satisfied by at least
+// one published version.
+func checkDependencies(metadataMap map[string]interface{}, metadata toothmetadata.Metadata,
+	fetchVersionList VersionListFetcher) []Finding {
+	findingList := make([]Finding, 0)
+
+	checkRangeMap := func(pointer string, rawMap map[string]interface{},
+		versionRangeMap map[string]([][]versionmatch.VersionMatch)) {
+		for _, toothPath := range sortedKeys(rawMap) {
+			versionRange := versionRangeMap[strings.ToLower(toothPath)]
+			dependencyPointer := pointer + "/" + escapePointerToken(toothPath)
+
+			versionList, err := fetchVersionList(strings.ToLower(toothPath))
+			if err != nil {
+				findingList = append(findingList, Finding{
+					Pointer:    dependencyPointer,
+					Severity:   SeverityWarning,
+					Message:    "cannot fetch published versions of " + toothPath + ": " + err.Error(),
+					Suggestion: "Check the tooth path and your network, or run with --offline.",
+				})
+				continue
+			}
+
+			isSatisfied := false
+			for _, version := range versionList {
+				if versionmatch.MatchRange(version, versionRange) {
+					isSatisfied = true
+					break
+				}
+			}
+
+			if !isSatisfied {
+				suggestion := toothPath + " has no published versions."
+				if len(versionList) > 0 {
+					suggestion = "The latest published version of " + toothPath + " is " + versionList[0].String() + "."
+				}
+
+				findingList = append(findingList, Finding{
+					Pointer:    dependencyPointer,
+					Severity:   SeverityError,
+					Message:    "no published version of " + toothPath + " satisfies " + versionmatch.RangeString(versionRange),
+					Suggestion: suggestion,
+				})
+			}
+		}
+	}
+
+	if rawMap, ok := metadataMap["dependencies"].(map[string]interface{}); ok {
+		checkRangeMap("/dependencies", rawMap, metadata.Dependencies)
+	}
+
+	if rawExtraMap, ok := metadataMap["optional_dependencies"].(map[string]interface{}); ok {
+		for _, extra := range sortedKeys(rawExtraMap) {
+			checkRangeMap("/optional_dependencies/"+escapePointerToken(extra),
+				rawExtraMap[extra].(map[string]interface{}), metadata.OptionalDependencies[extra])
+		}
+	}
+
+	return findingList
+}
+
+// escapePointerToken escapes a JSON pointer reference token.
+func escapePointerToken(token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	token = strings.ReplaceAll(token, "/", "~1")
+
+	return token
+}
+
+// isOutsideWorkspace returns true if the path is absolute or goes above the
+// workspace.
+func isOutsideWorkspace(p string) bool {
+	if filepath.IsAbs(p) || path.IsAbs(p) || filepath.VolumeName(p) != "" ||
+		(len(p) >= 2 && p[1] == ':') {
+		return true
+	}
+
+	cleaned := path.Clean(strings.ReplaceAll(p, "\\", "/"))
+
+	return cleaned == ".." || strings.HasPrefix(cleaned, "../")
+}
+
+// isPlatformOverlapped returns true if two GOOS or GOARCH filters may select
+// the same platform. An empty filter selects all platforms.
+func isPlatformOverlapped(a string, b string) bool {
+	return a == "" || b == "" || a == b
+}
+
+// sortedKeys returns the keys of a map in ascending order.
+func sortedKeys(m map[string]interface{}) []string {
+	keyList := make([]string, 0, len(m))
+	for key := range m {
+		keyList = append(keyList, key)
+	}
+	sort.Strings(keyList)
+
+	return keyList
+}
+
+// suggest suggests a known value for an unknown value.
+func suggest(value string, knownList []string) string {
+	for _, known := range knownList {
+		if strings.EqualFold(value, known) {
+			return "Values are case-sensitive. Use \"" + known + "\" instead."
+		}
+	}
+
+	// Find the closest known value.
+	closest := ""
+	closestDistance := 3
+	for _, known := range knownList {
+		distance := levenshteinDistance(strings.ToLower(value), known)
+		if distance < closestDistance {
+			closest = known
+			closestDistance = distance
+		}
+	}
+	if closest != "" {
+		return "Did you mean \"" + closest + "\"?"
+	}
+
+	return "Use one of: " + strings.Join(knownList, ", ") + "."
+}
+
+// levenshteinDistance returns the edit distance between two strings.
+func levenshteinDistance(a string, b string) int {
+	previousRow := make([]int, len(b)+1)
+	for j := range previousRow {
+		previousRow[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		currentRow := make([]int, len(b)+1)
+		currentRow[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			currentRow[j] = previousRow[j] + 1
+			if currentRow[j-1]+1 < currentRow[j] {
+				currentRow[j] = currentRow[j-1] + 1
+			}
+			if previousRow[j-1]+cost < currentRow[j] {
+				currentRow[j] = previousRow[j-1] + cost
+			}
+		}
+		previousRow = currentRow
+	}
+
+	return previousRow[len(b)]
+}
@@ -0,0 +1,146 @@
+package toothlint
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/liteldev/lip/utils/platform"
+	"github.com/liteldev/lip/utils/versions"
+)
+
+const validToothJSON = `{
+	"format_version": 1,
+	"tooth": "example.com/a/b",
+	"version": "1.0.0",
+	"information": {"name": "b", "description": "b", "author": "a"},
+	"dependencies": {"example.com/c/d": "^1.2"},
+	"placement": [
+		{"source": "plugins/*", "destination": "plugins/*", "GOOS": "windows"},
+		{"source": "bin/b", "destination": "bin/b", "GOOS": "linux", "GOARCH": "amd64"},
+		{"source": "bin/b.exe", "destination": "bin/b", "GOOS": "windows", "GOARCH": "amd64"}
+	],
+	"possession": ["data/"]
+}`
+
+func fetchVersionListStub(toothPath string) ([]versions.Version, error) {
+	switch toothPath {
+	case "example.com/c/d":
+		v1, _ := versions.NewFromString("1.3.0")
+		v2, _ := versions.NewFromString("1.2.0")
+		return []versions.Version{v1, v2}, nil
+	}
+
+	return nil, errors.New("not found")
+}
+
+func TestLintValid(t *testing.T) {
+	findingList := Lint([]byte(validToothJSON), fetchVersionListStub)
+	if len(findingList) != 0 {
+		t.Errorf("Lint() = %v, want no findings", findingList)
+	}
+}
+
+func TestLint(t *testing.T) {
+	jsonData := []byte(`{
+		"format_version": 1,
+		"tooth": "example.com/a/b",
+		"version": "1.0.0",
+		"information": {"name": "b", "description": "b", "author": "a"},
+		"dependencies": {"example.com/c/d": ">=2.0.0", "example.com/e/f": "1.x"},
+		"placement": [
+			{"source": "plugins/*", "destination": "plugins", "GOOS": "Windows"},
+			{"source": "bin/b", "destination": "../bin/b", "GOARCH": "amd46"},
+			{"source": "c", "destination": "plugins/"},
+			{"source": "a*b", "destination": "a*b"}
+		],
+		"possession": ["data"],
+		"tool": {
+			"name": "b",
+			"description": "b",
+			"entrypoints": [{"path": "bin/b", "GOOS": "linux"}, {"path": "bin/b; rm -rf ~", "GOOS": "windows"}],
+			"subcommands": {"c": {"description": "c", "entrypoints": [{"path": "../c", "GOOS": "linux", "GOARCH": "amd46"}]}}
+		}
+	}`)
+
+	findingList := Lint(jsonData, fetchVersionListStub)
+
+	expectedList := []struct {
+		pointer  string
+		severity SeverityType
+	}{
+		{"/placement/0/GOOS", SeverityError},
+		{"/placement/1/GOARCH", SeverityError},
+		{"/tool/subcommands/c/entrypoints/0/GOARCH", SeverityError},
+		{"/placement/0", SeverityError},
+		{"/placement/1/destination", SeverityError},
+		{"/placement/3/source", SeverityError},
+		{"/placement/3/destination", SeverityError},
+		{"/placement/2/destination", SeverityError},
+		{"/possession/0", SeverityError},
+		{"/tool/entrypoints/1/path", SeverityError},
+		{"/tool/subcommands/c/entrypoints/0/path", SeverityError},
+		{"/dependencies/example.com~1c~1d", SeverityError},
+		{"/dependencies/example.com~1e~1f", SeverityWarning},
+	}
+
+	if len(findingList) != len(expectedList) {
+		t.Fatalf("Lint() = %v, want %d findings", findingList, len(expectedList))
+	}
+
+	for i, expected := range expectedList {
+		if findingList[i].Pointer != expected.pointer || findingList[i].Severity != expected.severity {
+			t.Errorf("Lint()[%d] = %v, want %v %v", i, findingList[i], expected.pointer, expected.severity)
+		}
+
+		if findingList[i].Suggestion == "" {
+			t.Errorf("Lint()[%d] has no suggestion", i)
+		}
+	}
+
+	if !HasError(findingList) {
+		t.Errorf("HasError() = false, want true")
+	}
+}
+
+func TestLintSchemaError(t *testing.T) {
+	findingList := Lint([]byte(`{"format_version": 1, "tooth": "example.com/a/b", "version": "1.0"}`), nil)
+	if len(findingList) == 0 {
+		t.Fatalf("Lint() = no findings, want schema errors")
+	}
+
+	for _, finding := range findingList {
+		if finding.Severity != SeverityError {
+			t.Errorf("Lint() = %v, want only errors", finding)
+		}
+	}
+}
+
+func TestLintOffline(t *testing.T) {
+	jsonData := []byte(`{
+		"format_version": 1,
+		"tooth": "example.com/a/b",
+		"version": "1.0.0",
+		"dependencies": {"example.com/e/f": "1.x"}
+	}`)
+
+	findingList := Lint(jsonData, nil)
+	if len(findingList) != 0 {
+		t.Errorf("Lint() = %v, want no findings", findingList)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	testList := []struct {
+		value  string
+		output string
+	}{
+		{"Linux", "Values are case-sensitive. Use \"linux\" instead."},
+		{"linx", "Did you mean \"linux\"?"},
+	}
+
+	for _, test := range testList {
+		if output := suggest(test.value, platform.KnownGOOSList); output != test.output {
+			t.Errorf("suggest(%q) = %q, want %q", test.value, output, test.output)
+		}
+	}
+}
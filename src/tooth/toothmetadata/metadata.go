@@ -5,9 +5,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
+	lipcontext "github.com/liteldev/lip/context"
 	"github.com/liteldev/lip/tooth/toothutils"
+	"github.com/liteldev/lip/utils/logger"
 	"github.com/liteldev/lip/utils/versions"
 	"github.com/liteldev/lip/utils/versions/versionmatch"
 	"github.com/xeipuuv/gojsonschema"
@@ -50,6 +56,14 @@ type ToolStruct struct {
 	Name        string
 	Description string
 	Entrypoints []ToolEntrypointStruct
+	Subcommands map[string]ToolSubcommandStruct
+}
+
+// ToolSubcommandStruct is a named subcommand of a tool, run by lip exec
+// <tool>:<subcommand>.
+type ToolSubcommandStruct struct {
+	Description string
+	Entrypoints []ToolEntrypointStruct
 }
 
 type ToolEntrypointStruct struct {
@@ -60,15 +74,20 @@ type ToolEntrypointStruct struct {
 
 // Metadata is the struct that contains all the metadata of a tooth.
 type Metadata struct {
-	ToothPath    string
-	Version      versions.Version
-	Dependencies map[string]([][]versionmatch.VersionMatch)
-	Information  InfoStruct
-	Placement    []PlacementStruct
-	Possession   []string
-	Commands     []CommandStruct
-	Confirmation []ConfirmationStruct
-	Tool         ToolStruct
+	ToothPath            string
+	Version              versions.Version
+	MinLipVersion        versions.Version
+	Dependencies         map[string]([][]versionmatch.VersionMatch)
+	OptionalDependencies map[string](map[string]([][]versionmatch.VersionMatch))
+	Conflicts            map[string]([][]versionmatch.VersionMatch)
+	Provides             map[string]versions.Version
+	Replaces             []string
+	Information          InfoStruct
+	Placement            []PlacementStruct
+	Possession           []string
+	Commands             []CommandStruct
+	Confirmation         []ConfirmationStruct
+	Tool                 ToolStruct
 }
 
 const jsonSchema string = `
@@ -81,6 +100,30 @@ const jsonSchema string = `
         "tooth",
         "version"
     ],
+    "definitions": {
+        "versionRange": {
+            "oneOf": [
+                {
+                    "type": "string"
+                },
+                {
+                    "type": "array",
+                    "uniqueItems": true,
+                    "minItems": 1,
+                    "additionalItems": false,
+                    "items": {
+                        "type": "array",
+                        "uniqueItems": true,
+                        "minItems": 1,
+                        "additionalItems": false,
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                }
+            ]
+        }
+    },
     "properties": {
         "format_version": {
             "enum": [
@@ -93,30 +136,64 @@ const jsonSchema string = `
         },
         "version": {
             "type": "string",
-            "pattern": "^\\d+\\.\\d+\\.(\\d+|0-[a-z]+(\\.[0-9]+)?)$"
+            "pattern": "^\\d+\\.\\d+\\.\\d+(-[0-9A-Za-z-]+(\\.[0-9A-Za-z-]+)*)?(\\+[0-9A-Za-z-]+(\\.[0-9A-Za-z-]+)*)?$"
+        },
+        "min_lip_version": {
+            "type": "string",
+            "pattern": "^(0|[1-9]\\d*)\\.(0|[1-9]\\d*)\\.(0|[1-9]\\d*)(-[0-9A-Za-z-]+(\\.[0-9A-Za-z-]+)*)?(\\+[0-9A-Za-z-]+(\\.[0-9A-Za-z-]+)*)?$"
         },
         "dependencies": {
             "type": "object",
             "additionalProperties": false,
             "patternProperties": {
                 "^[a-zA-Z\\d-_\\.\\/]*$": {
-                    "type": "array",
-                    "uniqueItems": true,
-                    "minItems": 1,
-                    "additionalItems": false,
-                    "items": {
-                        "type": "array",
-                        "uniqueItems": true,
-                        "minItems": 1,
-                        "additionalItems": false,
-                        "items": {
-                            "type": "string",
-                            "pattern": "^((>|>=|<|<=|!)?\\d+\\.\\d+\\.\\d+|\\d+\\.\\d+\\.x)$"
+                    "$ref": "#/definitions/versionRange"
+                }
+            }
+        },
+        "optional_dependencies": {
+            "type": "object",
+            "additionalProperties": false,
+            "patternProperties": {
+                "^[a-z0-9][a-z0-9-_]*$": {
+                    "type": "object",
+                    "additionalProperties": false,
+                    "patternProperties": {
+                        "^[a-zA-Z\\d-_\\.\\/]*$": {
+                            "$ref": "#/definitions/versionRange"
                         }
                     }
                 }
             }
         },
+        "conflicts": {
+            "type": "object",
+            "additionalProperties": false,
+            "patternProperties": {
+                "^[a-zA-Z\\d-_\\.\\/]*$": {
+                    "$ref": "#/definitions/versionRange"
+                }
+            }
+        },
+        "provides": {
+            "type": "object",
+            "additionalProperties": false,
+            "patternProperties": {
+                "^[a-zA-Z\\d-_\\.\\/]*$": {
+                    "type": "string",
+                    "pattern": "^(0|[1-9]\\d*)\\.(0|[1-9]\\d*)\\.(0|[1-9]\\d*)(-[0-9A-Za-z-]+(\\.[0-9A-Za-z-]+)*)?(\\+[0-9A-Za-z-]+(\\.[0-9A-Za-z-]+)*)?$"
+                }
+            }
+        },
+        "replaces": {
+            "type": "array",
+            "uniqueItems": true,
+            "additionalItems": false,
+            "items": {
+                "type": "string",
+                "pattern": "^[a-zA-Z\\d-_\\.\\/]*$"
+            }
+        },
         "information": {
             "type": "object"
         },
@@ -250,6 +327,47 @@ const jsonSchema string = `
                             }
                         }
                     }
+                },
+                "subcommands": {
+                    "type": "object",
+                    "additionalProperties": false,
+                    "patternProperties": {
+                        "^[a-z\\d-]+$": {
+                            "type": "object",
+                            "additionalProperties": false,
+                            "required": [
+                                "description",
+                                "entrypoints"
+                            ],
+                            "properties": {
+                                "description": {
+                                    "type": "string"
+                                },
+                                "entrypoints": {
+                                    "type": "array",
+                                    "items": {
+                                        "type": "object",
+                                        "additionalProperties": false,
+                                        "required": [
+                                            "path",
+                                            "GOOS"
+                                        ],
+                                        "properties": {
+                                            "path": {
+                                                "type": "string"
+                                            },
+                                            "GOOS": {
+                                                "type": "string"
+                                            },
+                                            "GOARCH": {
+                                                "type": "string"
+                                            }
+                                        }
+                                    }
+                                }
+                            }
+                        }
+                    }
                 }
             }
         }
@@ -257,8 +375,113 @@ const jsonSchema string = `
 }
 `
 
+// SchemaError is an error found when validating tooth.json against the JSON
+// schema.
+type SchemaError struct {
+	// Pointer is the JSON pointer (RFC 6901) to the invalid value.
+	Pointer string
+	Message string
+}
+
+// ValidateSchema validates a JSON byte array against the JSON schema of
+// tooth.json and returns each error found. Version ranges are validated by the
+// versionmatch package rather than by the schema.
+func ValidateSchema(jsonData []byte) ([]SchemaError, error) {
+	schemaLoader := gojsonschema.NewStringLoader(jsonSchema)
+	documentLoader := gojsonschema.NewBytesLoader(jsonData)
+
+	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
+	if err != nil {
+		return nil, errors.New("JSON schema validation failed: " + err.Error())
+	}
+
+	schemaErrorList := make([]SchemaError, 0)
+	for _, desc := range result.Errors() {
+		// Use a delimiter that cannot appear in keys to split the context.
+		pointer := ""
+		for _, token := range strings.Split(desc.Context().String("\x00"), "\x00") {
+			if token == "(root)" {
+				continue
+			}
+			pointer += "/" + escapePointerToken(token)
+		}
+
+		schemaErrorList = append(schemaErrorList, SchemaError{
+			Pointer: pointer,
+			Message: desc.Description(),
+		})
+	}
+
+	if len(schemaErrorList) > 0 {
+		return schemaErrorList, nil
+	}
+
+	// Version ranges can be checked once the structure is valid.
+	var metadataMap map[string]interface{}
+	json.Unmarshal(jsonData, &metadataMap)
+
+	if dependencies, ok := metadataMap["dependencies"].(map[string]interface{}); ok {
+		schemaErrorList = append(schemaErrorList, validateVersionRangeMap("/dependencies", dependencies)...)
+	}
+	if optionalDependencies, ok := metadataMap["optional_dependencies"].(map[string]interface{}); ok {
+		for extra, dependencies := range optionalDependencies {
+			schemaErrorList = append(schemaErrorList, validateVersionRangeMap(
+				"/optional_dependencies/"+escapePointerToken(extra), dependencies.(map[string]interface{}))...)
+		}
+	}
+	if conflicts, ok := metadataMap["conflicts"].(map[string]interface{}); ok {
+		schemaErrorList = append(schemaErrorList, validateVersionRangeMap("/conflicts", conflicts)...)
+	}
+
+	sort.Slice(schemaErrorList, func(i, j int) bool {
+		return schemaErrorList[i].Pointer < schemaErrorList[j].Pointer
+	})
+
+	return schemaErrorList, nil
+}
+
+// validateVersionRangeMap validates the version ranges of a map from tooth
+// paths to version ranges, which is at the pointer.
+func validateVersionRangeMap(pointer string, versionRangeMap map[string]interface{}) []SchemaError {
+	schemaErrorList := make([]SchemaError, 0)
+	for toothPath, versionRange := range versionRangeMap {
+		toothPointer := pointer + "/" + escapePointerToken(toothPath)
+
+		if versionRangeString, ok := versionRange.(string); ok {
+			if _, err := versionmatch.NewRangeFromString(versionRangeString); err != nil {
+				schemaErrorList = append(schemaErrorList, SchemaError{toothPointer, err.Error()})
+			}
+			continue
+		}
+
+		for i, versionMatchList := range versionRange.([]interface{}) {
+			for j, versionMatch := range versionMatchList.([]interface{}) {
+				if _, err := versionmatch.NewFromString(versionMatch.(string)); err != nil {
+					schemaErrorList = append(schemaErrorList, SchemaError{
+						toothPointer + "/" + strconv.Itoa(i) + "/" + strconv.Itoa(j), err.Error()})
+				}
+			}
+		}
+	}
+
+	return schemaErrorList
+}
+
+// escapePointerToken escapes a token of a JSON pointer.
+func escapePointerToken(token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	return strings.ReplaceAll(token, "/", "~1")
+}
+
 // NewFromJSON decodes a JSON byte array into a Metadata struct.
 func NewFromJSON(jsonData []byte) (Metadata, error) {
+	// Check the minimum Lip version before validating the JSON schema, since
+	// tooths requiring a newer Lip may use fields unknown to this Lip.
+	err := checkLipVersionOfJSON(jsonData, lipcontext.Version)
+	if err != nil {
+		return Metadata{}, err
+	}
+
 	// Validate JSON schema.
 	schemaLoader := gojsonschema.NewStringLoader(jsonSchema)
 	documentLoader := gojsonschema.NewBytesLoader(jsonData)
@@ -292,34 +515,74 @@ func NewFromJSON(jsonData []byte) (Metadata, error) {
 		return Metadata{}, errors.New("failed to decode JSON into metadata: invalid tooth path: " + metadata.ToothPath)
 	}
 
-	version, err := versions.NewFromString(metadataMap["version"].(string))
-
+	version, isNormalized, err := versions.NewFromLegacyString(metadataMap["version"].(string))
 	if err != nil {
 		return Metadata{}, errors.New("failed to decode JSON into metadata: " + err.Error())
 	}
+	if isNormalized {
+		logger.Warning("Leading zeroes in version " + metadataMap["version"].(string) + " of " + metadata.ToothPath +
+			" are deprecated. It is read as " + version.String() + ".")
+	}
 	metadata.Version = version
 
+	if _, ok := metadataMap["min_lip_version"]; ok {
+		metadata.MinLipVersion, err = versions.NewFromString(metadataMap["min_lip_version"].(string))
+		if err != nil {
+			return Metadata{}, errors.New("failed to decode JSON into metadata: " + err.Error())
+		}
+	}
+
 	metadata.Dependencies = make(map[string]([][]versionmatch.VersionMatch))
 	if _, ok := metadataMap["dependencies"]; ok {
-		for toothPath, versionMatchOuterList := range metadataMap["dependencies"].(map[string]interface{}) {
+		metadata.Dependencies, err = parseVersionRangeMap(metadataMap["dependencies"].(map[string]interface{}))
+		if err != nil {
+			return Metadata{}, errors.New("failed to decode JSON into metadata: " + err.Error())
+		}
+	}
+
+	metadata.OptionalDependencies = make(map[string](map[string]([][]versionmatch.VersionMatch)))
+	if _, ok := metadataMap["optional_dependencies"]; ok {
+		for extra, dependencies := range metadataMap["optional_dependencies"].(map[string]interface{}) {
+			metadata.OptionalDependencies[extra], err = parseVersionRangeMap(dependencies.(map[string]interface{}))
+			if err != nil {
+				return Metadata{}, errors.New("failed to decode JSON into metadata: " + err.Error())
+			}
+		}
+	}
+
+	metadata.Conflicts = make(map[string]([][]versionmatch.VersionMatch))
+	if _, ok := metadataMap["conflicts"]; ok {
+		metadata.Conflicts, err = parseVersionRangeMap(metadataMap["conflicts"].(map[string]interface{}))
+		if err != nil {
+			return Metadata{}, errors.New("failed to decode JSON into metadata: " + err.Error())
+		}
+	}
+
+	metadata.Provides = make(map[string]versions.Version)
+	if _, ok := metadataMap["provides"]; ok {
+		for toothPath, versionString := range metadataMap["provides"].(map[string]interface{}) {
 			// Tooth path should be lower case.
 			toothPath = strings.ToLower(toothPath)
 
-			metadata.Dependencies[toothPath] = make([][]versionmatch.VersionMatch, len(versionMatchOuterList.([]interface{})))
-			for i, versionMatchInnerList := range versionMatchOuterList.([]interface{}) {
-				metadata.Dependencies[toothPath][i] = make([]versionmatch.VersionMatch, len(versionMatchInnerList.([]interface{})))
-				for j, versionMatch := range versionMatchInnerList.([]interface{}) {
-					versionMatch, err := versionmatch.NewFromString(versionMatch.(string))
-					if err != nil {
-						return Metadata{}, errors.New("failed to decode JSON into metadata: " + err.Error())
-					}
-
-					metadata.Dependencies[toothPath][i][j] = versionMatch
-				}
+			version, err := versions.NewFromString(versionString.(string))
+			if err != nil {
+				return Metadata{}, errors.New("failed to decode JSON into metadata: " + err.Error())
 			}
+
+			metadata.Provides[toothPath] = version
 		}
 	}
 
+	if _, ok := metadataMap["replaces"]; ok {
+		metadata.Replaces = make([]string, len(metadataMap["replaces"].([]interface{})))
+		for i, toothPath := range metadataMap["replaces"].([]interface{}) {
+			// Tooth path should be lower case.
+			metadata.Replaces[i] = strings.ToLower(toothPath.(string))
+		}
+	} else {
+		metadata.Replaces = make([]string, 0)
+	}
+
 	if _, ok := metadataMap["information"]; ok {
 		if _, ok := metadataMap["information"].(map[string]interface{})["name"]; ok {
 			metadata.Information.Name = metadataMap["information"].(map[string]interface{})["name"].(string)
@@ -409,16 +672,19 @@ func NewFromJSON(jsonData []byte) (Metadata, error) {
 	}
 
 	if _, ok := metadataMap["tool"]; ok {
-		metadata.Tool.Name = metadataMap["tool"].(map[string]interface{})["name"].(string)
-		metadata.Tool.Description = metadataMap["tool"].(map[string]interface{})["description"].(string)
+		toolMap := metadataMap["tool"].(map[string]interface{})
 
-		metadata.Tool.Entrypoints = make([]ToolEntrypointStruct, len(metadataMap["tool"].(map[string]interface{})["entrypoints"].([]interface{})))
-		for i, entrypoint := range metadataMap["tool"].(map[string]interface{})["entrypoints"].([]interface{}) {
-			metadata.Tool.Entrypoints[i].Path = entrypoint.(map[string]interface{})["path"].(string)
-			metadata.Tool.Entrypoints[i].GOOS = entrypoint.(map[string]interface{})["GOOS"].(string)
+		metadata.Tool.Name = toolMap["name"].(string)
+		metadata.Tool.Description = toolMap["description"].(string)
+		metadata.Tool.Entrypoints = ParseToolEntrypoints(toolMap["entrypoints"].([]interface{}))
 
-			if _, ok := entrypoint.(map[string]interface{})["GOARCH"]; ok {
-				metadata.Tool.Entrypoints[i].GOARCH = entrypoint.(map[string]interface{})["GOARCH"].(string)
+		metadata.Tool.Subcommands = make(map[string]ToolSubcommandStruct)
+		if _, ok := toolMap["subcommands"]; ok {
+			for name, subcommand := range toolMap["subcommands"].(map[string]interface{}) {
+				metadata.Tool.Subcommands[name] = ToolSubcommandStruct{
+					Description: subcommand.(map[string]interface{})["description"].(string),
+					Entrypoints: ParseToolEntrypoints(subcommand.(map[string]interface{})["entrypoints"].([]interface{})),
+				}
 			}
 		}
 	}
@@ -434,17 +700,28 @@ func (metadata Metadata) JSON() ([]byte, error) {
 
 	metadataMap["version"] = metadata.Version.String()
 
-	metadataMap["dependencies"] = make(map[string]interface{})
-	for toothPath, versionMatchOuterList := range metadata.Dependencies {
-		metadataMap["dependencies"].(map[string]interface{})[toothPath] =
-			make([]interface{}, len(versionMatchOuterList))
-		for i, versionMatchInnerList := range versionMatchOuterList {
-			metadataMap["dependencies"].(map[string]interface{})[toothPath].([]interface{})[i] =
-				make([]interface{}, len(versionMatchInnerList))
-			for j, versionMatch := range versionMatchInnerList {
-				metadataMap["dependencies"].(map[string]interface{})[toothPath].([]interface{})[i].([]interface{})[j] = versionMatch.String()
-			}
-		}
+	if !versions.Equal(metadata.MinLipVersion, versions.Version{}) {
+		metadataMap["min_lip_version"] = metadata.MinLipVersion.String()
+	}
+
+	metadataMap["dependencies"] = versionmatch.EncodeRangeMap(metadata.Dependencies)
+
+	optionalDependencyMap := make(map[string]interface{})
+	for extra, dependencies := range metadata.OptionalDependencies {
+		optionalDependencyMap[extra] = versionmatch.EncodeRangeMap(dependencies)
+	}
+	metadataMap["optional_dependencies"] = optionalDependencyMap
+
+	metadataMap["conflicts"] = versionmatch.EncodeRangeMap(metadata.Conflicts)
+
+	metadataMap["provides"] = make(map[string]interface{})
+	for toothPath, version := range metadata.Provides {
+		metadataMap["provides"].(map[string]interface{})[toothPath] = version.String()
+	}
+
+	metadataMap["replaces"] = make([]interface{}, len(metadata.Replaces))
+	for i, toothPath := range metadata.Replaces {
+		metadataMap["replaces"].([]interface{})[i] = toothPath
 	}
 
 	metadataMap["information"] = make(map[string]interface{})
@@ -493,14 +770,16 @@ func (metadata Metadata) JSON() ([]byte, error) {
 	metadataMap["tool"] = make(map[string]interface{})
 	metadataMap["tool"].(map[string]interface{})["name"] = metadata.Tool.Name
 	metadataMap["tool"].(map[string]interface{})["description"] = metadata.Tool.Description
-	metadataMap["tool"].(map[string]interface{})["entrypoints"] = make([]interface{}, len(metadata.Tool.Entrypoints))
-	for i, entrypoint := range metadata.Tool.Entrypoints {
-		metadataMap["tool"].(map[string]interface{})["entrypoints"].([]interface{})[i] = make(map[string]interface{})
-		metadataMap["tool"].(map[string]interface{})["entrypoints"].([]interface{})[i].(map[string]interface{})["path"] = entrypoint.Path
-		metadataMap["tool"].(map[string]interface{})["entrypoints"].([]interface{})[i].(map[string]interface{})["GOOS"] = entrypoint.GOOS
-		if entrypoint.GOARCH != "" {
-			metadataMap["tool"].(map[string]interface{})["entrypoints"].([]interface{})[i].(map[string]interface{})["GOARCH"] = entrypoint.GOARCH
+	metadataMap["tool"].(map[string]interface{})["entrypoints"] = EncodeToolEntrypoints(metadata.Tool.Entrypoints)
+	if len(metadata.Tool.Subcommands) > 0 {
+		subcommandMap := make(map[string]interface{})
+		for name, subcommand := range metadata.Tool.Subcommands {
+			subcommandMap[name] = map[string]interface{}{
+				"description": subcommand.Description,
+				"entrypoints": EncodeToolEntrypoints(subcommand.Entrypoints),
+			}
 		}
+		metadataMap["tool"].(map[string]interface{})["subcommands"] = subcommandMap
 	}
 
 	// Encode metadataMap into JSON
@@ -521,7 +800,238 @@ func (metadata Metadata) JSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// DependenciesWithExtras returns the dependencies of the tooth together with the
+// optional dependencies of the extras. It returns an error if any extra is not
+// declared.
+func (m Metadata) DependenciesWithExtras(extras []string) (map[string]([][]versionmatch.VersionMatch), error) {
+	dependencies := make(map[string]([][]versionmatch.VersionMatch))
+	for toothPath, versionRange := range m.Dependencies {
+		dependencies[toothPath] = versionRange
+	}
+
+	for _, extra := range extras {
+		optionalDependencies, ok := m.OptionalDependencies[extra]
+		if !ok {
+			return nil, errors.New("extra " + extra + " is not declared by " + m.ToothPath)
+		}
+
+		for toothPath, versionRange := range optionalDependencies {
+			// Both the dependency and the optional dependency should be satisfied.
+			if existingVersionRange, ok := dependencies[toothPath]; ok {
+				versionRange = versionmatch.IntersectRanges(existingVersionRange, versionRange)
+			}
+			dependencies[toothPath] = versionRange
+		}
+	}
+
+	return dependencies, nil
+}
+
+// Satisfies returns true if the tooth is or provides the tooth path with a
+// version matching the version range.
+func (m Metadata) Satisfies(toothPath string, versionRange [][]versionmatch.VersionMatch) bool {
+	return toothutils.Satisfies(m.ToothPath, m.Version, m.Provides, toothPath, versionRange)
+}
+
+// LipVersionError is returned when a tooth requires a newer version of Lip.
+type LipVersionError struct {
+	ToothPath     string
+	MinLipVersion versions.Version
+	LipVersion    versions.Version
+}
+
+func (e *LipVersionError) Error() string {
+	return e.ToothPath + " requires Lip " + e.MinLipVersion.String() + " or later, but Lip " +
+		e.LipVersion.String() + " is running. Run lip self update to update Lip"
+}
+
+// CheckLipVersion returns a *LipVersionError if the version of Lip is lower
+// than the minimum Lip version required by the tooth. Development builds of
+// Lip, whose version is 0.0.0, are never rejected.
+func (m Metadata) CheckLipVersion(lipVersion versions.Version) error {
+	if versions.Equal(lipVersion, versions.Version{}) {
+		return nil
+	}
+
+	if versions.LessThan(lipVersion, m.MinLipVersion) {
+		return &LipVersionError{m.ToothPath, m.MinLipVersion, lipVersion}
+	}
+
+	return nil
+}
+
+// checkLipVersionOfJSON checks the minimum Lip version in tooth.json without
+// validating the JSON schema. Malformed fields are left to the validation.
+func checkLipVersionOfJSON(jsonData []byte, lipVersion versions.Version) error {
+	var metadataMap struct {
+		ToothPath     string `json:"tooth"`
+		MinLipVersion string `json:"min_lip_version"`
+	}
+	if err := json.Unmarshal(jsonData, &metadataMap); err != nil || metadataMap.MinLipVersion == "" {
+		return nil
+	}
+
+	minLipVersion, err := versions.NewFromString(metadataMap.MinLipVersion)
+	if err != nil {
+		return nil
+	}
+
+	metadata := Metadata{ToothPath: strings.ToLower(metadataMap.ToothPath), MinLipVersion: minLipVersion}
+	return metadata.CheckLipVersion(lipVersion)
+}
+
+// shellMetacharacters are characters special to sh or cmd, which are not
+// allowed in entrypoint paths of tools.
+const shellMetacharacters = "\"!#$%&'()*;<>?[]^`{|}\n\r"
+
+// CheckToolEntrypoints returns an error if any entrypoint path of the tool or
+// its subcommands is rejected by CheckEntrypointPath.
+func (m Metadata) CheckToolEntrypoints() error {
+	entrypoints := append([]ToolEntrypointStruct{}, m.Tool.Entrypoints...)
+	for _, subcommand := range m.Tool.Subcommands {
+		entrypoints = append(entrypoints, subcommand.Entrypoints...)
+	}
+
+	for _, entrypoint := range entrypoints {
+		err := CheckEntrypointPath(entrypoint.Path)
+		if err != nil {
+			return errors.New("invalid tool entrypoint of " + m.ToothPath + ": " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// CheckEntrypointPath returns an error if an entrypoint path of a tool is
+// absolute, contains ".." segments or contains characters special to sh or
+// cmd. Entrypoints are run by shims, so they must stay in the workspace.
+func CheckEntrypointPath(entrypointPath string) error {
+	slashPath := strings.ReplaceAll(entrypointPath, "\\", "/")
+	if path.IsAbs(slashPath) || filepath.IsAbs(entrypointPath) || filepath.VolumeName(entrypointPath) != "" ||
+		(len(entrypointPath) >= 2 && entrypointPath[1] == ':') {
+		return errors.New(entrypointPath + " is an absolute path")
+	}
+
+	for _, segment := range strings.Split(slashPath, "/") {
+		if segment == ".." {
+			return errors.New(entrypointPath + " contains \"..\"")
+		}
+	}
+
+	if strings.ContainsAny(entrypointPath, shellMetacharacters) {
+		return errors.New(entrypointPath + " contains characters special to shells")
+	}
+
+	return nil
+}
+
 // IsTool returns true if the metadata is for a tool.
 func (m Metadata) IsTool() bool {
 	return m.Tool.Name != ""
 }
+
+// ReplaceVersion replaces the version in tooth.json without changing anything
+// else, so that the formatting and key order of tooth.json are kept.
+func ReplaceVersion(jsonData []byte, version versions.Version) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+
+	// Walk through the tokens of the top-level object to find "version".
+	depth := 0
+	isKey := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, errors.New("cannot find version in tooth.json: " + err.Error())
+		}
+
+		switch token := token.(type) {
+		case json.Delim:
+			if token == '{' || token == '[' {
+				depth++
+			} else {
+				depth--
+			}
+			isKey = depth == 1 && token != '['
+
+		case string:
+			if depth == 1 && isKey && token == "version" {
+				// The value must follow the key.
+				valueToken, err := decoder.Token()
+				if _, ok := valueToken.(string); err != nil || !ok {
+					return nil, errors.New("version in tooth.json is not a string")
+				}
+
+				// Version strings contain no escaped characters, so the value
+				// is the content between the last two quotes.
+				end := int(decoder.InputOffset()) - 1
+				start := bytes.LastIndexByte(jsonData[:end], '"') + 1
+
+				newJSONData := make([]byte, 0, len(jsonData))
+				newJSONData = append(newJSONData, jsonData[:start]...)
+				newJSONData = append(newJSONData, version.String()...)
+				newJSONData = append(newJSONData, jsonData[end:]...)
+
+				return newJSONData, nil
+			}
+
+			if depth == 1 {
+				isKey = !isKey
+			}
+
+		default:
+			if depth == 1 {
+				isKey = !isKey
+			}
+		}
+	}
+}
+
+// parseVersionRangeMap parses a map from tooth paths to version ranges, e.g.
+// the dependencies field of tooth.json. Tooth paths are converted to lower case.
+func parseVersionRangeMap(versionRangeMap map[string]interface{}) (map[string]([][]versionmatch.VersionMatch), error) {
+	parsedVersionRangeMap, err := versionmatch.ParseRangeMap(versionRangeMap)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tooth path should be lower case.
+	result := make(map[string]([][]versionmatch.VersionMatch))
+	for toothPath, versionRange := range parsedVersionRangeMap {
+		result[strings.ToLower(toothPath)] = versionRange
+	}
+
+	return result, nil
+}
+
+// ParseToolEntrypoints parses the entrypoints of a tool or a subcommand decoded
+// from JSON.
+func ParseToolEntrypoints(entrypointList []interface{}) []ToolEntrypointStruct {
+	entrypoints := make([]ToolEntrypointStruct, len(entrypointList))
+	for i, entrypoint := range entrypointList {
+		entrypoints[i].Path = entrypoint.(map[string]interface{})["path"].(string)
+		entrypoints[i].GOOS = entrypoint.(map[string]interface{})["GOOS"].(string)
+
+		if _, ok := entrypoint.(map[string]interface{})["GOARCH"]; ok {
+			entrypoints[i].GOARCH = entrypoint.(map[string]interface{})["GOARCH"].(string)
+		}
+	}
+
+	return entrypoints
+}
+
+// EncodeToolEntrypoints encodes the entrypoints of a tool or a subcommand for
+// JSON.
+func EncodeToolEntrypoints(entrypoints []ToolEntrypointStruct) []interface{} {
+	entrypointList := make([]interface{}, len(entrypoints))
+	for i, entrypoint := range entrypoints {
+		entrypointMap := make(map[string]interface{})
+		entrypointMap["path"] = entrypoint.Path
+		entrypointMap["GOOS"] = entrypoint.GOOS
+		if entrypoint.GOARCH != "" {
+			entrypointMap["GOARCH"] = entrypoint.GOARCH
+		}
+		entrypointList[i] = entrypointMap
+	}
+
+	return entrypointList
+}
@@ -1,8 +1,11 @@
 package toothmetadata
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
+	lipcontext "github.com/liteldev/lip/context"
 	"github.com/liteldev/lip/utils/versions"
 	"github.com/liteldev/lip/utils/versions/versionmatch"
 )
@@ -172,3 +175,483 @@ func TestJSON(t *testing.T) {
 	// Save json
 	t.Log(string(json))
 }
+
+func TestNewFromJSONRelationships(t *testing.T) {
+	// Read test data
+	jsonData := []byte(`
+{
+  "format_version": 1,
+  "tooth": "test.test/test/fork",
+  "version": "1.2.0",
+  "conflicts": {
+    "test.test/test/other": [
+      [
+        ">=1.0.0"
+      ]
+    ]
+  },
+  "provides": {
+    "test.test/test/api": "1.1.0"
+  },
+  "replaces": [
+    "test.test/test/Original"
+  ]
+}
+	`)
+
+	// Test
+	metadata, err := NewFromJSON(jsonData)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Check
+	if len(metadata.Conflicts["test.test/test/other"]) != 1 ||
+		metadata.Conflicts["test.test/test/other"][0][0].String() != ">=1.0.0" {
+		t.Errorf("metadata.Conflicts is not correct")
+	}
+
+	if metadata.Provides["test.test/test/api"].String() != "1.1.0" {
+		t.Errorf("metadata.Provides is not correct")
+	}
+
+	if len(metadata.Replaces) != 1 || metadata.Replaces[0] != "test.test/test/original" {
+		t.Errorf("metadata.Replaces is not correct")
+	}
+
+	versionRange := metadata.Conflicts["test.test/test/other"]
+
+	if !metadata.Satisfies("test.test/test/fork", versionRange) {
+		t.Errorf("metadata.Satisfies is not correct")
+	}
+
+	if !metadata.Satisfies("test.test/test/api", versionRange) {
+		t.Errorf("metadata.Satisfies is not correct")
+	}
+
+	if metadata.Satisfies("test.test/test/original", versionRange) {
+		t.Errorf("metadata.Satisfies is not correct")
+	}
+}
+
+func TestDependenciesWithExtras(t *testing.T) {
+	// Read test data
+	jsonData := []byte(`
+{
+  "format_version": 1,
+  "tooth": "test.test/test/test",
+  "version": "1.0.0",
+  "dependencies": {
+    "test.test/test/depend": [
+      [
+        ">=1.0.0"
+      ]
+    ]
+  },
+  "optional_dependencies": {
+    "web": {
+      "test.test/test/depend": [
+        [
+          "<2.0.0"
+        ]
+      ],
+      "test.test/test/web": [
+        [
+          "1.0.x"
+        ]
+      ]
+    }
+  }
+}
+	`)
+
+	metadata, err := NewFromJSON(jsonData)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Test
+	dependencies, err := metadata.DependenciesWithExtras([]string{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if len(dependencies) != 1 {
+		t.Errorf("dependencies without extras are not correct")
+	}
+
+	dependencies, err = metadata.DependenciesWithExtras([]string{"web"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if len(dependencies) != 2 {
+		t.Errorf("dependencies with extras are not correct")
+	}
+
+	if len(dependencies["test.test/test/depend"]) != 1 ||
+		len(dependencies["test.test/test/depend"][0]) != 2 {
+		t.Errorf("dependencies with extras are not intersected")
+	}
+
+	_, err = metadata.DependenciesWithExtras([]string{"db"})
+	if err == nil {
+		t.Errorf("undeclared extra is accepted")
+	}
+}
+
+func TestNewFromJSONVersionConstraints(t *testing.T) {
+	// Read test data
+	jsonData := []byte(`
+{
+  "format_version": 1,
+  "tooth": "test.test/test/test",
+  "version": "1.0.0",
+  "dependencies": {
+    "test.test/test/a": ">=1.0.0 <2.0.0 || ^3.1",
+    "test.test/test/b": [
+      [
+        "~1.2",
+        "!1.2.5"
+      ],
+      [
+        "2.x"
+      ]
+    ]
+  }
+}
+	`)
+
+	// Test
+	metadata, err := NewFromJSON(jsonData)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Check
+	if versionmatch.RangeString(metadata.Dependencies["test.test/test/a"]) != ">=1.0.0 <2.0.0 || ^3.1.0" {
+		t.Errorf("metadata.Dependencies is not correct: %s",
+			versionmatch.RangeString(metadata.Dependencies["test.test/test/a"]))
+	}
+
+	if versionmatch.RangeString(metadata.Dependencies["test.test/test/b"]) != "~1.2.0 !1.2.5 || 2.x" {
+		t.Errorf("metadata.Dependencies is not correct: %s",
+			versionmatch.RangeString(metadata.Dependencies["test.test/test/b"]))
+	}
+
+	// Invalid constraint strings should be rejected.
+	jsonData = []byte(`
+{
+  "format_version": 1,
+  "tooth": "test.test/test/test",
+  "version": "1.0.0",
+  "dependencies": {
+    "test.test/test/a": ">=1.0.0 ||"
+  }
+}
+	`)
+
+	_, err = NewFromJSON(jsonData)
+	if err == nil {
+		t.Errorf("invalid version constraint is accepted")
+	}
+}
+
+func TestValidateSchemaRangeString(t *testing.T) {
+	testList := []struct {
+		versionRange string
+		isValid      bool
+	}{
+		{">=1.0.0 <2.0.0 || ^3.1", true},
+		{"~1.2 !1.2.5", true},
+		{"1.0.0 - 2.0", true},
+		{"1.x || 2.0.* || *", true},
+		{"1.2.3-beta.1+build", true},
+		{"", false},
+		{">=1.0.0 ||", false},
+		{"1.2.x.x", false},
+		{"1.x.x.x", false},
+		{"latest", false},
+		{">=1.0.0 - 2.0.0", false},
+		{"!1.0.0", true},
+		{"!1", false},
+	}
+
+	for index, test := range testList {
+		jsonData := []byte(`{
+    "format_version": 1,
+    "tooth": "test.test/test/test",
+    "version": "1.0.0",
+    "dependencies": {
+        "test.test/test/a": "` + test.versionRange + `"
+    }
+}`)
+
+		schemaErrorList, err := ValidateSchema(jsonData)
+		if err != nil {
+			t.Fatalf("error at test %d: %s", index, err.Error())
+		}
+
+		if isValid := len(schemaErrorList) == 0; isValid != test.isValid {
+			t.Errorf("wrong result at test %d: %s: %v", index, test.versionRange, schemaErrorList)
+		}
+
+		// The schema accepts what NewFromJSON accepts.
+		_, err = NewFromJSON(jsonData)
+		if isValid := err == nil; isValid != test.isValid {
+			t.Errorf("wrong result of NewFromJSON at test %d: %s: %v", index, test.versionRange, err)
+		}
+	}
+
+	// Invalid version matches in lists are reported with their pointers.
+	schemaErrorList, err := ValidateSchema([]byte(`{
+    "format_version": 1,
+    "tooth": "test.test/test/test",
+    "version": "1.0.0",
+    "optional_dependencies": {
+        "web": {
+            "test.test/test/a": [[">=1.0.0", "!1"]]
+        }
+    }
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schemaErrorList) != 1 || schemaErrorList[0].Pointer != "/optional_dependencies/web/test.test~1test~1a/0/1" {
+		t.Errorf("wrong errors of version matches in lists: %v", schemaErrorList)
+	}
+}
+
+func TestNewFromJSONLegacyVersion(t *testing.T) {
+	// Versions with leading zeroes were accepted by older versions of Lip.
+	jsonData := []byte(`
+{
+  "format_version": 1,
+  "tooth": "test.test/test/test",
+  "version": "1.02.0-beta.01",
+  "dependencies": {
+    "test.test/test/a": [
+      [
+        ">=1.01.0"
+      ]
+    ]
+  }
+}
+	`)
+
+	metadata, err := NewFromJSON(jsonData)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if metadata.Version.String() != "1.2.0-beta.1" {
+		t.Errorf("metadata.Version is not correct: %s", metadata.Version.String())
+	}
+
+	if versionmatch.RangeString(metadata.Dependencies["test.test/test/a"]) != ">=1.1.0" {
+		t.Errorf("metadata.Dependencies is not correct: %s",
+			versionmatch.RangeString(metadata.Dependencies["test.test/test/a"]))
+	}
+}
+
+func TestReplaceVersion(t *testing.T) {
+	// Read test data
+	jsonData := []byte(`{
+	"format_version": 1,
+	"tooth": "test.test/test/test",
+	"dependencies": {"test.test/test/a": [["1.0.0"]], "version": "1.0.0"},
+	"information": {"name": "version", "version": "1.0.0"},
+	"placement": [{"source": "version", "destination": "version"}],
+	"version"  :  "1.0.0-beta.1",
+	"possession": []
+}
+`)
+
+	// Test
+	version, _ := versions.NewFromString("1.0.0")
+	newJSONData, err := ReplaceVersion(jsonData, version)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Check
+	expected := strings.Replace(string(jsonData), `"version"  :  "1.0.0-beta.1"`, `"version"  :  "1.0.0"`, 1)
+	if string(newJSONData) != expected {
+		t.Errorf("ReplaceVersion() = %s, want %s", newJSONData, expected)
+	}
+
+	if _, err := ReplaceVersion([]byte(`{"format_version": 1}`), version); err == nil {
+		t.Errorf("ReplaceVersion() without version should fail")
+	}
+}
+
+func TestNewFromJSONToolSubcommands(t *testing.T) {
+	// Read test data
+	jsonData := []byte(`
+{
+  "format_version": 1,
+  "tooth": "test.test/test/test",
+  "version": "1.0.0",
+  "tool": {
+    "name": "test",
+    "description": "test description",
+    "entrypoints": [
+      {
+        "path": "test.sh",
+        "GOOS": "linux"
+      }
+    ],
+    "subcommands": {
+      "build": {
+        "description": "build description",
+        "entrypoints": [
+          {
+            "path": "build.sh",
+            "GOOS": "linux",
+            "GOARCH": "amd64"
+          }
+        ]
+      }
+    }
+  }
+}
+	`)
+
+	// Test
+	metadata, err := NewFromJSON(jsonData)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Check
+	subcommand, ok := metadata.Tool.Subcommands["build"]
+	if !ok {
+		t.Fatalf("subcommand build not found")
+	}
+	if subcommand.Description != "build description" || len(subcommand.Entrypoints) != 1 ||
+		subcommand.Entrypoints[0].Path != "build.sh" || subcommand.Entrypoints[0].GOARCH != "amd64" {
+		t.Errorf("unexpected subcommand: %v", subcommand)
+	}
+
+	// Subcommands are kept when encoding.
+	json, err := metadata.JSON()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if !strings.Contains(string(json), `"build.sh"`) {
+		t.Errorf("subcommands are lost when encoding: %s", json)
+	}
+
+	// Subcommand names are validated.
+	_, err = NewFromJSON([]byte(strings.Replace(string(jsonData), `"build":`, `"Build:":`, 1)))
+	if err == nil {
+		t.Errorf("invalid subcommand name is accepted")
+	}
+}
+
+func TestCheckLipVersion(t *testing.T) {
+	// Read test data
+	jsonData := []byte(`
+{
+  "format_version": 1,
+  "tooth": "test.test/test/test",
+  "version": "1.0.0",
+  "min_lip_version": "0.14.0"
+}
+	`)
+
+	metadata, err := NewFromJSON(jsonData)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Test
+	for _, testCase := range []struct {
+		lipVersion string
+		isOK       bool
+	}{
+		{"0.13.0", false},
+		{"0.14.0-beta.1", false},
+		{"0.14.0", true},
+		{"1.0.0", true},
+		{"0.0.0", true}, // Development build
+	} {
+		lipVersion, _ := versions.NewFromString(testCase.lipVersion)
+		err := metadata.CheckLipVersion(lipVersion)
+		if (err == nil) != testCase.isOK {
+			t.Errorf("CheckLipVersion(%s) = %v", testCase.lipVersion, err)
+		}
+	}
+
+	// Tooths without min_lip_version accept any Lip.
+	metadata.MinLipVersion = versions.Version{}
+	lipVersion, _ := versions.NewFromString("0.1.0")
+	if err := metadata.CheckLipVersion(lipVersion); err != nil {
+		t.Errorf("CheckLipVersion(0.1.0) = %v without min_lip_version", err)
+	}
+}
+
+func TestNewFromJSONRequiresNewerLip(t *testing.T) {
+	// Read test data with a field unknown to this Lip.
+	jsonData := []byte(`
+{
+  "format_version": 1,
+  "tooth": "test.test/test/test",
+  "version": "1.0.0",
+  "min_lip_version": "99.0.0",
+  "unknown_field": true
+}
+	`)
+
+	previousVersion := lipcontext.Version
+	defer func() { lipcontext.Version = previousVersion }()
+	lipcontext.Version, _ = versions.NewFromString("0.14.0")
+
+	// Test
+	_, err := NewFromJSON(jsonData)
+	var lipVersionErr *LipVersionError
+	if !errors.As(err, &lipVersionErr) {
+		t.Fatalf("NewFromJSON() = %v, want a *LipVersionError", err)
+	}
+	if !strings.Contains(err.Error(), "requires Lip 99.0.0 or later") {
+		t.Errorf("wrong error message: %s", err.Error())
+	}
+
+	// A Lip new enough reports the unknown field.
+	lipcontext.Version, _ = versions.NewFromString("99.0.0")
+	_, err = NewFromJSON(jsonData)
+	if err == nil || errors.As(err, &lipVersionErr) {
+		t.Errorf("NewFromJSON() = %v, want a schema error", err)
+	}
+}
+
+func TestCheckToolEntrypoints(t *testing.T) {
+	for _, testCase := range []struct {
+		path string
+		isOK bool
+	}{
+		{"bin/tool", true},
+		{"bin/my tool.exe", true},
+		{"bin\\tool.exe", true},
+		{"/usr/bin/tool", false},
+		{"C:\\tool.exe", false},
+		{"../tool", false},
+		{"bin/../../tool", false},
+		{"bin/tool; rm -rf ~", false},
+		{"bin/$(id)", false},
+		{"bin/it's", false},
+		{"bin/100%", false},
+	} {
+		metadata := Metadata{ToothPath: "test.test/test/test"}
+		metadata.Tool.Name = "tool"
+		metadata.Tool.Subcommands = map[string]ToolSubcommandStruct{
+			"sub": {Entrypoints: []ToolEntrypointStruct{{Path: testCase.path, GOOS: "linux"}}},
+		}
+
+		err := metadata.CheckToolEntrypoints()
+		if (err == nil) != testCase.isOK {
+			t.Errorf("CheckToolEntrypoints() = %v for %s", err, testCase.path)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package toothpack
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileName is the name of the file listing files not to pack.
+const IgnoreFileName = ".toothignore"
+
+// ignoreRule is a rule in .toothignore.
+type ignoreRule struct {
+	reg        *regexp.Regexp
+	isNegated  bool
+	isDirOnly  bool
+	isAnchored bool
+}
+
+// IgnoreMatcher matches paths against the rules in .toothignore. The syntax is
+// a subset of .gitignore: blank lines and lines beginning with "#" are
+// skipped, "!" negates a rule, a trailing "/" matches only directories, a rule
+// containing "/" elsewhere is relative to the tooth root, "*" and "?" do not
+// match "/", and "**" matches any number of directories. The last matching rule
+// wins.
+type IgnoreMatcher struct {
+	ruleList []ignoreRule
+}
+
+// NewIgnoreMatcher creates an ignore matcher from the content of .toothignore.
+func NewIgnoreMatcher(content []byte) IgnoreMatcher {
+	matcher := IgnoreMatcher{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{}
+
+		if strings.HasPrefix(line, "!") {
+			rule.isNegated = true
+			line = line[1:]
+		}
+
+		if strings.HasSuffix(line, "/") {
+			rule.isDirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+
+		if strings.Contains(line, "/") {
+			rule.isAnchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+
+		if line == "" {
+			continue
+		}
+
+		reg, err := regexp.Compile("^" + globToRegexp(line) + "$")
+		if err != nil {
+			continue
+		}
+		rule.reg = reg
+
+		matcher.ruleList = append(matcher.ruleList, rule)
+	}
+
+	return matcher
+}
+
+// NewIgnoreMatcherFromDir creates an ignore matcher from .toothignore in the
+// directory. If there is no .toothignore, the matcher ignores nothing.
+func NewIgnoreMatcherFromDir(dirPath string) (IgnoreMatcher, error) {
+	content, err := os.ReadFile(filepath.Join(dirPath, IgnoreFileName))
+	if os.IsNotExist(err) {
+		return IgnoreMatcher{}, nil
+	} else if err != nil {
+		return IgnoreMatcher{}, err
+	}
+
+	return NewIgnoreMatcher(content), nil
+}
+
+// IsIgnored returns true if the path relative to the tooth root, separated by
+// slashes, should be ignored.
+func (m IgnoreMatcher) IsIgnored(relPath string, isDir bool) bool {
+	isIgnored := false
+
+	for _, rule := range m.ruleList {
+		if rule.isDirOnly && !isDir {
+			continue
+		}
+
+		target := relPath
+		if !rule.isAnchored {
+			target = path.Base(relPath)
+		}
+
+		if rule.reg.MatchString(target) {
+			isIgnored = !rule.isNegated
+		}
+	}
+
+	return isIgnored
+}
+
+// globToRegexp converts a glob pattern to a regular expression.
+func globToRegexp(pattern string) string {
+	var builder strings.Builder
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			builder.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "/**") && i+3 == len(pattern):
+			builder.WriteString("(/.*)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			builder.WriteString(".*")
+			i++
+		case c == '*':
+			builder.WriteString("[^/]*")
+		case c == '?':
+			builder.WriteString("[^/]")
+		case c == '[':
+			end := strings.Index(pattern[i:], "]")
+			if end == -1 {
+				builder.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			builder.WriteString("[" + class + "]")
+			i += end
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return builder.String()
+}
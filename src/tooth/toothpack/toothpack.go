@@ -0,0 +1,237 @@
+// Package toothpack builds .tth files from tooth directories.
+package toothpack
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/liteldev/lip/tooth/toothmetadata"
+)
+
+// fixedModifiedTime is the modified time of all entries in packed archives, so
+// that identical inputs give byte-identical archives. It is the earliest time
+// representable in the MS-DOS format used by zip.
+var fixedModifiedTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ReadMetadata reads and validates tooth.json in the tooth directory.
+func ReadMetadata(dirPath string) (toothmetadata.Metadata, error) {
+	content, err := os.ReadFile(filepath.Join(dirPath, "tooth.json"))
+	if err != nil {
+		return toothmetadata.Metadata{}, errors.New("cannot read tooth.json in " + dirPath + ": " + err.Error())
+	}
+
+	metadata, err := toothmetadata.NewFromJSON(content)
+	if err != nil {
+		return toothmetadata.Metadata{}, errors.New("invalid tooth.json: " + err.Error())
+	}
+
+	return metadata, nil
+}
+
+// ListFiles lists files to pack in the tooth directory, relative to the
+// directory and separated by slashes, in ascending order. Files ignored by
+// .toothignore, the .git and .lip directories and the excluded paths are
+// skipped. tooth.json is never ignored.
+func ListFiles(dirPath string, excludedPathList ...string) ([]string, error) {
+	matcher, err := NewIgnoreMatcherFromDir(dirPath)
+	if err != nil {
+		return nil, errors.New("cannot read " + IgnoreFileName + ": " + err.Error())
+	}
+
+	excludedPathMap := make(map[string]bool)
+	for _, excludedPath := range excludedPathList {
+		absPath, err := filepath.Abs(excludedPath)
+		if err != nil {
+			continue
+		}
+		excludedPathMap[absPath] = true
+	}
+
+	fileList := make([]string, 0)
+	err = filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(dirPath, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if absPath, err := filepath.Abs(path); err == nil && excludedPathMap[absPath] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			if relPath == ".git" || relPath == ".lip" || matcher.IsIgnored(relPath, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Only regular files are packed.
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		if relPath != "tooth.json" && matcher.IsIgnored(relPath, false) {
+			return nil
+		}
+
+		fileList = append(fileList, relPath)
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New("cannot list files in " + dirPath + ": " + err.Error())
+	}
+
+	sort.Strings(fileList)
+
+	return fileList, nil
+}
+
+// VerifyPlacements checks that the source of every placement exists in the
+// file list. Wildcard placements must match at least one file.
+func VerifyPlacements(metadata toothmetadata.Metadata, fileList []string) error {
+	fileMap := make(map[string]bool)
+	for _, file := range fileList {
+		fileMap[file] = true
+	}
+
+	missingList := make([]string, 0)
+	for _, placement := range metadata.Placement {
+		// Wildcard placements are expanded in the same way as when installing.
+		if strings.HasSuffix(placement.Source, "*") && strings.HasSuffix(placement.Destination, "*") {
+			prefix := strings.TrimSuffix(placement.Source, "*")
+
+			isMatched := false
+			for _, file := range fileList {
+				if strings.HasPrefix(file, prefix) {
+					isMatched = true
+					break
+				}
+			}
+
+			if !isMatched {
+				missingList = append(missingList, placement.Source)
+			}
+
+			continue
+		}
+
+		if !fileMap[placement.Source] {
+			missingList = append(missingList, placement.Source)
+		}
+	}
+
+	if len(missingList) > 0 {
+		return errors.New("placement sources not found: " + strings.Join(missingList, ", "))
+	}
+
+	return nil
+}
+
+// Pack validates the tooth directory and writes its files to a deterministic
+// .tth file: entries are sorted and have fixed timestamps, so identical inputs
+// give byte-identical output. It returns the list of packed files.
+func Pack(dirPath string, outputPath string) ([]string, error) {
+	metadata, err := ReadMetadata(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	fileList, err := ListFiles(dirPath, outputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	err = VerifyPlacements(metadata, fileList)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write to a temporary file first to avoid leaving broken archives.
+	tempFilePath := outputPath + ".tmp"
+	err = writeArchive(dirPath, fileList, tempFilePath)
+	if err != nil {
+		os.Remove(tempFilePath)
+		return nil, err
+	}
+
+	err = os.Rename(tempFilePath, outputPath)
+	if err != nil {
+		os.Remove(tempFilePath)
+		return nil, errors.New("cannot move archive to " + outputPath + ": " + err.Error())
+	}
+
+	return fileList, nil
+}
+
+// writeArchive writes the files to a zip archive.
+func writeArchive(dirPath string, fileList []string, outputPath string) error {
+	fw, err := os.Create(outputPath)
+	if err != nil {
+		return errors.New("cannot create " + outputPath + ": " + err.Error())
+	}
+	defer fw.Close()
+
+	zipWriter := zip.NewWriter(fw)
+
+	for _, file := range fileList {
+		fileInfo, err := os.Stat(filepath.Join(dirPath, filepath.FromSlash(file)))
+		if err != nil {
+			return errors.New("cannot access " + file + ": " + err.Error())
+		}
+
+		header := &zip.FileHeader{
+			Name:     file,
+			Method:   zip.Deflate,
+			Modified: fixedModifiedTime,
+		}
+
+		// Only keep the executable bit of file modes.
+		if fileInfo.Mode()&0111 != 0 {
+			header.SetMode(0755)
+		} else {
+			header.SetMode(0644)
+		}
+
+		entryWriter, err := zipWriter.CreateHeader(header)
+		if err != nil {
+			return errors.New("cannot add " + file + " to archive: " + err.Error())
+		}
+
+		fr, err := os.Open(filepath.Join(dirPath, filepath.FromSlash(file)))
+		if err != nil {
+			return errors.New("cannot open " + file + ": " + err.Error())
+		}
+
+		_, err = io.Copy(entryWriter, fr)
+		fr.Close()
+		if err != nil {
+			return errors.New("cannot add " + file + " to archive: " + err.Error())
+		}
+	}
+
+	err = zipWriter.Close()
+	if err != nil {
+		return errors.New("cannot write archive: " + err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package toothpack
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	matcher := NewIgnoreMatcher([]byte(`
+# Comment
+*.log
+!keep.log
+build/
+/docs/*.md
+**/temp/**
+src/**/*.bak
+`))
+
+	testList := []struct {
+		path   string
+		isDir  bool
+		output bool
+	}{
+		{"a.log", false, true},
+		{"sub/a.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"sub/build", true, true},
+		{"build", false, false},
+		{"docs/readme.md", false, true},
+		{"docs/sub/readme.md", false, false},
+		{"sub/docs/readme.md", false, false},
+		{"temp/a.txt", false, true},
+		{"sub/temp/a.txt", false, true},
+		{"src/a.bak", false, true},
+		{"src/sub/a.bak", false, true},
+		{"a.bak", false, false},
+		{"tooth.json", false, false},
+	}
+
+	for index, test := range testList {
+		if matcher.IsIgnored(test.path, test.isDir) != test.output {
+			t.Errorf("wrong output at test %d: %s", index, test.path)
+		}
+	}
+}
+
+func TestPack(t *testing.T) {
+	dirPath := t.TempDir()
+
+	fileMap := map[string]string{
+		"tooth.json": `{
+    "format_version": 1,
+    "tooth": "example.com/test/test",
+    "version": "1.0.0",
+    "placement": [
+        {
+            "source": "a.txt",
+            "destination": "a.txt"
+        },
+        {
+            "source": "sub/*",
+            "destination": "out/*"
+        }
+    ]
+}`,
+		"a.txt":        "a",
+		"sub/b.txt":    "b",
+		"sub/c.log":    "c",
+		".toothignore": "*.log\n",
+	}
+
+	for file, content := range fileMap {
+		filePath := filepath.Join(dirPath, filepath.FromSlash(file))
+		os.MkdirAll(filepath.Dir(filePath), 0755)
+		err := os.WriteFile(filePath, []byte(content), 0644)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+
+	outputPath := filepath.Join(dirPath, "test.tth")
+	fileList, err := Pack(dirPath, outputPath)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if strings.Join(fileList, " ") != ".toothignore a.txt sub/b.txt tooth.json" {
+		t.Errorf("wrong file list: %v", fileList)
+	}
+
+	firstContent, _ := os.ReadFile(outputPath)
+
+	// Packing again should give byte-identical output.
+	_, err = Pack(dirPath, outputPath)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	secondContent, _ := os.ReadFile(outputPath)
+	if !bytes.Equal(firstContent, secondContent) {
+		t.Errorf("packed archives are not identical")
+	}
+
+	// Missing placement sources should be reported.
+	os.Remove(filepath.Join(dirPath, "a.txt"))
+	_, err = Pack(dirPath, outputPath)
+	if err == nil || !strings.Contains(err.Error(), "a.txt") {
+		t.Errorf("missing placement source is not reported")
+	}
+}
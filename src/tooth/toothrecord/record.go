@@ -6,10 +6,14 @@ import (
 	"encoding/json"
 	"errors"
 	"os"
+	"path"
 	"path/filepath"
 
 	"github.com/liteldev/lip/localfile"
 	"github.com/liteldev/lip/tooth/toothmetadata"
+	"github.com/liteldev/lip/tooth/toothutils"
+	"github.com/liteldev/lip/utils/logger"
+	"github.com/liteldev/lip/utils/platform"
 	"github.com/liteldev/lip/utils/versions"
 	"github.com/liteldev/lip/utils/versions/versionmatch"
 )
@@ -51,26 +55,46 @@ type ToolStruct struct {
 	Name        string
 	Description string
 	Entrypoints []ToolEntrypointStruct
+	Subcommands map[string]ToolSubcommandStruct
 }
 
-type ToolEntrypointStruct struct {
-	Path   string
-	GOOS   string
-	GOARCH string
+// ToolSubcommandStruct is a named subcommand of a tool, run by lip exec
+// <tool>:<subcommand>.
+type ToolSubcommandStruct struct {
+	Description string
+	Entrypoints []ToolEntrypointStruct
 }
 
+// ToolEntrypointStruct is the same as in tooth.json, so that entrypoints are
+// parsed and encoded in the same way.
+type ToolEntrypointStruct = toothmetadata.ToolEntrypointStruct
+
 // Record is the struct that contains the record of a tooth installation.
 type Record struct {
-	ToothPath           string
-	Version             versions.Version
-	Dependencies        map[string]([][]versionmatch.VersionMatch)
-	Information         InfoStruct
-	Placement           []PlacementStruct
-	Possession          []string
-	Commands            []CommandStruct
-	Confirmation        []ConfirmationStruct
-	Tool                ToolStruct
-	IsManuallyInstalled bool
+	ToothPath            string
+	Version              versions.Version
+	Dependencies         map[string]([][]versionmatch.VersionMatch)
+	OptionalDependencies map[string](map[string]([][]versionmatch.VersionMatch))
+	Conflicts            map[string]([][]versionmatch.VersionMatch)
+	Provides             map[string]versions.Version
+	Replaces             []string
+	Information          InfoStruct
+	Placement            []PlacementStruct
+	Possession           []string
+	Commands             []CommandStruct
+	Confirmation         []ConfirmationStruct
+	Tool                 ToolStruct
+	IsManuallyInstalled  bool
+	Extras               []string
+	GitURL               string
+	GitCommit            string
+	IsEditable           bool
+	SourceDir            string
+	TargetOS             string
+	TargetArch           string
+	// IsCommandDeferred is true if the install commands were not run because
+	// the tooth was installed for another platform.
+	IsCommandDeferred bool
 }
 
 // New creates a new Record struct from a tooth path.
@@ -119,29 +143,62 @@ func NewFromJSON(jsonData []byte) (Record, error) {
 
 	record.ToothPath = recordMap["tooth"].(string)
 
-	version, err := versions.NewFromString(recordMap["version"].(string))
-
+	version, isNormalized, err := versions.NewFromLegacyString(recordMap["version"].(string))
 	if err != nil {
 		return Record{}, errors.New("failed to decode JSON into record: " + err.Error())
 	}
+	if isNormalized {
+		logger.Warning("Leading zeroes in version " + recordMap["version"].(string) + " of " + record.ToothPath +
+			" are deprecated. It is read as " + version.String() + ".")
+	}
 	record.Version = version
 
-	record.Dependencies = make(map[string]([][]versionmatch.VersionMatch))
-	for toothPath, versionMatchOuterList := range recordMap["dependencies"].(map[string]interface{}) {
-		record.Dependencies[toothPath] = make([][]versionmatch.VersionMatch, len(versionMatchOuterList.([]interface{})))
-		for i, versionMatchInnerList := range versionMatchOuterList.([]interface{}) {
-			record.Dependencies[toothPath][i] = make([]versionmatch.VersionMatch, len(versionMatchInnerList.([]interface{})))
-			for j, versionMatch := range versionMatchInnerList.([]interface{}) {
-				versionMatch, err := versionmatch.NewFromString(versionMatch.(string))
-				if err != nil {
-					return Record{}, errors.New("failed to decode JSON into record: " + err.Error())
-				}
+	record.Dependencies, err = versionmatch.ParseRangeMap(recordMap["dependencies"].(map[string]interface{}))
+	if err != nil {
+		return Record{}, errors.New("failed to decode JSON into record: " + err.Error())
+	}
 
-				record.Dependencies[toothPath][i][j] = versionMatch
+	// Records written by older versions of Lip may not contain optional
+	// dependencies, conflicts, provides, replaces and extras.
+	record.OptionalDependencies = make(map[string](map[string]([][]versionmatch.VersionMatch)))
+	if _, ok := recordMap["optional_dependencies"]; ok {
+		for extra, dependencies := range recordMap["optional_dependencies"].(map[string]interface{}) {
+			record.OptionalDependencies[extra], err = versionmatch.ParseRangeMap(dependencies.(map[string]interface{}))
+			if err != nil {
+				return Record{}, errors.New("failed to decode JSON into record: " + err.Error())
 			}
 		}
 	}
 
+	record.Conflicts = make(map[string]([][]versionmatch.VersionMatch))
+	if _, ok := recordMap["conflicts"]; ok {
+		record.Conflicts, err = versionmatch.ParseRangeMap(recordMap["conflicts"].(map[string]interface{}))
+		if err != nil {
+			return Record{}, errors.New("failed to decode JSON into record: " + err.Error())
+		}
+	}
+
+	record.Provides = make(map[string]versions.Version)
+	if _, ok := recordMap["provides"]; ok {
+		for toothPath, versionString := range recordMap["provides"].(map[string]interface{}) {
+			version, err := versions.NewFromString(versionString.(string))
+			if err != nil {
+				return Record{}, errors.New("failed to decode JSON into record: " + err.Error())
+			}
+
+			record.Provides[toothPath] = version
+		}
+	}
+
+	if _, ok := recordMap["replaces"]; ok {
+		record.Replaces = make([]string, len(recordMap["replaces"].([]interface{})))
+		for i, toothPath := range recordMap["replaces"].([]interface{}) {
+			record.Replaces[i] = toothPath.(string)
+		}
+	} else {
+		record.Replaces = make([]string, 0)
+	}
+
 	record.Information.Name = recordMap["information"].(map[string]interface{})["name"].(string)
 	record.Information.Description = recordMap["information"].(map[string]interface{})["description"].(string)
 	record.Information.Author = recordMap["information"].(map[string]interface{})["author"].(string)
@@ -200,22 +257,62 @@ func NewFromJSON(jsonData []byte) (Record, error) {
 	}
 
 	if _, ok := recordMap["tool"]; ok {
-		record.Tool.Name = recordMap["tool"].(map[string]interface{})["name"].(string)
-		record.Tool.Description = recordMap["tool"].(map[string]interface{})["description"].(string)
-
-		record.Tool.Entrypoints = make([]ToolEntrypointStruct, len(recordMap["tool"].(map[string]interface{})["entrypoints"].([]interface{})))
-		for i, entrypoint := range recordMap["tool"].(map[string]interface{})["entrypoints"].([]interface{}) {
-			record.Tool.Entrypoints[i].Path = entrypoint.(map[string]interface{})["path"].(string)
-			record.Tool.Entrypoints[i].GOOS = entrypoint.(map[string]interface{})["GOOS"].(string)
-
-			if _, ok := entrypoint.(map[string]interface{})["GOARCH"]; ok {
-				record.Tool.Entrypoints[i].GOARCH = entrypoint.(map[string]interface{})["GOARCH"].(string)
+		toolMap := recordMap["tool"].(map[string]interface{})
+
+		record.Tool.Name = toolMap["name"].(string)
+		record.Tool.Description = toolMap["description"].(string)
+		record.Tool.Entrypoints = toothmetadata.ParseToolEntrypoints(toolMap["entrypoints"].([]interface{}))
+
+		record.Tool.Subcommands = make(map[string]ToolSubcommandStruct)
+		if _, ok := toolMap["subcommands"]; ok {
+			for name, subcommand := range toolMap["subcommands"].(map[string]interface{}) {
+				record.Tool.Subcommands[name] = ToolSubcommandStruct{
+					Description: subcommand.(map[string]interface{})["description"].(string),
+					Entrypoints: toothmetadata.ParseToolEntrypoints(subcommand.(map[string]interface{})["entrypoints"].([]interface{})),
+				}
 			}
 		}
 	}
 
 	record.IsManuallyInstalled = recordMap["is_manually_installed"].(bool)
 
+	if _, ok := recordMap["extras"]; ok {
+		record.Extras = make([]string, len(recordMap["extras"].([]interface{})))
+		for i, extra := range recordMap["extras"].([]interface{}) {
+			record.Extras[i] = extra.(string)
+		}
+	} else {
+		record.Extras = make([]string, 0)
+	}
+
+	if _, ok := recordMap["git_url"]; ok {
+		record.GitURL = recordMap["git_url"].(string)
+	}
+
+	if _, ok := recordMap["git_commit"]; ok {
+		record.GitCommit = recordMap["git_commit"].(string)
+	}
+
+	if _, ok := recordMap["is_editable"]; ok {
+		record.IsEditable = recordMap["is_editable"].(bool)
+	}
+
+	if _, ok := recordMap["source_dir"]; ok {
+		record.SourceDir = recordMap["source_dir"].(string)
+	}
+
+	if _, ok := recordMap["target_os"]; ok {
+		record.TargetOS = recordMap["target_os"].(string)
+	}
+
+	if _, ok := recordMap["target_arch"]; ok {
+		record.TargetArch = recordMap["target_arch"].(string)
+	}
+
+	if _, ok := recordMap["is_command_deferred"]; ok {
+		record.IsCommandDeferred = recordMap["is_command_deferred"].(bool)
+	}
+
 	return record, nil
 }
 
@@ -229,6 +326,15 @@ func NewFromMetadata(metadata toothmetadata.Metadata, isManuallyInstalled bool)
 
 	record.Dependencies = metadata.Dependencies
 
+	record.OptionalDependencies = metadata.OptionalDependencies
+
+	record.Conflicts = metadata.Conflicts
+
+	record.Provides = metadata.Provides
+
+	record.Replaces = make([]string, len(metadata.Replaces))
+	copy(record.Replaces, metadata.Replaces)
+
 	record.Information.Name = metadata.Information.Name
 	record.Information.Description = metadata.Information.Description
 	record.Information.Author = metadata.Information.Author
@@ -266,14 +372,21 @@ func NewFromMetadata(metadata toothmetadata.Metadata, isManuallyInstalled bool)
 	record.Tool.Name = metadata.Tool.Name
 	record.Tool.Description = metadata.Tool.Description
 	record.Tool.Entrypoints = make([]ToolEntrypointStruct, len(metadata.Tool.Entrypoints))
-	for i, entrypoint := range metadata.Tool.Entrypoints {
-		record.Tool.Entrypoints[i].Path = entrypoint.Path
-		record.Tool.Entrypoints[i].GOOS = entrypoint.GOOS
-		record.Tool.Entrypoints[i].GOARCH = entrypoint.GOARCH
+	copy(record.Tool.Entrypoints, metadata.Tool.Entrypoints)
+	record.Tool.Subcommands = make(map[string]ToolSubcommandStruct)
+	for name, subcommand := range metadata.Tool.Subcommands {
+		entrypoints := make([]ToolEntrypointStruct, len(subcommand.Entrypoints))
+		copy(entrypoints, subcommand.Entrypoints)
+		record.Tool.Subcommands[name] = ToolSubcommandStruct{
+			Description: subcommand.Description,
+			Entrypoints: entrypoints,
+		}
 	}
 
 	record.IsManuallyInstalled = isManuallyInstalled
 
+	record.Extras = make([]string, 0)
+
 	return record
 }
 
@@ -285,17 +398,24 @@ func (record Record) JSON() ([]byte, error) {
 
 	recordMap["version"] = record.Version.String()
 
-	recordMap["dependencies"] = make(map[string]interface{})
-	for toothPath, versionMatchOuterList := range record.Dependencies {
-		recordMap["dependencies"].(map[string]interface{})[toothPath] =
-			make([]interface{}, len(versionMatchOuterList))
-		for i, versionMatchInnerList := range versionMatchOuterList {
-			recordMap["dependencies"].(map[string]interface{})[toothPath].([]interface{})[i] =
-				make([]interface{}, len(versionMatchInnerList))
-			for j, versionMatch := range versionMatchInnerList {
-				recordMap["dependencies"].(map[string]interface{})[toothPath].([]interface{})[i].([]interface{})[j] = versionMatch.String()
-			}
-		}
+	recordMap["dependencies"] = versionmatch.EncodeRangeMap(record.Dependencies)
+
+	optionalDependencyMap := make(map[string]interface{})
+	for extra, dependencies := range record.OptionalDependencies {
+		optionalDependencyMap[extra] = versionmatch.EncodeRangeMap(dependencies)
+	}
+	recordMap["optional_dependencies"] = optionalDependencyMap
+
+	recordMap["conflicts"] = versionmatch.EncodeRangeMap(record.Conflicts)
+
+	recordMap["provides"] = make(map[string]interface{})
+	for toothPath, version := range record.Provides {
+		recordMap["provides"].(map[string]interface{})[toothPath] = version.String()
+	}
+
+	recordMap["replaces"] = make([]interface{}, len(record.Replaces))
+	for i, toothPath := range record.Replaces {
+		recordMap["replaces"].([]interface{})[i] = toothPath
 	}
 
 	recordMap["information"] = make(map[string]interface{})
@@ -347,18 +467,44 @@ func (record Record) JSON() ([]byte, error) {
 	recordMap["tool"] = make(map[string]interface{})
 	recordMap["tool"].(map[string]interface{})["name"] = record.Tool.Name
 	recordMap["tool"].(map[string]interface{})["description"] = record.Tool.Description
-	recordMap["tool"].(map[string]interface{})["entrypoints"] = make([]interface{}, len(record.Tool.Entrypoints))
-	for i, entrypoint := range record.Tool.Entrypoints {
-		recordMap["tool"].(map[string]interface{})["entrypoints"].([]interface{})[i] = make(map[string]interface{})
-		recordMap["tool"].(map[string]interface{})["entrypoints"].([]interface{})[i].(map[string]interface{})["path"] = entrypoint.Path
-		recordMap["tool"].(map[string]interface{})["entrypoints"].([]interface{})[i].(map[string]interface{})["GOOS"] = entrypoint.GOOS
-		if entrypoint.GOARCH != "" {
-			recordMap["tool"].(map[string]interface{})["entrypoints"].([]interface{})[i].(map[string]interface{})["GOARCH"] = entrypoint.GOARCH
+	recordMap["tool"].(map[string]interface{})["entrypoints"] = toothmetadata.EncodeToolEntrypoints(record.Tool.Entrypoints)
+	if len(record.Tool.Subcommands) > 0 {
+		subcommandMap := make(map[string]interface{})
+		for name, subcommand := range record.Tool.Subcommands {
+			subcommandMap[name] = map[string]interface{}{
+				"description": subcommand.Description,
+				"entrypoints": toothmetadata.EncodeToolEntrypoints(subcommand.Entrypoints),
+			}
 		}
+		recordMap["tool"].(map[string]interface{})["subcommands"] = subcommandMap
 	}
 
 	recordMap["is_manually_installed"] = record.IsManuallyInstalled
 
+	recordMap["extras"] = make([]interface{}, len(record.Extras))
+	for i, extra := range record.Extras {
+		recordMap["extras"].([]interface{})[i] = extra
+	}
+
+	if record.GitURL != "" {
+		recordMap["git_url"] = record.GitURL
+		recordMap["git_commit"] = record.GitCommit
+	}
+
+	if record.IsEditable {
+		recordMap["is_editable"] = true
+		recordMap["source_dir"] = record.SourceDir
+	}
+
+	if record.TargetOS != "" {
+		recordMap["target_os"] = record.TargetOS
+		recordMap["target_arch"] = record.TargetArch
+	}
+
+	if record.IsCommandDeferred {
+		recordMap["is_command_deferred"] = true
+	}
+
 	// Encode recordMap into JSON
 	buf := bytes.NewBuffer([]byte{})
 	encoder := json.NewEncoder(buf)
@@ -377,7 +523,91 @@ func (record Record) JSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// ActiveDependencies returns the dependencies of the tooth together with the
+// optional dependencies of the selected extras.
+func (r Record) ActiveDependencies() map[string]([][]versionmatch.VersionMatch) {
+	dependencies := make(map[string]([][]versionmatch.VersionMatch))
+	for toothPath, versionRange := range r.Dependencies {
+		dependencies[toothPath] = versionRange
+	}
+
+	for _, extra := range r.Extras {
+		for toothPath, versionRange := range r.OptionalDependencies[extra] {
+			if existingVersionRange, ok := dependencies[toothPath]; ok {
+				versionRange = versionmatch.IntersectRanges(existingVersionRange, versionRange)
+			}
+			dependencies[toothPath] = versionRange
+		}
+	}
+
+	return dependencies
+}
+
+// Satisfies returns true if the tooth is or provides the tooth path with a
+// version matching the version range.
+func (r Record) Satisfies(toothPath string, versionRange [][]versionmatch.VersionMatch) bool {
+	return toothutils.Satisfies(r.ToothPath, r.Version, r.Provides, toothPath, versionRange)
+}
+
 // IsTool returns true if the record is a tool.
 func (r Record) IsTool() bool {
 	return r.Tool.Name != ""
 }
+
+// ToolOwner returns the owner of the tool, i.e. the element of the tooth path
+// before the last one. For example, the owner of github.com/tooth-hub/foo is
+// tooth-hub.
+func (r Record) ToolOwner() string {
+	owner := path.Base(path.Dir(r.ToothPath))
+	if owner == "." || owner == "/" {
+		return r.ToothPath
+	}
+
+	return owner
+}
+
+// QualifiedToolName returns the name of the tool in the form of owner/tool,
+// which tells tools with the same name apart.
+func (r Record) QualifiedToolName() string {
+	return r.ToolOwner() + "/" + r.Tool.Name
+}
+
+// MatchToolName returns true if the name refers to the tool, either as tool or
+// as owner/tool.
+func (r Record) MatchToolName(name string) bool {
+	return r.IsTool() && (name == r.Tool.Name || name == r.QualifiedToolName())
+}
+
+// Platform returns the platform that the tooth was installed for. Records
+// written by older versions of Lip have no platform, so the target platform is
+// returned.
+func (r Record) Platform() (goos string, goarch string) {
+	if r.TargetOS == "" {
+		return platform.GOOS(), platform.GOARCH()
+	}
+
+	return r.TargetOS, r.TargetArch
+}
+
+// ToolEntrypoint returns the path of the entrypoint of the tool, or of its
+// subcommand if subcommand is not empty, for the platform.
+func (r Record) ToolEntrypoint(subcommand string, goos string, goarch string) (string, bool) {
+	entrypoints := r.Tool.Entrypoints
+	if subcommand != "" {
+		subcommandStruct, ok := r.Tool.Subcommands[subcommand]
+		if !ok {
+			return "", false
+		}
+		entrypoints = subcommandStruct.Entrypoints
+	}
+
+	for _, entrypoint := range entrypoints {
+		if !platform.MatchPlatform(entrypoint.GOOS, entrypoint.GOARCH, goos, goarch) {
+			continue
+		}
+
+		return entrypoint.Path, true
+	}
+
+	return "", false
+}
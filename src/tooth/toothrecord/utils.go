@@ -30,16 +30,24 @@ func Get(toothPath string) (Record, error) {
 
 // ListAll lists all installed tooth records.
 func ListAll() ([]Record, error) {
-	recordList := make([]Record, 0)
-
-	// Get all record paths
 	recordDir, err := localfile.RecordDir()
 	if err != nil {
 		return nil, errors.New("failed to get record directory: " + err.Error())
 	}
 
+	return ListAllInDir(recordDir)
+}
+
+// ListAllInDir lists all records in the record directory, e.g. that of another
+// workspace. If the record directory does not exist, nothing is installed.
+func ListAllInDir(recordDir string) ([]Record, error) {
+	recordList := make([]Record, 0)
+
+	// Get all record paths
 	files, err := os.ReadDir(recordDir)
-	if err != nil {
+	if os.IsNotExist(err) {
+		return recordList, nil
+	} else if err != nil {
 		return nil, errors.New("failed to read record directory: " + err.Error())
 	}
 
@@ -80,3 +88,41 @@ func IsToothInstalled(toothPath string) (bool, error) {
 
 	return false, nil
 }
+
+// ListProviders lists installed tooth records that are or provide the tooth
+// path, regardless of the version.
+func ListProviders(toothPath string) ([]Record, error) {
+	// Get the tooth record list.
+	recordList, err := ListAll()
+	if err != nil {
+		return nil, err
+	}
+
+	providerList := make([]Record, 0)
+	for _, record := range recordList {
+		if record.ToothPath == toothPath {
+			providerList = append(providerList, record)
+			continue
+		}
+
+		if _, ok := record.Provides[toothPath]; ok {
+			providerList = append(providerList, record)
+		}
+	}
+
+	return providerList, nil
+}
+
+// ToolName returns the name that the tool of the record is called by among the
+// tools in the record list: its name, or owner/tool if another tool in the list
+// has the same name.
+func ToolName(record Record, recordList []Record) string {
+	for _, otherRecord := range recordList {
+		if otherRecord.IsTool() && otherRecord.ToothPath != record.ToothPath &&
+			otherRecord.Tool.Name == record.Tool.Name {
+			return record.QualifiedToolName()
+		}
+	}
+
+	return record.Tool.Name
+}
@@ -3,32 +3,204 @@ package toothrepo
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
+	lipcontext "github.com/liteldev/lip/context"
 	"github.com/liteldev/lip/download"
+	"github.com/liteldev/lip/tooth/toothgit"
 	"github.com/liteldev/lip/utils/versions"
 )
 
-// FetchVersionList fetches the version list of a tooth repository.
-func FetchVersionList(repoPath string) ([]versions.Version, error) {
-	var err error
+// FetchVersionList fetches the version lists of all major version module paths
+// of a tooth repository (e.g. example.com/repo, example.com/repo/v2 and
+// example.com/repo/v3) and merges them into one list in descending order. It is
+// for showing available versions only, since versions of other module paths
+// belong to other tooth paths. Use FetchModuleVersionList to select versions.
+func FetchVersionList(ctx context.Context, repoPath string) ([]versions.Version, error) {
 	if !isValidPath(repoPath) {
 		return nil, errors.New("invalid repository path: " + repoPath)
 	}
 
-	urlPath := repoPath + "/@v/list"
+	basePath, _ := splitMajorVersionSuffix(repoPath)
+
+	versionMap := make(map[string]versions.Version)
+	isAnyFetched := false
+	var lastErr error
+
+	// Fetch the base module path first and then /v2, /v3, etc. Stop at the first
+	// major version module path that does not exist, unless the base module path
+	// already contains higher major versions via +incompatible.
+	maxMajor := 1
+	for major := 1; ; major++ {
+		modulePath := basePath
+		if major >= 2 {
+			modulePath = basePath + "/v" + strconv.Itoa(major)
+		}
+
+		versionList, err := fetchModuleVersionList(modulePath)
+		if err != nil {
+			lastErr = err
+		} else {
+			isAnyFetched = true
+		}
+
+		for _, version := range versionList {
+			versionMap[version.String()] = version
+			if version.Major() > maxMajor {
+				maxMajor = version.Major()
+			}
+		}
+
+		if major >= 2 && len(versionList) == 0 && major >= maxMajor {
+			break
+		}
+	}
+
+	if !isAnyFetched && !lipcontext.IsDirectGitEnabled {
+		return nil, lastErr
+	}
+
+	// Fall back to the git repository directly if all GOPROXYs fail.
+	if !isAnyFetched {
+		versionList, err := fetchDirectVersionList(ctx, basePath)
+		if err != nil {
+			return nil, errors.New("Failed to fetch version list: " + lastErr.Error() + "; " + err.Error())
+		}
+
+		for _, version := range versionList {
+			versionMap[version.String()] = version
+		}
+	}
+
+	versionList := make([]versions.Version, 0, len(versionMap))
+	for _, version := range versionMap {
+		versionList = append(versionList, version)
+	}
+
+	sortVersionList(versionList)
+
+	return versionList, nil
+}
+
+// FetchModuleVersionList fetches the version list of a module path of a tooth
+// repository in descending order. A /vN module path (e.g. example.com/repo/v2)
+// only has versions of major version N, and the base module path only has
+// versions of major versions 0 and 1 and +incompatible versions. Use it to
+// select versions of a tooth path.
+func FetchModuleVersionList(ctx context.Context, repoPath string) ([]versions.Version, error) {
+	if !isValidPath(repoPath) {
+		return nil, errors.New("invalid repository path: " + repoPath)
+	}
+
+	versionList, err := fetchModuleVersionList(repoPath)
+	if err != nil && !lipcontext.IsDirectGitEnabled {
+		return nil, err
+	}
+
+	// Fall back to the git repository directly if all GOPROXYs fail.
+	if err != nil {
+		basePath, major := splitMajorVersionSuffix(repoPath)
+
+		tagVersionList, directErr := fetchDirectVersionList(ctx, basePath)
+		if directErr != nil {
+			return nil, errors.New("Failed to fetch version list: " + err.Error() + "; " + directErr.Error())
+		}
+
+		// Tags of other major versions belong to other module paths, except that
+		// the base module path has all tags, as if they were +incompatible.
+		versionList = make([]versions.Version, 0, len(tagVersionList))
+		for _, version := range tagVersionList {
+			if major >= 2 && version.Major() != major {
+				continue
+			}
+
+			versionList = append(versionList, version)
+		}
+	}
+
+	sortVersionList(versionList)
+
+	return versionList, nil
+}
+
+// ValidateVersion checks if the version of the tooth repository is valid. If
+// the version cannot be accessed via any GOPROXY and direct git access is
+// enabled, the tags of the git repository are checked directly.
+func ValidateVersion(ctx context.Context, repoPath string, version versions.Version) error {
+	_, err := ResolveVersionURLPath(repoPath, version)
+	if err == nil || !lipcontext.IsDirectGitEnabled {
+		return err
+	}
+
+	gitURL, gitTag := DirectGitSource(repoPath, version)
+	tagList, directErr := toothgit.ListTags(ctx, gitURL)
+	if directErr != nil {
+		return errors.New(err.Error() + "; " + directErr.Error())
+	}
+
+	for _, tag := range tagList {
+		if tag == gitTag {
+			return nil
+		}
+	}
+
+	return err
+}
+
+// DirectGitSource returns the URL of the git repository and the tag of a version
+// of a tooth repository. This is used to fetch the tooth directly when all
+// GOPROXYs fail and direct git access is enabled.
+func DirectGitSource(repoPath string, version versions.Version) (string, string) {
+	basePath, _ := splitMajorVersionSuffix(repoPath)
+
+	return toothgit.RepoURL(strings.ToLower(basePath)), "v" + version.String()
+}
+
+// ResolveVersionURLPath returns the GOPROXY URL path of a version of a tooth
+// repository without the file extension, e.g. "example.com/repo/v2/@v/v2.0.0".
+// Append ".zip", ".info" or ".mod" to get the URL path of the corresponding file.
+// Versions with major version 2 or higher are fetched from the /vN module path
+// if the repository path has the suffix, and as +incompatible versions of the
+// base module path otherwise.
+func ResolveVersionURLPath(repoPath string, version versions.Version) (string, error) {
+	if !isValidPath(repoPath) {
+		return "", errors.New("invalid repository path: " + repoPath)
+	}
+
+	urlPath, err := versionURLPath(repoPath, version)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = download.GetGoproxyContent(urlPath + ".info")
+	if err != nil {
+		return "", errors.New("Failed to access version " + version.String() + " of " + repoPath + ": " +
+			urlPath + ": " + err.Error())
+	}
+
+	return urlPath, nil
+}
+
+// fetchModuleVersionList fetches the version list of a single Go module path.
+// Versions whose major version does not match the module path are skipped.
+func fetchModuleVersionList(modulePath string) ([]versions.Version, error) {
+	urlPath := modulePath + "/@v/list"
 
 	// To lowercases.
 	urlPath = strings.ToLower(urlPath)
 
 	content, err := download.GetGoproxyContent(urlPath)
 	if err != nil {
-		return nil, errors.New("Failed to fetch version list: " + err.Error())
+		return nil, err
 	}
 
+	_, major := splitMajorVersionSuffix(modulePath)
+
 	reader := bytes.NewReader(content)
 
 	// Each line is a version.
@@ -42,39 +214,88 @@ func FetchVersionList(repoPath string) ([]versions.Version, error) {
 		if err != nil {
 			continue
 		}
+
+		// Versions of /vN module paths must have major version N.
+		if major >= 2 && version.Major() != major {
+			continue
+		}
+
+		versionList = append(versionList, version)
+	}
+
+	return versionList, nil
+}
+
+// fetchDirectVersionList fetches the version list from the tags of the git
+// repository of a tooth repository.
+func fetchDirectVersionList(ctx context.Context, basePath string) ([]versions.Version, error) {
+	tagList, err := toothgit.ListTags(ctx, toothgit.RepoURL(strings.ToLower(basePath)))
+	if err != nil {
+		return nil, err
+	}
+
+	var versionList []versions.Version
+	for _, tag := range tagList {
+		if !strings.HasPrefix(tag, "v") {
+			continue
+		}
+
+		version, err := versions.NewFromString(strings.TrimPrefix(tag, "v"))
+		if err != nil {
+			continue
+		}
+
 		versionList = append(versionList, version)
 	}
 
-	// Sort the version list in descending order.
+	return versionList, nil
+}
+
+// sortVersionList sorts a version list in descending order.
+func sortVersionList(versionList []versions.Version) {
 	sort.Slice(versionList, func(i, j int) bool {
 		return versions.GreaterThan(versionList[i], versionList[j])
 	})
-
-	return versionList, nil
 }
 
-// ValidateVersion checks if the version of the tooth repository is valid.
-func ValidateVersion(repoPath string, version versions.Version) error {
-	if !isValidPath(repoPath) {
-		return errors.New("invalid repository path: " + repoPath)
+// splitMajorVersionSuffix splits the major version suffix from a Go module path,
+// e.g. "example.com/repo/v2" is split into "example.com/repo" and 2. If there is
+// no major version suffix, the major version is 0.
+func splitMajorVersionSuffix(modulePath string) (string, int) {
+	reg := regexp.MustCompile(`^(.+)/v([2-9]|[1-9]\d+)$`)
+
+	matchList := reg.FindStringSubmatch(modulePath)
+	if matchList == nil {
+		return modulePath, 0
 	}
 
-	// Check if the version is valid.
-	urlPathSuffix := "+incompatible.info"
-	if strings.HasPrefix(version.String(), "0.") || strings.HasPrefix(version.String(), "1.") {
-		urlPathSuffix = ".info"
+	major, err := strconv.Atoi(matchList[2])
+	if err != nil {
+		return modulePath, 0
 	}
-	urlPath := repoPath + "/@v/v" + version.String() + urlPathSuffix
 
-	// To lower case.
-	urlPath = strings.ToLower(urlPath)
+	return matchList[1], major
+}
 
-	_, err := download.GetGoproxyContent(urlPath)
-	if err != nil {
-		return errors.New("Failed to access version " + version.String() + " of " + repoPath + ": " + err.Error())
+// versionURLPath returns the GOPROXY URL path of a version of a tooth
+// repository without the file extension.
+func versionURLPath(repoPath string, version versions.Version) (string, error) {
+	basePath, major := splitMajorVersionSuffix(repoPath)
+
+	var urlPath string
+	if major >= 2 {
+		if version.Major() != major {
+			return "", errors.New("version " + version.String() + " does not belong to module path " + repoPath)
+		}
+		urlPath = repoPath + "/@v/v" + version.String()
+	} else if version.Major() >= 2 {
+		urlPath = basePath + "/@v/v" + version.String() + "+incompatible"
+	} else {
+		urlPath = basePath + "/@v/v" + version.String()
 	}
 
-	return nil
+	// To lower case.
+	return strings.ToLower(urlPath), nil
 }
 
 // isValidPath checks if the repoPath is valid.
@@ -0,0 +1,57 @@
+package toothrepo
+
+import (
+	"testing"
+
+	"github.com/liteldev/lip/utils/versions"
+)
+
+func TestSplitMajorVersionSuffix(t *testing.T) {
+	testList := []struct {
+		input    string
+		basePath string
+		major    int
+	}{
+		{"github.com/tooth-hub/tooth", "github.com/tooth-hub/tooth", 0},
+		{"github.com/tooth-hub/tooth/v2", "github.com/tooth-hub/tooth", 2},
+		{"github.com/tooth-hub/tooth/v12", "github.com/tooth-hub/tooth", 12},
+		{"github.com/tooth-hub/tooth/v1", "github.com/tooth-hub/tooth/v1", 0},
+		{"github.com/tooth-hub/tooth/v0", "github.com/tooth-hub/tooth/v0", 0},
+		{"github.com/tooth-hub/tooth/v02", "github.com/tooth-hub/tooth/v02", 0},
+	}
+
+	for index, test := range testList {
+		basePath, major := splitMajorVersionSuffix(test.input)
+		if basePath != test.basePath || major != test.major {
+			t.Errorf("wrong output at test %d: %s %d", index, basePath, major)
+		}
+	}
+}
+
+func TestVersionURLPath(t *testing.T) {
+	testList := []struct {
+		repoPath string
+		version  string
+		output   string
+		isOK     bool
+	}{
+		{"github.com/tooth-hub/tooth", "1.2.3", "github.com/tooth-hub/tooth/@v/v1.2.3", true},
+		{"github.com/tooth-hub/tooth", "0.1.0-beta.1", "github.com/tooth-hub/tooth/@v/v0.1.0-beta.1", true},
+		{"github.com/tooth-hub/tooth", "2.0.0", "github.com/tooth-hub/tooth/@v/v2.0.0+incompatible", true},
+		{"github.com/Tooth-Hub/tooth/v3", "3.1.0", "github.com/tooth-hub/tooth/v3/@v/v3.1.0", true},
+		{"github.com/tooth-hub/tooth/v3", "2.0.0", "", false},
+		{"github.com/tooth-hub/tooth/v3", "1.0.0", "", false},
+	}
+
+	for index, test := range testList {
+		version, err := versions.NewFromString(test.version)
+		if err != nil {
+			t.Fatalf("error at test %d: %s", index, err.Error())
+		}
+
+		output, err := versionURLPath(test.repoPath, version)
+		if (err == nil) != test.isOK || output != test.output {
+			t.Errorf("wrong output at test %d: %s %v", index, output, err)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+// Package toothshim generates shims, i.e. small launcher scripts, for installed
+// tools so that they can be run without typing lip exec.
+package toothshim
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/liteldev/lip/localfile"
+	"github.com/liteldev/lip/tooth/toothrecord"
+	"github.com/liteldev/lip/utils/platform"
+)
+
+// marker is contained in every shim generated by Lip. Files without it are
+// never overwritten or removed.
+const marker = "Generated by Lip. Do not edit."
+
+// Sync regenerates the shims of the tools installed in the current workspace.
+// Shims of the global workspace are placed in ~/.lip/bin and those of other
+// workspaces in .lip/bin.
+func Sync() error {
+	workspaceDir, err := localfile.WorkspaceDir()
+	if err != nil {
+		return err
+	}
+
+	binDir, err := BinDir()
+	if err != nil {
+		return err
+	}
+
+	recordList, err := toothrecord.ListAll()
+	if err != nil {
+		return err
+	}
+
+	lipPath, err := os.Executable()
+	if err != nil {
+		return errors.New("failed to locate the Lip executable: " + err.Error())
+	}
+
+	return Generate(binDir, workspaceDir, lipPath, recordList)
+}
+
+// BinDir returns the directory of the shims of the current workspace.
+func BinDir() (string, error) {
+	workspaceDir, err := localfile.WorkspaceDir()
+	if err != nil {
+		return "", err
+	}
+
+	globalDir, err := localfile.GlobalDir()
+	if err != nil {
+		return "", err
+	}
+
+	if workspaceDir == globalDir {
+		return localfile.GlobalBinDir()
+	}
+
+	workspaceLipDir, err := localfile.WorkspaceLipDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(workspaceLipDir, "bin"), nil
+}
+
+// BinDirList returns the directories of shims that should be on PATH: that of
+// the current workspace, followed by ~/.lip/bin so that tools in the workspace
+// take precedence over global tools.
+func BinDirList() ([]string, error) {
+	binDir, err := BinDir()
+	if err != nil {
+		return nil, err
+	}
+
+	globalBinDir, err := localfile.GlobalBinDir()
+	if err != nil {
+		return nil, err
+	}
+
+	binDirList := []string{filepath.Clean(binDir)}
+	if filepath.Clean(globalBinDir) != binDirList[0] {
+		binDirList = append(binDirList, filepath.Clean(globalBinDir))
+	}
+
+	return binDirList, nil
+}
+
+// Generate writes a shim to binDir for each tool in the record list that can
+// run on this platform, and removes shims of tools no longer in the list.
+// Tools sharing a name with another tool get no shim, because they can only be
+// told apart by lip exec owner/tool.
+// Shims run the tools of workspaceDir with lip exec of the Lip executable at
+// lipPath, so that tools run the same way as with lip exec, in the working
+// directory of the caller. Shims of other workspaces than the global workspace
+// locate their workspace relative to themselves, so they work regardless of
+// the working directory of the caller.
+func Generate(binDir string, workspaceDir string, lipPath string, recordList []toothrecord.Record) error {
+	globalDir, err := localfile.GlobalDir()
+	if err != nil {
+		return err
+	}
+
+	// Global tools are found by lip exec in any workspace, which it selects as
+	// usual.
+	relativeWorkspaceDir := ""
+	if filepath.Clean(workspaceDir) != filepath.Clean(globalDir) {
+		relativeWorkspaceDir, err = filepath.Rel(binDir, workspaceDir)
+		if err != nil {
+			return errors.New("failed to locate the workspace from " + binDir + ": " + err.Error())
+		}
+	}
+
+	// Map shim file names to their contents.
+	shimMap := make(map[string]string)
+	for _, record := range recordList {
+		if !record.IsTool() || toothrecord.ToolName(record, recordList) != record.Tool.Name {
+			continue
+		}
+
+		if !hasEntrypoint(record) {
+			continue
+		}
+
+		if runtime.GOOS == "windows" {
+			shimMap[record.Tool.Name+".cmd"] = windowsShim(lipPath, relativeWorkspaceDir, record.QualifiedToolName())
+		} else {
+			shimMap[record.Tool.Name] = unixShim(lipPath, relativeWorkspaceDir, record.QualifiedToolName())
+		}
+	}
+
+	// Do not create binDir, and the workspace with it, if there are no shims.
+	if _, err := os.Stat(binDir); os.IsNotExist(err) && len(shimMap) == 0 {
+		return nil
+	}
+
+	err = os.MkdirAll(binDir, 0755)
+	if err != nil {
+		return errors.New("failed to create " + binDir + ": " + err.Error())
+	}
+
+	// Remove shims of uninstalled tools.
+	fileList, err := os.ReadDir(binDir)
+	if err != nil {
+		return errors.New("failed to read " + binDir + ": " + err.Error())
+	}
+
+	for _, file := range fileList {
+		if _, ok := shimMap[file.Name()]; ok || file.IsDir() {
+			continue
+		}
+
+		shimPath := filepath.Join(binDir, file.Name())
+		if !isShim(shimPath) {
+			continue
+		}
+
+		err = os.Remove(shimPath)
+		if err != nil {
+			return errors.New("failed to remove " + shimPath + ": " + err.Error())
+		}
+	}
+
+	// Write shims of installed tools.
+	for fileName, content := range shimMap {
+		shimPath := filepath.Join(binDir, fileName)
+
+		if _, err := os.Stat(shimPath); err == nil && !isShim(shimPath) {
+			return errors.New("cannot write the shim " + shimPath + " because a file not generated by Lip exists")
+		}
+
+		err = os.WriteFile(shimPath, []byte(content), 0755)
+		if err != nil {
+			return errors.New("failed to write " + shimPath + ": " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// hasEntrypoint returns true if the tool has an entrypoint for the platform it
+// was installed for, and the platform is the one Lip is running on.
+func hasEntrypoint(record toothrecord.Record) bool {
+	goos, goarch := record.Platform()
+
+	if !platform.IsNativePlatform(goos, goarch) {
+		return false
+	}
+
+	_, ok := record.ToolEntrypoint("", goos, goarch)
+	return ok
+}
+
+// isShim returns true if the file is a shim generated by Lip.
+func isShim(filePath string) bool {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return false
+	}
+
+	return strings.Contains(string(content), marker)
+}
+
+// unixShim returns the content of a shim for sh.
+func unixShim(lipPath string, relativeWorkspaceDir string, toolName string) string {
+	command := "exec " + quoteForSh(lipPath)
+	if relativeWorkspaceDir != "" {
+		command += " --workspace \"$(dirname \"$0\")\"" + quoteForSh("/"+filepath.ToSlash(relativeWorkspaceDir))
+	}
+
+	return "#!/bin/sh\n" +
+		"# " + marker + "\n" +
+		command + " exec --cwd . " + quoteForSh(toolName) + " \"$@\"\n"
+}
+
+// windowsShim returns the content of a shim for cmd.
+func windowsShim(lipPath string, relativeWorkspaceDir string, toolName string) string {
+	command := "\"" + escapeForCmd(lipPath) + "\""
+	if relativeWorkspaceDir != "" {
+		command += " --workspace \"%~dp0" + escapeForCmd(filepath.FromSlash(relativeWorkspaceDir)) + "\""
+	}
+
+	return "@echo off\r\n" +
+		"rem " + marker + "\r\n" +
+		command + " exec --cwd . \"" + escapeForCmd(toolName) + "\" %*\r\n"
+}
+
+// quoteForSh quotes a string in single quotes for sh, so that no character in
+// it is special.
+func quoteForSh(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "'\\''") + "'"
+}
+
+// escapeForCmd escapes a string to be put in double quotes in a batch file.
+// Only "%" is special there. Double quotes cannot appear in Windows paths.
+func escapeForCmd(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
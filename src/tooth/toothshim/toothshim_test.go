@@ -0,0 +1,132 @@
+package toothshim
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/liteldev/lip/tooth/toothrecord"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("USERPROFILE", os.Getenv("HOME"))
+	workspaceDir := t.TempDir()
+	binDir := filepath.Join(workspaceDir, ".lip", "bin")
+
+	shimName := func(name string) string {
+		if runtime.GOOS == "windows" {
+			return name + ".cmd"
+		}
+		return name
+	}
+
+	newToolRecord := func(name string, goos string) toothrecord.Record {
+		record := toothrecord.Record{ToothPath: "example.com/owner/" + name}
+		record.Tool.Name = name
+		record.Tool.Entrypoints = []toothrecord.ToolEntrypointStruct{
+			{Path: "tools/" + name, GOOS: goos},
+		}
+		return record
+	}
+
+	// A file not generated by Lip is kept.
+	os.MkdirAll(binDir, 0755)
+	os.WriteFile(filepath.Join(binDir, "other"), []byte("other"), 0755)
+
+	err := Generate(binDir, workspaceDir, "/usr/bin/lip", []toothrecord.Record{
+		newToolRecord("a", runtime.GOOS),
+		newToolRecord("b", runtime.GOOS),
+		newToolRecord("foreign", "plan9"),
+		{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(binDir, shimName("a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(filepath.ToSlash(string(content)), "../..") ||
+		!strings.Contains(string(content), "exec --cwd . ") || !strings.Contains(string(content), "owner/a") {
+		t.Errorf("the shim does not run the tool of its workspace with lip exec: %s", content)
+	}
+
+	if _, err := os.Stat(filepath.Join(binDir, shimName("foreign"))); err == nil {
+		t.Errorf("a shim is generated for a tool of another platform")
+	}
+
+	// Shims of removed tools are removed.
+	err = Generate(binDir, workspaceDir, "/usr/bin/lip", []toothrecord.Record{newToolRecord("a", runtime.GOOS)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(binDir, shimName("b"))); err == nil {
+		t.Errorf("the shim of a removed tool is kept")
+	}
+
+	if _, err := os.Stat(filepath.Join(binDir, "other")); err != nil {
+		t.Errorf("a file not generated by Lip is removed")
+	}
+
+	// Files not generated by Lip are never overwritten.
+	os.WriteFile(filepath.Join(binDir, shimName("c")), []byte("user script"), 0755)
+	err = Generate(binDir, workspaceDir, "/usr/bin/lip", []toothrecord.Record{newToolRecord("c", runtime.GOOS)})
+	if err == nil {
+		t.Errorf("a file not generated by Lip is overwritten")
+	}
+
+	// Tools with the same name get no shim.
+	otherRecord := newToolRecord("d", runtime.GOOS)
+	otherRecord.ToothPath = "example.com/other/d"
+	err = Generate(binDir, workspaceDir, "/usr/bin/lip", []toothrecord.Record{newToolRecord("d", runtime.GOOS), otherRecord})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(binDir, shimName("d"))); err == nil {
+		t.Errorf("a shim is generated for tools with the same name")
+	}
+}
+
+func TestShimQuoting(t *testing.T) {
+	// Characters special to sh and cmd are quoted rather than interpreted.
+	content := unixShim("/opt/it's $(id) `id`/lip", "../..", "owner/a")
+	if !strings.Contains(content, `exec '/opt/it'\''s $(id) `+"`id`"+`/lip' --workspace "$(dirname "$0")"'/../..' exec --cwd . 'owner/a' "$@"`) {
+		t.Errorf("wrong sh shim: %s", content)
+	}
+
+	content = windowsShim(`C:\100%\lip.exe`, "..", "owner/a")
+	if !strings.Contains(content, `"C:\100%%\lip.exe" --workspace "%~dp0.." exec --cwd . "owner/a" %*`) {
+		t.Errorf("wrong cmd shim: %s", content)
+	}
+
+	// Shims of the global workspace let lip exec select the workspace.
+	content = unixShim("/usr/bin/lip", "", "owner/a")
+	if strings.Contains(content, "--workspace") {
+		t.Errorf("wrong sh shim of the global workspace: %s", content)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	// The sh shim passes arguments with quotes and spaces to lip exec.
+	rootDir := t.TempDir()
+	lipPath := filepath.Join(rootDir, "it's a lip")
+	os.WriteFile(lipPath, []byte("#!/bin/sh\nprintf '%s\\n' \"$@\"\n"), 0755)
+	binDir := filepath.Join(rootDir, "workspace", ".lip", "bin")
+	os.MkdirAll(binDir, 0755)
+	os.WriteFile(filepath.Join(binDir, "a"), []byte(unixShim(lipPath, "../..", "owner/a")), 0755)
+
+	output, err := exec.Command(filepath.Join(binDir, "a"), "x y", "it's").CombinedOutput()
+	expected := "--workspace\n" + binDir + "/../..\nexec\n--cwd\n.\nowner/a\nx y\nit's\n"
+	if err != nil || string(output) != expected {
+		t.Errorf("failed to run the shim: %s %v", output, err)
+	}
+}
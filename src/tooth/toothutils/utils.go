@@ -2,6 +2,9 @@ package toothutils
 
 import (
 	"regexp"
+
+	"github.com/liteldev/lip/utils/versions"
+	"github.com/liteldev/lip/utils/versions/versionmatch"
 )
 
 // IsValidToothPath returns true if the tooth path is valid.
@@ -10,3 +13,19 @@ func IsValidToothPath(toothPath string) bool {
 
 	return reg.FindString(toothPath) == toothPath
 }
+
+// Satisfies returns true if a tooth of the tooth path and the version, which
+// provides the tooth paths in provides, is or provides the required tooth path
+// with a version matching the version range.
+func Satisfies(toothPath string, version versions.Version, provides map[string]versions.Version,
+	requiredToothPath string, versionRange [][]versionmatch.VersionMatch) bool {
+	if toothPath == requiredToothPath {
+		return versionmatch.MatchRange(version, versionRange)
+	}
+
+	if providedVersion, ok := provides[requiredToothPath]; ok {
+		return versionmatch.MatchRange(providedVersion, versionRange)
+	}
+
+	return false
+}
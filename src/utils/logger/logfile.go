@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// maxLogFileSize is the size in bytes at which the log file is rotated. It is a
+// variable so that tests can lower it.
+var maxLogFileSize int64 = 10 * 1024 * 1024
+
+// maxLogFileBackups is the number of rotated log files kept, i.e. path.1 to
+// path.3, where path.1 is the latest.
+const maxLogFileBackups = 3
+
+var logFile *rotatingFile
+
+// rotatingFile is a log file that is rotated when it grows too large.
+type rotatingFile struct {
+	mutex sync.Mutex
+	path  string
+	file  *os.File
+	size  int64
+}
+
+// SetLogFile makes all messages, regardless of the logging level, be written
+// to the file at path as well. The file is appended to, and rotated when it
+// grows over 10 MiB. An empty path stops writing to the log file.
+func SetLogFile(path string) error {
+	if logFile != nil {
+		logFile.close()
+		logFile = nil
+	}
+
+	if path == "" {
+		return nil
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return errors.New("failed to resolve the log file " + path + ": " + err.Error())
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return errors.New("failed to create the directory of the log file " + path + ": " + err.Error())
+	}
+
+	newLogFile := &rotatingFile{path: path}
+	err = newLogFile.open()
+	if err != nil {
+		return err
+	}
+
+	logFile = newLogFile
+	return nil
+}
+
+// open opens the log file for appending.
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.New("failed to open the log file " + f.path + ": " + err.Error())
+	}
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return errors.New("failed to stat the log file " + f.path + ": " + err.Error())
+	}
+
+	f.file = file
+	f.size = fileInfo.Size()
+	return nil
+}
+
+// close closes the log file.
+func (f *rotatingFile) close() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
+
+// write appends the text to the log file, rotating it first if it would grow
+// too large. Failures are ignored so that logging never stops Lip.
+func (f *rotatingFile) write(text string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return
+	}
+
+	if f.size > 0 && f.size+int64(len(text)) > maxLogFileSize {
+		f.rotate()
+		if f.file == nil {
+			return
+		}
+	}
+
+	n, _ := f.file.WriteString(text)
+	f.size += int64(n)
+}
+
+// rotate moves path.N to path.N+1, dropping the oldest one, moves the log file
+// to path.1 and opens a new log file.
+func (f *rotatingFile) rotate() {
+	f.file.Close()
+	f.file = nil
+
+	os.Remove(f.path + "." + strconv.Itoa(maxLogFileBackups))
+	for i := maxLogFileBackups - 1; i >= 1; i-- {
+		os.Rename(f.path+"."+strconv.Itoa(i), f.path+"."+strconv.Itoa(i+1))
+	}
+	os.Rename(f.path, f.path+".1")
+
+	f.open()
+}
@@ -1,10 +1,23 @@
 // Package logger deals with the output of Lip.
+//
+// Debug and info messages are printed to stdout, and warnings and errors to
+// stderr. Messages may carry key/value fields added by With, and are printed
+// as text or as JSON lines. All messages, regardless of the logging level, can
+// also be written to a log file. Text messages on the console are meant for
+// humans and leave the fields out, while the log file keeps them.
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	color "github.com/fatih/color"
+	"github.com/mattn/go-isatty"
 )
 
 type LoggingLevelType int
@@ -17,43 +30,123 @@ const (
 	CriticalLevel
 )
 
+// FormatType is the format that messages are printed in.
+type FormatType int
+
+const (
+	// TextFormat prints messages for humans.
+	TextFormat FormatType = iota
+	// JSONFormat prints each message as a JSON object on its own line.
+	JSONFormat
+)
+
+// ColorModeType decides whether messages are colored.
+type ColorModeType int
+
+const (
+	// ColorAuto colors messages printed to a terminal unless NO_COLOR is set.
+	ColorAuto ColorModeType = iota
+	ColorAlways
+	ColorNever
+)
+
 var loggingLevel LoggingLevelType = InfoLevel
+var format FormatType = TextFormat
+var colorMode ColorModeType = ColorAuto
+
+// stdout and stderr are variables so that tests can capture the output.
+var stdout io.Writer = os.Stdout
+var stderr io.Writer = os.Stderr
+
+// Field is a key/value pair attached to a message.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Entry is a set of fields to attach to messages. Create one with With.
+type Entry struct {
+	fields []Field
+}
+
+// jsonMessage is a message in JSON format.
+type jsonMessage struct {
+	Time    string                 `json:"time"`
+	Level   string                 `json:"level"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// With returns an entry with the key/value pairs as fields, e.g.
+// With("tooth", toothPath, "version", version).Info("Installing...").
+func With(keyValues ...interface{}) Entry {
+	return Entry{}.With(keyValues...)
+}
+
+// With returns a copy of the entry with the key/value pairs added.
+func (e Entry) With(keyValues ...interface{}) Entry {
+	fields := make([]Field, len(e.fields), len(e.fields)+len(keyValues)/2+1)
+	copy(fields, e.fields)
+
+	for i := 0; i < len(keyValues); i += 2 {
+		field := Field{Key: fmt.Sprint(keyValues[i])}
+		if i+1 < len(keyValues) {
+			field.Value = keyValues[i+1]
+		}
+		fields = append(fields, field)
+	}
+
+	return Entry{fields: fields}
+}
+
+// Debug prints a debug message.
+func (e Entry) Debug(format string, a ...interface{}) {
+	e.log(DebugLevel, format, a...)
+}
+
+// Info prints a message.
+func (e Entry) Info(format string, a ...interface{}) {
+	e.log(InfoLevel, format, a...)
+}
+
+// Warning prints a warning message.
+func (e Entry) Warning(format string, a ...interface{}) {
+	e.log(WarningLevel, format, a...)
+}
+
+// Error prints an error message.
+func (e Entry) Error(format string, a ...interface{}) {
+	e.log(ErrorLevel, format, a...)
+}
+
+// Critical prints a critical message.
+func (e Entry) Critical(format string, a ...interface{}) {
+	e.log(CriticalLevel, format, a...)
+}
 
 // Debug prints a debug message to the console.
 func Debug(format string, a ...interface{}) {
-	if loggingLevel <= DebugLevel {
-		color.HiBlack("DEBUG: "+format, a...)
-	}
+	Entry{}.log(DebugLevel, format, a...)
 }
 
 // Info prints a message to the console.
 func Info(format string, a ...interface{}) {
-	if loggingLevel <= InfoLevel {
-		fmt.Printf(format, a...)
-		// Print a new line.
-		fmt.Println()
-	}
+	Entry{}.log(InfoLevel, format, a...)
 }
 
 // Warning prints a warning message to the console.
 func Warning(format string, a ...interface{}) {
-	if loggingLevel <= WarningLevel {
-		color.HiYellow("WARNING: "+format, a...)
-	}
+	Entry{}.log(WarningLevel, format, a...)
 }
 
 // Error prints an error message to the console.
 func Error(format string, a ...interface{}) {
-	if loggingLevel <= ErrorLevel {
-		color.HiRed("ERROR: "+format, a...)
-	}
+	Entry{}.log(ErrorLevel, format, a...)
 }
 
 // Critical prints a critical message to the console.
 func Critical(format string, a ...interface{}) {
-	if loggingLevel <= CriticalLevel {
-		color.HiRed("CRITICAL: "+format, a...)
-	}
+	Entry{}.log(CriticalLevel, format, a...)
 }
 
 // GetLevel returns the logging level.
@@ -65,3 +158,159 @@ func GetLevel() LoggingLevelType {
 func SetLevel(level LoggingLevelType) {
 	loggingLevel = level
 }
+
+// GetFormat returns the format that messages are printed in.
+func GetFormat() FormatType {
+	return format
+}
+
+// SetFormat sets the format that messages are printed in.
+func SetFormat(newFormat FormatType) {
+	format = newFormat
+}
+
+// SetInfoOutput sets where debug and info messages are printed, which is
+// stdout by default.
+func SetInfoOutput(writer io.Writer) {
+	stdout = writer
+}
+
+// SetColorMode sets whether messages are colored.
+func SetColorMode(mode ColorModeType) {
+	colorMode = mode
+}
+
+// IsTerminal returns true if the file is a terminal. Progress bars and colors
+// are only shown on terminals.
+func IsTerminal(file *os.File) bool {
+	return isatty.IsTerminal(file.Fd()) || isatty.IsCygwinTerminal(file.Fd())
+}
+
+// log prints the message to the console if its level is enabled, and writes it
+// to the log file if any.
+func (e Entry) log(level LoggingLevelType, format string, a ...interface{}) {
+	message := fmt.Sprintf(format, a...)
+	now := time.Now()
+
+	if logFile != nil {
+		logFile.write(e.render(level, message, now, false))
+	}
+
+	if level < loggingLevel {
+		return
+	}
+
+	writer := stdout
+	if level >= WarningLevel {
+		writer = stderr
+	}
+
+	consoleEntry := e
+	if GetFormat() == TextFormat {
+		consoleEntry = Entry{}
+	}
+
+	fmt.Fprint(writer, consoleEntry.render(level, message, now, isColorEnabled(writer)))
+}
+
+// render returns the message in the format, ending with a new line.
+func (e Entry) render(level LoggingLevelType, message string, now time.Time, isColored bool) string {
+	if format == JSONFormat {
+		jsonMsg := jsonMessage{
+			Time:    now.Format(time.RFC3339Nano),
+			Level:   levelName(level),
+			Message: message,
+		}
+
+		if len(e.fields) > 0 {
+			jsonMsg.Fields = make(map[string]interface{})
+			for _, field := range e.fields {
+				jsonMsg.Fields[field.Key] = field.Value
+			}
+		}
+
+		line, err := json.Marshal(jsonMsg)
+		if err != nil {
+			// Fields that cannot be encoded are printed as strings.
+			for key, value := range jsonMsg.Fields {
+				jsonMsg.Fields[key] = fmt.Sprint(value)
+			}
+			line, _ = json.Marshal(jsonMsg)
+		}
+
+		return string(line) + "\n"
+	}
+
+	text := message
+	if level != InfoLevel {
+		text = strings.ToUpper(levelName(level)) + ": " + message
+	}
+
+	for _, field := range e.fields {
+		text += " " + field.Key + "=" + quoteValue(field.Value)
+	}
+
+	if isColored {
+		var levelColor *color.Color
+		switch level {
+		case DebugLevel:
+			levelColor = color.New(color.FgHiBlack)
+		case WarningLevel:
+			levelColor = color.New(color.FgHiYellow)
+		case ErrorLevel, CriticalLevel:
+			levelColor = color.New(color.FgHiRed)
+		}
+
+		if levelColor != nil {
+			levelColor.EnableColor()
+			text = levelColor.Sprint(text)
+		}
+	}
+
+	return text + "\n"
+}
+
+// isColorEnabled returns true if messages printed to the writer should be
+// colored.
+func isColorEnabled(writer io.Writer) bool {
+	switch colorMode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	file, ok := writer.(*os.File)
+	return ok && IsTerminal(file)
+}
+
+// levelName returns the name of the level in lower case.
+func levelName(level LoggingLevelType) string {
+	switch level {
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarningLevel:
+		return "warning"
+	case ErrorLevel:
+		return "error"
+	default:
+		return "critical"
+	}
+}
+
+// quoteValue formats a field value for text output, quoting it if it is empty
+// or contains spaces.
+func quoteValue(value interface{}) string {
+	text := fmt.Sprint(value)
+	if text == "" || strings.ContainsAny(text, " \t\n\"=") {
+		return strconv.Quote(text)
+	}
+
+	return text
+}
@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// capture redirects the output of the logger to buffers until the end of the
+// test.
+func capture(t *testing.T) (*bytes.Buffer, *bytes.Buffer) {
+	var stdoutBuffer, stderrBuffer bytes.Buffer
+
+	originalStdout, originalStderr := stdout, stderr
+	originalLevel, originalFormat, originalColorMode := loggingLevel, format, colorMode
+	t.Cleanup(func() {
+		stdout, stderr = originalStdout, originalStderr
+		loggingLevel, format, colorMode = originalLevel, originalFormat, originalColorMode
+		SetLogFile("")
+	})
+
+	stdout, stderr = &stdoutBuffer, &stderrBuffer
+	SetColorMode(ColorNever)
+	return &stdoutBuffer, &stderrBuffer
+}
+
+func TestTextFormat(t *testing.T) {
+	stdoutBuffer, stderrBuffer := capture(t)
+	SetLevel(InfoLevel)
+	SetFormat(TextFormat)
+
+	Debug("hidden")
+	With("tooth", "github.com/tooth/example", "note", "two words").Info("Installing %s", "example")
+	Error("failed")
+
+	// Fields are left out of text messages on the console.
+	if got, want := stdoutBuffer.String(), "Installing example\n"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+
+	if got, want := stderrBuffer.String(), "ERROR: failed\n"; got != want {
+		t.Errorf("stderr = %q, want %q", got, want)
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	stdoutBuffer, stderrBuffer := capture(t)
+	SetLevel(DebugLevel)
+	SetFormat(JSONFormat)
+
+	Debug("debug message")
+	With("version", "1.0.0").Warning("warning message")
+
+	var debugMsg, warningMsg jsonMessage
+	if err := json.Unmarshal(stdoutBuffer.Bytes(), &debugMsg); err != nil {
+		t.Fatalf("stdout is not a JSON line: %v", err)
+	}
+	if err := json.Unmarshal(stderrBuffer.Bytes(), &warningMsg); err != nil {
+		t.Fatalf("stderr is not a JSON line: %v", err)
+	}
+
+	if debugMsg.Level != "debug" || debugMsg.Message != "debug message" || debugMsg.Fields != nil {
+		t.Errorf("debug message = %+v", debugMsg)
+	}
+
+	if warningMsg.Level != "warning" || warningMsg.Message != "warning message" ||
+		warningMsg.Fields["version"] != "1.0.0" {
+		t.Errorf("warning message = %+v", warningMsg)
+	}
+
+	if debugMsg.Time == "" {
+		t.Errorf("time is missing")
+	}
+}
+
+func TestLogFile(t *testing.T) {
+	capture(t)
+	SetLevel(ErrorLevel)
+	SetFormat(TextFormat)
+
+	originalMaxLogFileSize := maxLogFileSize
+	defer func() { maxLogFileSize = originalMaxLogFileSize }()
+	maxLogFileSize = 100
+
+	logPath := filepath.Join(t.TempDir(), "logs", "lip.log")
+	if err := SetLogFile(logPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// Messages below the logging level are written to the log file as well.
+	Debug("first")
+	content, _ := os.ReadFile(logPath)
+	if string(content) != "DEBUG: first\n" {
+		t.Errorf("log file = %q", content)
+	}
+
+	// Fields are kept in the log file.
+	With("tooth", "github.com/tooth/example", "note", "two words").Debug("second")
+	content, _ = os.ReadFile(logPath)
+	if want := "DEBUG: first\nDEBUG: second tooth=github.com/tooth/example note=\"two words\"\n"; string(content) != want {
+		t.Errorf("log file = %q, want %q", content, want)
+	}
+
+	// The log file is rotated when it grows too large, and only a few backups
+	// are kept.
+	for i := 0; i < 50; i++ {
+		Info(strings.Repeat("x", 40))
+	}
+
+	for i := 1; i <= maxLogFileBackups; i++ {
+		fileInfo, err := os.Stat(logPath + "." + strconv.Itoa(i))
+		if err != nil {
+			t.Fatalf("backup %d is missing: %v", i, err)
+		}
+		if fileInfo.Size() > maxLogFileSize {
+			t.Errorf("backup %d is %d bytes, larger than %d", i, fileInfo.Size(), maxLogFileSize)
+		}
+	}
+
+	if _, err := os.Stat(logPath + ".4"); err == nil {
+		t.Errorf("too many backups are kept")
+	}
+}
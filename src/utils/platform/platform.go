@@ -0,0 +1,137 @@
+// Package platform provides the target platform that tooths are installed for.
+//
+// The target platform is decided in the following order:
+//
+//  1. The target set by SetTarget, i.e. the --target-os and --target-arch flags.
+//  2. The LIP_TARGET_OS and LIP_TARGET_ARCH environment variables.
+//  3. The target set by SetDefaultTarget, i.e. the platform of the workspace.
+//  4. The platform Lip is running on.
+package platform
+
+import (
+	"os"
+	"runtime"
+)
+
+// KnownGOOSList is the list of valid GOOS values.
+var KnownGOOSList = []string{
+	"aix", "android", "darwin", "dragonfly", "freebsd", "illumos", "ios", "js",
+	"linux", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows",
+}
+
+// KnownGOARCHList is the list of valid GOARCH values.
+var KnownGOARCHList = []string{
+	"386", "amd64", "arm", "arm64", "loong64", "mips", "mips64", "mips64le",
+	"mipsle", "ppc64", "ppc64le", "riscv64", "s390x", "wasm",
+}
+
+var targetGOOS, targetGOARCH string
+var defaultGOOS, defaultGOARCH string
+
+// SetTarget sets the target platform. Empty values are ignored.
+func SetTarget(goos string, goarch string) {
+	targetGOOS = goos
+	targetGOARCH = goarch
+}
+
+// SetDefaultTarget sets the target platform used when neither SetTarget nor
+// the environment variables specify one. Empty values are ignored.
+func SetDefaultTarget(goos string, goarch string) {
+	defaultGOOS = goos
+	defaultGOARCH = goarch
+}
+
+// DefaultTarget returns the target platform set by SetDefaultTarget.
+func DefaultTarget() (goos string, goarch string) {
+	return defaultGOOS, defaultGOARCH
+}
+
+// GOOS returns the target GOOS.
+func GOOS() string {
+	if targetGOOS != "" {
+		return targetGOOS
+	}
+
+	if goos := os.Getenv("LIP_TARGET_OS"); goos != "" {
+		return goos
+	}
+
+	if defaultGOOS != "" {
+		return defaultGOOS
+	}
+
+	return runtime.GOOS
+}
+
+// GOARCH returns the target GOARCH.
+func GOARCH() string {
+	if targetGOARCH != "" {
+		return targetGOARCH
+	}
+
+	if goarch := os.Getenv("LIP_TARGET_ARCH"); goarch != "" {
+		return goarch
+	}
+
+	if defaultGOARCH != "" {
+		return defaultGOARCH
+	}
+
+	return runtime.GOARCH
+}
+
+// IsKnownGOOS returns true if the GOOS is in KnownGOOSList.
+func IsKnownGOOS(goos string) bool {
+	for _, known := range KnownGOOSList {
+		if goos == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsKnownGOARCH returns true if the GOARCH is in KnownGOARCHList.
+func IsKnownGOARCH(goarch string) bool {
+	for _, known := range KnownGOARCHList {
+		if goarch == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsNative returns true if the target platform is the platform Lip is running
+// on. Commands of tooths can only run on the native platform.
+func IsNative() bool {
+	return IsNativePlatform(GOOS(), GOARCH())
+}
+
+// IsNativePlatform returns true if the platform is the platform Lip is running
+// on.
+func IsNativePlatform(goos string, goarch string) bool {
+	return goos == runtime.GOOS && goarch == runtime.GOARCH
+}
+
+// Match returns true if the GOOS and GOARCH filters select the target platform.
+// An empty filter selects all platforms.
+func Match(goos string, goarch string) bool {
+	return MatchPlatform(goos, goarch, GOOS(), GOARCH())
+}
+
+// MatchPlatform returns true if the GOOS and GOARCH filters select the
+// platform. An empty filter selects all platforms.
+func MatchPlatform(filterGOOS string, filterGOARCH string, goos string, goarch string) bool {
+	return (filterGOOS == "" || filterGOOS == goos) && (filterGOARCH == "" || filterGOARCH == goarch)
+}
+
+// Native returns the platform Lip is running on in the form of GOOS/GOARCH.
+func Native() string {
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
+// String returns the target platform in the form of GOOS/GOARCH.
+func String() string {
+	return GOOS() + "/" + GOARCH()
+}
@@ -0,0 +1,66 @@
+package platform
+
+import (
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestGOOS(t *testing.T) {
+	os.Unsetenv("LIP_TARGET_OS")
+	defer SetTarget("", "")
+	defer SetDefaultTarget("", "")
+
+	if GOOS() != runtime.GOOS {
+		t.Errorf("GOOS() = %q, want %q", GOOS(), runtime.GOOS)
+	}
+
+	SetDefaultTarget("plan9", "")
+	if GOOS() != "plan9" {
+		t.Errorf("GOOS() = %q, want %q", GOOS(), "plan9")
+	}
+
+	t.Setenv("LIP_TARGET_OS", "aix")
+	if GOOS() != "aix" {
+		t.Errorf("GOOS() = %q, want %q", GOOS(), "aix")
+	}
+
+	SetTarget("illumos", "")
+	if GOOS() != "illumos" {
+		t.Errorf("GOOS() = %q, want %q", GOOS(), "illumos")
+	}
+
+}
+
+func TestMatch(t *testing.T) {
+	SetTarget("windows", "amd64")
+	defer SetTarget("", "")
+
+	testList := []struct {
+		goos   string
+		goarch string
+		output bool
+	}{
+		{"", "", true},
+		{"windows", "", true},
+		{"windows", "amd64", true},
+		{"", "arm64", false},
+		{"linux", "amd64", false},
+	}
+
+	for _, test := range testList {
+		if output := Match(test.goos, test.goarch); output != test.output {
+			t.Errorf("Match(%q, %q) = %v, want %v", test.goos, test.goarch, output, test.output)
+		}
+	}
+}
+
+func TestIsNativePlatform(t *testing.T) {
+	if !IsNativePlatform(runtime.GOOS, runtime.GOARCH) {
+		t.Errorf("IsNativePlatform(%q, %q) = false", runtime.GOOS, runtime.GOARCH)
+	}
+
+	if IsNativePlatform("plan9", runtime.GOARCH) || IsNativePlatform(runtime.GOOS, "") {
+		t.Errorf("IsNativePlatform() = true for another platform")
+	}
+}
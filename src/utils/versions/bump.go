@@ -0,0 +1,123 @@
+package versions
+
+import (
+	"errors"
+	"strconv"
+)
+
+// BumpType is an enum that represents the part of a version to increment.
+type BumpType int
+
+const (
+	MajorBumpType BumpType = iota
+	MinorBumpType
+	PatchBumpType
+	PreReleaseBumpType
+)
+
+// Bump returns the next version of the given version. Build metadata is
+// dropped.
+//
+// Major, minor and patch bumps of a pre-release version release it if the
+// lower parts are already zero, e.g. 2.0.0-beta.1 becomes 2.0.0 with a major
+// bump. If preReleaseID is not empty, the bumped version is the first
+// pre-release of it, e.g. 1.2.3 becomes 2.0.0-beta.0 with a major bump and
+// preReleaseID "beta".
+//
+// Pre-release bumps of a pre-release version increment the last numeric
+// identifier, e.g. 1.0.0-beta.1 becomes 1.0.0-beta.2. If preReleaseID differs
+// from the first identifier, the pre-release restarts from 0 with it. Pre-release
+// bumps of a stable version increment the patch version first, e.g. 1.2.3
+// becomes 1.2.4-beta.0 with preReleaseID "beta", or 1.2.4-0 without it.
+//
+// An error is returned if the bumped version would not be greater than the given
+// version, e.g. 1.0.0-beta.1 with preReleaseID "alpha".
+func Bump(version Version, bumpType BumpType, preReleaseID string) (Version, error) {
+	if preReleaseID != "" && !isValidIdentifier(preReleaseID) {
+		return Version{}, errors.New("invalid pre-release identifier: " + preReleaseID)
+	}
+
+	bumpedVersion, err := bump(version, bumpType, preReleaseID)
+	if err != nil {
+		return Version{}, err
+	}
+
+	if !GreaterThan(bumpedVersion, version) {
+		return Version{}, errors.New("bumping " + version.String() + " gives " + bumpedVersion.String() +
+			", which is not greater than it")
+	}
+
+	return bumpedVersion, nil
+}
+
+// bump returns the next version of the given version as described in Bump,
+// without checking that it is greater.
+func bump(version Version, bumpType BumpType, preReleaseID string) (Version, error) {
+	major := version.Major()
+	minor := version.Minor()
+	patch := version.Patch()
+	isStable := version.IsStable()
+
+	switch bumpType {
+	case MajorBumpType:
+		if isStable || minor != 0 || patch != 0 {
+			major++
+		}
+		minor = 0
+		patch = 0
+
+	case MinorBumpType:
+		if isStable || patch != 0 {
+			minor++
+		}
+		patch = 0
+
+	case PatchBumpType:
+		if isStable {
+			patch++
+		}
+
+	case PreReleaseBumpType:
+		if isStable {
+			patch++
+			break
+		}
+
+		preRelease := version.PreRelease()
+		if preReleaseID != "" && preRelease[0] != preReleaseID {
+			break
+		}
+
+		return New(major, minor, patch, incrementPreRelease(preRelease), nil)
+
+	default:
+		return Version{}, errors.New("invalid bump type")
+	}
+
+	// Start a new pre-release if required.
+	if preReleaseID != "" {
+		return New(major, minor, patch, []string{preReleaseID, "0"}, nil)
+	}
+	if bumpType == PreReleaseBumpType {
+		return New(major, minor, patch, []string{"0"}, nil)
+	}
+
+	return New(major, minor, patch, nil, nil)
+}
+
+// incrementPreRelease increments the last numeric identifier of pre-release
+// identifiers. If there is no numeric identifier, "0" is appended.
+func incrementPreRelease(preRelease []string) []string {
+	newPreRelease := make([]string, len(preRelease))
+	copy(newPreRelease, preRelease)
+
+	for i := len(newPreRelease) - 1; i >= 0; i-- {
+		if isNumericIdentifier(newPreRelease[i]) {
+			number, _ := strconv.Atoi(newPreRelease[i])
+			newPreRelease[i] = strconv.Itoa(number + 1)
+			return newPreRelease
+		}
+	}
+
+	return append(newPreRelease, "0")
+}
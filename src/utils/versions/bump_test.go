@@ -0,0 +1,78 @@
+package versions
+
+import "testing"
+
+func TestBump(t *testing.T) {
+	testList := []struct {
+		version      string
+		bumpType     BumpType
+		preReleaseID string
+		output       string
+	}{
+		{"1.2.3", MajorBumpType, "", "2.0.0"},
+		{"1.2.3", MinorBumpType, "", "1.3.0"},
+		{"1.2.3", PatchBumpType, "", "1.2.4"},
+		{"1.2.3+build.1", PatchBumpType, "", "1.2.4"},
+		{"2.0.0-beta.1", MajorBumpType, "", "2.0.0"},
+		{"2.1.0-beta.1", MajorBumpType, "", "3.0.0"},
+		{"1.3.0-beta.1", MinorBumpType, "", "1.3.0"},
+		{"1.3.1-beta.1", MinorBumpType, "", "1.4.0"},
+		{"1.2.4-beta.1", PatchBumpType, "", "1.2.4"},
+		{"1.2.3", MajorBumpType, "beta", "2.0.0-beta.0"},
+		{"1.2.3", MinorBumpType, "rc", "1.3.0-rc.0"},
+		{"1.2.3", PatchBumpType, "alpha", "1.2.4-alpha.0"},
+		{"1.2.3", PreReleaseBumpType, "", "1.2.4-0"},
+		{"1.2.3", PreReleaseBumpType, "beta", "1.2.4-beta.0"},
+		{"1.2.4-beta.0", PreReleaseBumpType, "", "1.2.4-beta.1"},
+		{"1.2.4-beta.9", PreReleaseBumpType, "beta", "1.2.4-beta.10"},
+		{"1.2.4-beta.1.x", PreReleaseBumpType, "", "1.2.4-beta.2.x"},
+		{"1.2.4-beta", PreReleaseBumpType, "", "1.2.4-beta.0"},
+		{"1.2.4-beta.3", PreReleaseBumpType, "rc", "1.2.4-rc.0"},
+	}
+
+	for _, test := range testList {
+		version, err := NewFromString(test.version)
+		if err != nil {
+			t.Fatalf("NewFromString(%q) failed: %v", test.version, err)
+		}
+
+		output, err := Bump(version, test.bumpType, test.preReleaseID)
+		if err != nil {
+			t.Errorf("Bump(%q, %d, %q) failed: %v", test.version, test.bumpType, test.preReleaseID, err)
+			continue
+		}
+
+		if output.String() != test.output {
+			t.Errorf("Bump(%q, %d, %q) = %q, want %q",
+				test.version, test.bumpType, test.preReleaseID, output.String(), test.output)
+		}
+	}
+}
+
+func TestBumpInvalid(t *testing.T) {
+	version, _ := NewFromString("1.2.3")
+
+	if _, err := Bump(version, PreReleaseBumpType, "beta.1"); err == nil {
+		t.Errorf("Bump() with an invalid pre-release identifier should fail")
+	}
+
+	if _, err := Bump(version, BumpType(100), ""); err == nil {
+		t.Errorf("Bump() with an invalid bump type should fail")
+	}
+
+	// Bumped versions must be greater than the given version.
+	for _, test := range []struct {
+		version      string
+		bumpType     BumpType
+		preReleaseID string
+	}{
+		{"1.0.0-beta.1", PreReleaseBumpType, "alpha"},
+		{"1.3.0-beta.1", MinorBumpType, "alpha"},
+		{"2.0.0-rc.1", MajorBumpType, "beta"},
+	} {
+		version, _ := NewFromString(test.version)
+		if output, err := Bump(version, test.bumpType, test.preReleaseID); err == nil {
+			t.Errorf("Bump(%v, %v, %v) = %v, want an error", test.version, test.bumpType, test.preReleaseID, output.String())
+		}
+	}
+}
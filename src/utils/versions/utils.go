@@ -2,103 +2,178 @@ package versions
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 )
 
 // ---------------------------------------------------------------------
 // Version comparison functions
 
-// Equal returns true if the two versions are equal.
-func Equal(v1, v2 Version) bool {
-	return v1.major == v2.major &&
-		v1.minor == v2.minor &&
-		v1.patch == v2.patch &&
-		v1.preReleaseName == v2.preReleaseName &&
-		v1.preReleaseNumber == v2.preReleaseNumber
-}
-
-// GreaterThan returns true if the first version is greater than the second
-// version.
-func GreaterThan(v1, v2 Version) bool {
-	if v1.major > v2.major {
-		return true
-	}
-	if v1.major < v2.major {
-		return false
-	}
-	if v1.minor > v2.minor {
-		return true
-	}
-	if v1.minor < v2.minor {
-		return false
+// Compare compares the precedence of two versions. It returns -1 if v1 is less
+// than v2, 0 if they are equal and 1 if v1 is greater than v2. Build metadata
+// is ignored.
+func Compare(v1, v2 Version) int {
+	if v1.major != v2.major {
+		return compareInt(v1.major, v2.major)
 	}
-	if v1.patch > v2.patch {
-		return true
+	if v1.minor != v2.minor {
+		return compareInt(v1.minor, v2.minor)
 	}
-	if v1.patch < v2.patch {
-		return false
+	if v1.patch != v2.patch {
+		return compareInt(v1.patch, v2.patch)
 	}
-	if v1.preReleaseName != "" && v2.preReleaseName == "" {
-		return false
-	}
-	if v1.preReleaseName == "" && v2.preReleaseName != "" {
-		return true
+
+	// A pre-release version has lower precedence than a normal version.
+	if len(v1.preRelease) == 0 && len(v2.preRelease) == 0 {
+		return 0
 	}
-	if v1.preReleaseName > v2.preReleaseName {
-		return true
+	if len(v1.preRelease) == 0 {
+		return 1
 	}
-	if v1.preReleaseName < v2.preReleaseName {
-		return false
+	if len(v2.preRelease) == 0 {
+		return -1
 	}
-	if v1.preReleaseNumber > v2.preReleaseNumber {
-		return true
+
+	// Compare each dot-separated identifier from left to right.
+	for i := 0; i < len(v1.preRelease) && i < len(v2.preRelease); i++ {
+		if result := compareIdentifier(v1.preRelease[i], v2.preRelease[i]); result != 0 {
+			return result
+		}
 	}
-	return false
+
+	// A larger set of pre-release identifiers has a higher precedence.
+	return compareInt(len(v1.preRelease), len(v2.preRelease))
+}
+
+// Equal returns true if the two versions have the same precedence. Build
+// metadata is ignored.
+func Equal(v1, v2 Version) bool {
+	return Compare(v1, v2) == 0
+}
+
+// GreaterThan returns true if the first version is greater than the second
+// version.
+func GreaterThan(v1, v2 Version) bool {
+	return Compare(v1, v2) > 0
 }
 
 // GreaterThanOrEqual returns true if the first version is greater than or equal
 // to the second version.
 func GreaterThanOrEqual(v1, v2 Version) bool {
-	return GreaterThan(v1, v2) || Equal(v1, v2)
+	return Compare(v1, v2) >= 0
 }
 
 // LessThan returns true if the first version is less than the second version.
 func LessThan(v1, v2 Version) bool {
-	return !GreaterThanOrEqual(v1, v2)
+	return Compare(v1, v2) < 0
 }
 
 // LessThanOrEqual returns true if the first version is less than or equal to
 // the second version.
 func LessThanOrEqual(v1, v2 Version) bool {
-	return !GreaterThan(v1, v2)
+	return Compare(v1, v2) <= 0
 }
 
 // Compatible returns true if the two versions are compatible.
 func Compatible(v1, v2 Version) bool {
 	return v1.major == v2.major &&
 		v1.minor == v2.minor &&
-		(v1.preReleaseName == "" || v2.preReleaseName == "")
+		(v1.IsStable() || v2.IsStable())
 }
 
 // ---------------------------------------------------------------------
 
 // IsValidVersionString returns true if the version string is valid.
 func IsValidVersionString(versionString string) bool {
-	reg := regexp.MustCompile(`^(0|[0-9]\d*)\.(0|[0-9]\d*)\.(0|[0-9]\d*)(-[a-z]+(\.(0|[0-9]\d*))?)?$`)
-	if !reg.MatchString(versionString) {
-		return false
+	// This is the regular expression suggested by Semantic Versioning 2.0.0.
+	reg := regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+		`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+		`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+	return reg.MatchString(versionString)
+}
+
+// isLegacyVersionString returns true if the version string follows the syntax
+// accepted by older versions of Lip, which allows leading zeroes in numbers.
+func isLegacyVersionString(versionString string) bool {
+	reg := regexp.MustCompile(`^\d+\.\d+\.\d+(-[a-z]+(\.\d+)?)?$`)
+
+	return reg.MatchString(versionString)
+}
+
+// normalizeLegacyVersionString removes leading zeroes from the numbers of a
+// version string accepted by isLegacyVersionString.
+func normalizeLegacyVersionString(versionString string) string {
+	partList := strings.FieldsFunc(versionString, func(r rune) bool {
+		return r == '-' || r == '.'
+	})
+
+	for i, part := range partList {
+		if number, err := strconv.Atoi(part); err == nil {
+			partList[i] = strconv.Itoa(number)
+		}
+	}
+
+	normalizedVersionString := strings.Join(partList[:3], ".")
+	if len(partList) > 3 {
+		normalizedVersionString += "-" + strings.Join(partList[3:], ".")
 	}
 
-	// Split the version string into numeric and pre-release parts.
-	versionParts := strings.Split(versionString, "-")
+	return normalizedVersionString
+}
+
+// compareIdentifier compares two pre-release identifiers. Numeric identifiers
+// are compared numerically and always have lower precedence than alphanumeric
+// identifiers, which are compared lexically in ASCII sort order.
+func compareIdentifier(id1, id2 string) int {
+	isNumeric1 := isNumericIdentifier(id1)
+	isNumeric2 := isNumericIdentifier(id2)
 
-	// If the version string has a pre-release part, the patch version must be 0.
-	if len(versionParts) == 2 {
-		patchVersion := strings.Split(versionParts[0], ".")[2]
-		if patchVersion != "0" {
-			return false
+	switch {
+	case isNumeric1 && isNumeric2:
+		// Compare by length first to avoid overflow with very long numbers.
+		if len(id1) != len(id2) {
+			return compareInt(len(id1), len(id2))
 		}
+		number1, err1 := strconv.ParseUint(id1, 10, 64)
+		number2, err2 := strconv.ParseUint(id2, 10, 64)
+		if err1 == nil && err2 == nil {
+			if number1 < number2 {
+				return -1
+			}
+			if number1 > number2 {
+				return 1
+			}
+			return 0
+		}
+		return compareString(id1, id2)
+	case isNumeric1:
+		return -1
+	case isNumeric2:
+		return 1
+	default:
+		return compareString(id1, id2)
 	}
+}
 
-	return true
+// compareInt compares two integers.
+func compareInt(a, b int) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// compareString compares two strings in ASCII sort order.
+func compareString(a, b string) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
 }
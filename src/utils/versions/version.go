@@ -4,105 +4,127 @@ package versions
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
-// Version is a version number. The version number is split into three parts: the
-// major version, the minor version, and the patch version. The major version is
-// incremented when a backwards incompatible change is made. The minor version is
-// incremented when a backwards compatible change is made. The patch version is
-// incremented when a bug fix is made. The version number is also split into a
-// pre-release version and a stable version. The pre-release version is
-// incremented when a pre-release version is made. The pre-release version is
-// only used for pre-release versions. The stable version is only used for stable
-// versions. The pre-release version is only used when the version is not a
-// stable version.
+// Version is a version number following Semantic Versioning 2.0.0. The version
+// number is split into three parts: the major version, the minor version, and
+// the patch version. The major version is incremented when a backwards
+// incompatible change is made. The minor version is incremented when a
+// backwards compatible change is made. The patch version is incremented when a
+// bug fix is made. A version may also have pre-release identifiers, which mark
+// the version as unstable, and build metadata, which is ignored when
+// determining precedence.
 type Version struct {
 	major int
 	minor int
 	patch int
 
-	// PreRelease is the pre-release version. If this is set, the version is not
-	// considered to be a stable release.
-	preReleaseName string
+	// preRelease is the list of dot-separated pre-release identifiers. If this
+	// is not empty, the version is not considered to be a stable release.
+	preRelease []string
 
-	// preReleaseNumber is the pre-release version number. This is only used when
-	// the version is not a stable version.
-	// If the pre-release number is less than 0, the pre-release number is not
-	// used.
-	preReleaseNumber int
+	// build is the list of dot-separated build metadata identifiers.
+	build []string
 }
 
 // New creates a new version.
-// If the version is stable, the pre-release name should be empty and the
-// pre-release number should -1.
+// If the version is stable, the pre-release identifiers should be empty. Build
+// metadata identifiers can be empty.
 func New(
 	major int, minor int, patch int,
-	preReleaseName string, preReleaseNumber int) (Version, error) {
+	preRelease []string, build []string) (Version, error) {
 	// The major, minor, and patch versions must be greater than or equal to 0.
 	if major < 0 || minor < 0 || patch < 0 {
 		return Version{},
 			errors.New("major, minor, and patch versions must be greater than or equal to 0")
 	}
 
-	// The patch version must be 0 if the pre-release name is not empty.
-	if patch != 0 && preReleaseName != "" {
-		return Version{},
-			errors.New("patch version must be 0 if the pre-release name is not empty")
+	for _, identifier := range preRelease {
+		if !isValidIdentifier(identifier) {
+			return Version{},
+				errors.New("invalid pre-release identifier: " + identifier)
+		}
+
+		// Numeric pre-release identifiers must not include leading zeroes.
+		if isNumericIdentifier(identifier) && len(identifier) > 1 && identifier[0] == '0' {
+			return Version{},
+				errors.New("numeric pre-release identifier must not include leading zeroes: " + identifier)
+		}
 	}
 
-	// The pre-release name must not be empty if the pre-release number is not
-	// less than 0.
-	if preReleaseName == "" && preReleaseNumber >= 0 {
-		return Version{},
-			errors.New("pre-release name must not be empty if the pre-release number is not less than 0")
+	for _, identifier := range build {
+		if !isValidIdentifier(identifier) {
+			return Version{},
+				errors.New("invalid build metadata identifier: " + identifier)
+		}
+	}
+
+	// Copy the identifiers so that the version is immutable.
+	var preReleaseCopy []string
+	if len(preRelease) > 0 {
+		preReleaseCopy = append([]string{}, preRelease...)
 	}
 
-	// The pre-release number should be set to -1 if the pre-release name is
-	// empty or if the pre-release number is less than 0 but not -1
-	if preReleaseName == "" || preReleaseNumber < 0 {
-		preReleaseNumber = -1
+	var buildCopy []string
+	if len(build) > 0 {
+		buildCopy = append([]string{}, build...)
 	}
 
 	return Version{
-		major:            major,
-		minor:            minor,
-		patch:            patch,
-		preReleaseName:   preReleaseName,
-		preReleaseNumber: preReleaseNumber,
+		major:      major,
+		minor:      minor,
+		patch:      patch,
+		preRelease: preReleaseCopy,
+		build:      buildCopy,
 	}, nil
 }
 
-// NewFromString creates a new version from a version string.
+// NewFromString creates a new version from a version string. Version strings
+// with leading zeroes, which were accepted by older versions of Lip, are
+// normalized, e.g. 1.02.0-beta.01 is read as 1.2.0-beta.1.
 func NewFromString(versionString string) (Version, error) {
-	if !IsValidVersionString(versionString) {
-		return Version{}, errors.New("invalid version string: " + versionString)
-	}
+	version, _, err := NewFromLegacyString(versionString)
+	return version, err
+}
 
-	var major, minor, patch int
-	preReleaseName := ""
-	preReleaseNumber := -1
+// NewFromLegacyString is like NewFromString, and also returns true if the
+// version string has leading zeroes and is normalized.
+func NewFromLegacyString(versionString string) (Version, bool, error) {
+	isNormalized := false
+	if !IsValidVersionString(versionString) {
+		if !isLegacyVersionString(versionString) {
+			return Version{}, false, errors.New("invalid version string: " + versionString)
+		}
 
-	// Split versionString into parts using the "-" and "." character.
-	versionStringParts := strings.FieldsFunc(versionString, func(r rune) bool {
-		return r == '-' || r == '.'
-	})
+		versionString = normalizeLegacyVersionString(versionString)
+		isNormalized = true
+	}
 
-	// Parse major, minor, and patch versions.
-	fmt.Sscanf(versionStringParts[0], "%d", &major)
-	fmt.Sscanf(versionStringParts[1], "%d", &minor)
-	fmt.Sscanf(versionStringParts[2], "%d", &patch)
+	var preRelease, build []string
 
-	// Parse pre-release name and pre-release number.
-	if len(versionStringParts) > 3 {
-		preReleaseName = versionStringParts[3]
+	// Split off build metadata.
+	if index := strings.Index(versionString, "+"); index >= 0 {
+		build = strings.Split(versionString[index+1:], ".")
+		versionString = versionString[:index]
 	}
 
-	if len(versionStringParts) > 4 {
-		fmt.Sscanf(versionStringParts[4], "%d", &preReleaseNumber)
+	// Split off pre-release identifiers. Identifiers may contain hyphens, so
+	// only the first hyphen is a separator.
+	if index := strings.Index(versionString, "-"); index >= 0 {
+		preRelease = strings.Split(versionString[index+1:], ".")
+		versionString = versionString[:index]
 	}
 
-	return New(major, minor, patch, preReleaseName, preReleaseNumber)
+	// Parse major, minor, and patch versions.
+	versionStringParts := strings.Split(versionString, ".")
+	major, _ := strconv.Atoi(versionStringParts[0])
+	minor, _ := strconv.Atoi(versionStringParts[1])
+	patch, _ := strconv.Atoi(versionStringParts[2])
+
+	version, err := New(major, minor, patch, preRelease, build)
+	return version, isNormalized, err
 }
 
 // Major returns the major version.
@@ -120,35 +142,63 @@ func (v Version) Patch() int {
 	return v.patch
 }
 
-// PreReleaseName returns the pre-release name.
-func (v Version) PreReleaseName() string {
-	return v.preReleaseName
+// PreRelease returns the pre-release identifiers.
+func (v Version) PreRelease() []string {
+	return append([]string{}, v.preRelease...)
 }
 
-// PreReleaseNumber returns the pre-release number.
-func (v Version) PreReleaseNumber() int {
-	return v.preReleaseNumber
+// Build returns the build metadata identifiers.
+func (v Version) Build() []string {
+	return append([]string{}, v.build...)
 }
 
+// IsStable returns true if the version is not a pre-release.
 func (v Version) IsStable() bool {
-	return v.preReleaseName == ""
+	return len(v.preRelease) == 0
 }
 
 // String returns the string representation of the version.
 func (v Version) String() string {
-	if v.preReleaseName == "" {
-		return fmt.Sprintf("%d.%d.%d", v.major, v.minor, v.patch)
+	versionString := fmt.Sprintf("%d.%d.%d", v.major, v.minor, v.patch)
+
+	if len(v.preRelease) > 0 {
+		versionString += "-" + strings.Join(v.preRelease, ".")
+	}
+
+	if len(v.build) > 0 {
+		versionString += "+" + strings.Join(v.build, ".")
+	}
+
+	return versionString
+}
+
+// isValidIdentifier returns true if the identifier is a non-empty string
+// comprising only ASCII alphanumerics and hyphens.
+func isValidIdentifier(identifier string) bool {
+	if identifier == "" {
+		return false
+	}
+
+	for _, r := range identifier {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isNumericIdentifier returns true if the identifier comprises only digits.
+func isNumericIdentifier(identifier string) bool {
+	if identifier == "" {
+		return false
 	}
 
-	if v.preReleaseNumber < 0 {
-		return fmt.Sprintf("%d.%d.%d-%s", v.major, v.minor, v.patch, v.preReleaseName)
+	for _, r := range identifier {
+		if r < '0' || r > '9' {
+			return false
+		}
 	}
 
-	return fmt.Sprintf(
-		"%d.%d.%d-%s.%d",
-		v.major,
-		v.minor,
-		v.patch,
-		v.preReleaseName,
-		v.preReleaseNumber)
+	return true
 }
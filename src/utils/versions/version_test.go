@@ -14,6 +14,19 @@ func TestNewFromString(t *testing.T) {
 		{"3.2.0-beta.1", "3.2.0-beta.1"},
 		{"0.0.1", "0.0.1"},
 		{"0.0.0", "0.0.0"},
+		{"1.2.3-beta", "1.2.3-beta"},
+		{"1.0.0-alpha.beta.1", "1.0.0-alpha.beta.1"},
+		{"1.0.0-x-y-z.--", "1.0.0-x-y-z.--"},
+		{"1.0.0-RC.1", "1.0.0-RC.1"},
+		{"1.0.0+build.5", "1.0.0+build.5"},
+		{"1.0.0-beta.11+exp.sha.5114f85", "1.0.0-beta.11+exp.sha.5114f85"},
+		{"1.0.0+001", "1.0.0+001"},
+
+		// Leading zeroes accepted by older versions of Lip.
+		{"01.0.0", "1.0.0"},
+		{"1.00.0", "1.0.0"},
+		{"1.0.0-beta.01", "1.0.0-beta.1"},
+		{"01.02.00-rc.000", "1.2.0-rc.0"},
 	}
 
 	for index, test := range testList {
@@ -25,5 +38,85 @@ func TestNewFromString(t *testing.T) {
 		if version.String() != test.output {
 			t.Errorf("wrong output at test %d: %s != %s", index, version.String(), test.output)
 		}
+
+		// Only version strings with leading zeroes are normalized.
+		_, isNormalized, err := NewFromLegacyString(test.input)
+		if err != nil || isNormalized != (test.input != test.output) {
+			t.Errorf("wrong normalization at test %d: %v %v", index, isNormalized, err)
+		}
+	}
+}
+
+func TestNewFromStringInvalid(t *testing.T) {
+	testList := []string{
+		"",
+		"1",
+		"1.0",
+		"1.0.0.0",
+		"01.0.0+build",
+		"1.0.0-",
+		"1.0.0-beta.01.1",
+		"1.0.0-beta..1",
+		"1.0.0+",
+		"1.0.0+build..1",
+		"1.0.0-beta_1",
+		"v1.0.0",
+	}
+
+	for index, test := range testList {
+		_, err := NewFromString(test)
+		if err == nil {
+			t.Errorf("no error at test %d: %s", index, test)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// Versions in ascending order of precedence.
+	orderedList := []string{
+		"1.0.0-0",
+		"1.0.0-1",
+		"1.0.0-2",
+		"1.0.0-10",
+		"1.0.0-alph",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1-alpha",
+		"1.0.1",
+		"2.0.0",
+		"2.1.0",
+		"2.1.1",
+	}
+
+	for i := range orderedList {
+		for j := range orderedList {
+			v1, _ := NewFromString(orderedList[i])
+			v2, _ := NewFromString(orderedList[j])
+
+			expected := 0
+			if i < j {
+				expected = -1
+			} else if i > j {
+				expected = 1
+			}
+
+			if Compare(v1, v2) != expected {
+				t.Errorf("wrong output comparing %s and %s: %d != %d",
+					orderedList[i], orderedList[j], Compare(v1, v2), expected)
+			}
+		}
+	}
+
+	// Build metadata is ignored.
+	v1, _ := NewFromString("1.0.0+build.1")
+	v2, _ := NewFromString("1.0.0+build.2")
+	if !Equal(v1, v2) {
+		t.Errorf("build metadata should be ignored in comparison")
 	}
 }
@@ -1,22 +1,207 @@
 package versionmatch
 
 import (
+	"errors"
 	"regexp"
 	"strings"
+
+	"github.com/liteldev/lip/utils/versions"
 )
 
+// versionMatchPattern is the pattern of a version match string without anchors.
+const versionMatchPattern = `(\*|[xX]|` +
+	// Full versions with an optional operator. Leading zeroes accepted by older
+	// versions of Lip are normalized by versions.NewFromString.
+	`(>=|<=|>|<|!|=|\^|~)?\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?|` +
+	// Partial versions with an optional operator except "!".
+	`(>=|<=|>|<|=|\^|~)?(0|[1-9]\d*)(\.(0|[1-9]\d*))?|` +
+	// Partial versions with wildcards, of at most three components. Leading
+	// zeroes are accepted like full versions.
+	`(=|\^|~)?\d+((\.[xX*]){1,2}|\.\d+\.[xX*]))`
+
 // IsValidVersionMatchString returns true if the version match string is valid.
 func IsValidVersionMatchString(versionMatchString string) bool {
-	reg := regexp.MustCompile(`^(>|>=|<|<=|!)?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*|x)$`)
-	if !reg.MatchString(versionMatchString) {
-		return false
+	reg := regexp.MustCompile(`^` + versionMatchPattern + `$`)
+
+	return reg.MatchString(versionMatchString)
+}
+
+// IsValidRangeString returns true if the version range string is valid.
+func IsValidRangeString(versionRangeString string) bool {
+	_, err := NewRangeFromString(versionRangeString)
+
+	return err == nil
+}
+
+// NewRangeFromString parses a version range string into a version range.
+// Alternatives are separated by "||" and calculated with OR. In each
+// alternative, version matches are separated by spaces and calculated with AND.
+// An alternative can also be a hyphen range like "1.0.0 - 2.0.0", which means
+// ">=1.0.0 <=2.0.0". For example, ">=1.0.0 <1.2.0 || ^2.1.0 || 3.0.0 - 3.2".
+func NewRangeFromString(versionRangeString string) ([][]VersionMatch, error) {
+	versionRange := make([][]VersionMatch, 0)
+
+	for _, alternative := range strings.Split(versionRangeString, "||") {
+		fields := strings.Fields(alternative)
+		if len(fields) == 0 {
+			return nil, errors.New("invalid version range string: " + versionRangeString)
+		}
+
+		// Hyphen range.
+		if len(fields) == 3 && fields[1] == "-" {
+			innerVersionRange, err := newHyphenRange(fields[0], fields[2])
+			if err != nil {
+				return nil, errors.New("invalid version range string: " + versionRangeString + ": " + err.Error())
+			}
+
+			versionRange = append(versionRange, innerVersionRange)
+			continue
+		}
+
+		innerVersionRange := make([]VersionMatch, 0, len(fields))
+		for _, field := range fields {
+			versionMatch, err := NewFromString(field)
+			if err != nil {
+				return nil, errors.New("invalid version range string: " + versionRangeString + ": " + err.Error())
+			}
+
+			innerVersionRange = append(innerVersionRange, versionMatch)
+		}
+
+		versionRange = append(versionRange, innerVersionRange)
+	}
+
+	return versionRange, nil
+}
+
+// MatchRange returns true if the version matches the version range. All
+// version matches in the outer list are calculated with OR, and version
+// matches in the inner lists are calculated with AND.
+func MatchRange(version versions.Version, versionRange [][]VersionMatch) bool {
+	for _, innerVersionRange := range versionRange {
+		isAllMatched := true
+		for _, versionMatch := range innerVersionRange {
+			if !versionMatch.Match(version) {
+				isAllMatched = false
+				break
+			}
+		}
+
+		if isAllMatched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RangeString returns the canonical string representation of a version range,
+// e.g. ">=1.0.0 <=1.1.0 || 2.0.x". It can be parsed by NewRangeFromString.
+func RangeString(versionRange [][]VersionMatch) string {
+	alternativeList := make([]string, 0, len(versionRange))
+	for _, innerVersionRange := range versionRange {
+		versionMatchList := make([]string, 0, len(innerVersionRange))
+		for _, versionMatch := range innerVersionRange {
+			versionMatchList = append(versionMatchList, versionMatch.String())
+		}
+
+		alternativeList = append(alternativeList, strings.Join(versionMatchList, " "))
+	}
+
+	return strings.Join(alternativeList, " || ")
+}
+
+// ParseRangeMap parses a map from tooth paths to version ranges decoded from
+// JSON, e.g. the dependencies field of tooth.json. A version range is either a
+// string like ">=1.0.0 <2.0.0 || ^3.1", or a list of lists of version match
+// strings, where the inner lists are ANDed and the outer list is ORed.
+func ParseRangeMap(versionRangeMap map[string]interface{}) (map[string]([][]VersionMatch), error) {
+	result := make(map[string]([][]VersionMatch))
+
+	for toothPath, versionMatchOuterList := range versionRangeMap {
+		if versionRangeString, ok := versionMatchOuterList.(string); ok {
+			versionRange, err := NewRangeFromString(versionRangeString)
+			if err != nil {
+				return nil, err
+			}
+
+			result[toothPath] = versionRange
+			continue
+		}
+
+		result[toothPath] = make([][]VersionMatch, len(versionMatchOuterList.([]interface{})))
+		for i, versionMatchInnerList := range versionMatchOuterList.([]interface{}) {
+			result[toothPath][i] = make([]VersionMatch, len(versionMatchInnerList.([]interface{})))
+			for j, versionMatch := range versionMatchInnerList.([]interface{}) {
+				versionMatch, err := NewFromString(versionMatch.(string))
+				if err != nil {
+					return nil, err
+				}
+
+				result[toothPath][i][j] = versionMatch
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// EncodeRangeMap encodes a map from tooth paths to version ranges into lists
+// of lists of version match strings, which can be parsed by ParseRangeMap.
+func EncodeRangeMap(versionRangeMap map[string]([][]VersionMatch)) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	for toothPath, versionMatchOuterList := range versionRangeMap {
+		outerList := make([]interface{}, len(versionMatchOuterList))
+		for i, versionMatchInnerList := range versionMatchOuterList {
+			innerList := make([]interface{}, len(versionMatchInnerList))
+			for j, versionMatch := range versionMatchInnerList {
+				innerList[j] = versionMatch.String()
+			}
+			outerList[i] = innerList
+		}
+		result[toothPath] = outerList
+	}
+
+	return result
+}
+
+// IntersectRanges returns a version range matching versions matched by both
+// version ranges.
+func IntersectRanges(versionRange1 [][]VersionMatch, versionRange2 [][]VersionMatch) [][]VersionMatch {
+	result := make([][]VersionMatch, 0, len(versionRange1)*len(versionRange2))
+
+	// (A or B) and (C or D) = (A and C) or (A and D) or (B and C) or (B and D)
+	for _, innerVersionRange1 := range versionRange1 {
+		for _, innerVersionRange2 := range versionRange2 {
+			innerVersionRange := make([]VersionMatch, 0, len(innerVersionRange1)+len(innerVersionRange2))
+			innerVersionRange = append(innerVersionRange, innerVersionRange1...)
+			innerVersionRange = append(innerVersionRange, innerVersionRange2...)
+			result = append(result, innerVersionRange)
+		}
+	}
+
+	return result
+}
+
+// newHyphenRange creates the version matches of a hyphen range. Partial
+// versions are allowed, e.g. "1.2 - 2" means ">=1.2.0 <3.0.0".
+func newHyphenRange(lowerString string, upperString string) ([]VersionMatch, error) {
+	for _, bound := range []string{lowerString, upperString} {
+		if strings.ContainsAny(bound, "<>=!^~xX*") || !IsValidVersionMatchString(bound) {
+			return nil, errors.New("invalid bound of hyphen range: " + bound)
+		}
+	}
+
+	lower, err := NewFromString(">=" + lowerString)
+	if err != nil {
+		return nil, err
 	}
 
-	// If there is a prefix, the last character must be a digit.
-	if strings.HasSuffix(versionMatchString, "x") &&
-		strings.ContainsAny(versionMatchString, "<>=!") {
-		return false
+	upper, err := NewFromString("<=" + upperString)
+	if err != nil {
+		return nil, err
 	}
 
-	return true
+	return []VersionMatch{lower, upper}, nil
 }
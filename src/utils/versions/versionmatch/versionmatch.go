@@ -3,6 +3,8 @@ package versionmatch
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/liteldev/lip/utils/versions"
@@ -19,6 +21,10 @@ const (
 	LessThanMatchType
 	LessThanOrEqualMatchType
 	CompatibleMatchType
+	CaretMatchType
+	TildeMatchType
+	MajorCompatibleMatchType
+	AnyMatchType
 )
 
 // VersionMatch is a version match. The version match is used to match a version
@@ -32,7 +38,7 @@ type VersionMatch struct {
 // New creates a new version match.
 func New(version versions.Version, matchType MatchType) (VersionMatch, error) {
 	// The match type must be valid.
-	if matchType < EqualMatchType || matchType > CompatibleMatchType {
+	if matchType < EqualMatchType || matchType > AnyMatchType {
 		return VersionMatch{}, errors.New("invalid match type")
 	}
 
@@ -43,42 +49,123 @@ func New(version versions.Version, matchType MatchType) (VersionMatch, error) {
 }
 
 // NewFromString creates a new version match from a string.
+// Besides full versions with an optional operator (=, !, <, <=, > or >=), the
+// following forms are accepted:
+//
+//   - ^1.2.3, ^1.2, ^1: compatible with the version (npm/cargo caret).
+//   - ~1.2.3, ~1.2, ~1: approximately the version (npm/cargo tilde).
+//   - 1.2.x, 1.2.*, 1.2: any patch version of 1.2.
+//   - 1.x, 1.x.x, 1.*, 1: any minor and patch version of 1.
+//   - *, x: any version.
+//   - >=1.2, <1.2, >1.2, <=1.2: partial versions with an operator.
+//
+// Forms with the same meaning are converted to the same canonical form, which
+// is returned by String().
 func NewFromString(versionMatchString string) (VersionMatch, error) {
 	if !IsValidVersionMatchString(versionMatchString) {
-		return VersionMatch{}, errors.New("invalid version match string")
+		return VersionMatch{}, errors.New("invalid version match string: " + versionMatchString)
 	}
 
-	// Get the match type.
-	var matchType MatchType
-	if strings.HasPrefix(versionMatchString, "!") {
-		matchType = InequalMatchType
-		versionMatchString = versionMatchString[1:]
-	} else if strings.HasPrefix(versionMatchString, ">=") {
-		matchType = GreaterThanOrEqualMatchType
-		versionMatchString = versionMatchString[2:]
-	} else if strings.HasPrefix(versionMatchString, ">") {
-		matchType = GreaterThanMatchType
-		versionMatchString = versionMatchString[1:]
-	} else if strings.HasPrefix(versionMatchString, "<=") {
-		matchType = LessThanOrEqualMatchType
-		versionMatchString = versionMatchString[2:]
-	} else if strings.HasPrefix(versionMatchString, "<") {
-		matchType = LessThanMatchType
-		versionMatchString = versionMatchString[1:]
-	} else if strings.HasSuffix(versionMatchString, "x") {
-		matchType = CompatibleMatchType
-		versionMatchString = versionMatchString[:len(versionMatchString)-1] + "0"
-	} else {
-		matchType = EqualMatchType
+	// Any version.
+	if versionMatchString == "*" || versionMatchString == "x" || versionMatchString == "X" {
+		return New(versions.Version{}, AnyMatchType)
 	}
 
-	// Create the version.
-	version, err := versions.NewFromString(versionMatchString)
+	// Get the operator.
+	operator := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "!", "=", "^", "~"} {
+		if strings.HasPrefix(versionMatchString, prefix) {
+			operator = prefix
+			versionMatchString = strings.TrimPrefix(versionMatchString, prefix)
+			break
+		}
+	}
+
+	// Full versions are parsed directly. Pre-release identifiers and build
+	// metadata may contain letters like "x", so only the core part is checked.
+	coreVersionString := strings.FieldsFunc(versionMatchString, func(r rune) bool {
+		return r == '-' || r == '+'
+	})[0]
+	if strings.Count(coreVersionString, ".") >= 2 &&
+		!strings.ContainsAny(coreVersionString, "xX*") {
+		version, err := versions.NewFromString(versionMatchString)
+		if err != nil {
+			return VersionMatch{}, err
+		}
+
+		switch operator {
+		case "", "=":
+			return New(version, EqualMatchType)
+		case "!":
+			return New(version, InequalMatchType)
+		case ">":
+			return New(version, GreaterThanMatchType)
+		case ">=":
+			return New(version, GreaterThanOrEqualMatchType)
+		case "<":
+			return New(version, LessThanMatchType)
+		case "<=":
+			return New(version, LessThanOrEqualMatchType)
+		case "^":
+			// ^0.0 is handled as a partial version, but ^0.0.0 is not.
+			return New(version, CaretMatchType)
+		case "~":
+			return New(version, TildeMatchType)
+		}
+	}
+
+	// Parse the partial version. Wildcards are regarded as missing parts.
+	major, minor, precision, err := parsePartialVersion(versionMatchString)
 	if err != nil {
 		return VersionMatch{}, err
 	}
 
-	return New(version, matchType)
+	switch operator {
+	case "", "=":
+		if precision == 1 {
+			return newFromNumbers(major, 0, 0, MajorCompatibleMatchType)
+		}
+		return newFromNumbers(major, minor, 0, CompatibleMatchType)
+
+	case ">=":
+		return newFromNumbers(major, minor, 0, GreaterThanOrEqualMatchType)
+
+	case "<":
+		return newFromNumbers(major, minor, 0, LessThanMatchType)
+
+	case ">":
+		// >1.2 means >=1.3.0 and >1 means >=2.0.0.
+		if precision == 1 {
+			return newFromNumbers(major+1, 0, 0, GreaterThanOrEqualMatchType)
+		}
+		return newFromNumbers(major, minor+1, 0, GreaterThanOrEqualMatchType)
+
+	case "<=":
+		// <=1.2 means <1.3.0 and <=1 means <2.0.0.
+		if precision == 1 {
+			return newFromNumbers(major+1, 0, 0, LessThanMatchType)
+		}
+		return newFromNumbers(major, minor+1, 0, LessThanMatchType)
+
+	case "^":
+		// ^1 and ^0 mean 1.x and 0.x. ^0.0 means 0.0.x.
+		if precision == 1 {
+			return newFromNumbers(major, 0, 0, MajorCompatibleMatchType)
+		}
+		if major == 0 && minor == 0 {
+			return newFromNumbers(0, 0, 0, CompatibleMatchType)
+		}
+		return newFromNumbers(major, minor, 0, CaretMatchType)
+
+	case "~":
+		// ~1 means 1.x.
+		if precision == 1 {
+			return newFromNumbers(major, 0, 0, MajorCompatibleMatchType)
+		}
+		return newFromNumbers(major, minor, 0, TildeMatchType)
+	}
+
+	return VersionMatch{}, errors.New("invalid version match string: " + versionMatchString)
 }
 
 // Match matches the version to the version match.
@@ -98,6 +185,18 @@ func (vm VersionMatch) Match(version versions.Version) bool {
 		return versions.LessThanOrEqual(version, vm.version)
 	case CompatibleMatchType:
 		return versions.Compatible(version, vm.version)
+	case CaretMatchType:
+		return vm.isPreReleaseAllowed(version) &&
+			versions.GreaterThanOrEqual(version, vm.version) &&
+			versions.LessThan(version, vm.upperBound())
+	case TildeMatchType:
+		return vm.isPreReleaseAllowed(version) &&
+			versions.GreaterThanOrEqual(version, vm.version) &&
+			versions.LessThan(version, vm.upperBound())
+	case MajorCompatibleMatchType:
+		return version.IsStable() && version.Major() == vm.version.Major()
+	case AnyMatchType:
+		return version.IsStable()
 	}
 
 	// This should never happen.
@@ -121,8 +220,92 @@ func (vm VersionMatch) String() string {
 		return "<=" + vm.version.String()
 	case CompatibleMatchType:
 		return strings.TrimSuffix(vm.version.String(), "0") + "x"
+	case CaretMatchType:
+		return "^" + vm.version.String()
+	case TildeMatchType:
+		return "~" + vm.version.String()
+	case MajorCompatibleMatchType:
+		return fmt.Sprintf("%d.x", vm.version.Major())
+	case AnyMatchType:
+		return "*"
 	}
 
 	// This should never happen.
 	return ""
 }
+
+// isPreReleaseAllowed returns true if the version is stable or the version is
+// a pre-release of the same major, minor and patch version as the version
+// match. This prevents ranges from unexpectedly matching pre-releases.
+func (vm VersionMatch) isPreReleaseAllowed(version versions.Version) bool {
+	if version.IsStable() {
+		return true
+	}
+
+	return !vm.version.IsStable() &&
+		version.Major() == vm.version.Major() &&
+		version.Minor() == vm.version.Minor() &&
+		version.Patch() == vm.version.Patch()
+}
+
+// upperBound returns the exclusive upper bound of caret and tilde version matches.
+func (vm VersionMatch) upperBound() versions.Version {
+	var upperBound versions.Version
+
+	switch {
+	case vm.matchType == TildeMatchType:
+		upperBound, _ = versions.New(vm.version.Major(), vm.version.Minor()+1, 0, nil, nil)
+	case vm.version.Major() > 0:
+		upperBound, _ = versions.New(vm.version.Major()+1, 0, 0, nil, nil)
+	case vm.version.Minor() > 0:
+		upperBound, _ = versions.New(0, vm.version.Minor()+1, 0, nil, nil)
+	default:
+		upperBound, _ = versions.New(0, 0, vm.version.Patch()+1, nil, nil)
+	}
+
+	return upperBound
+}
+
+// newFromNumbers creates a new version match of a stable version.
+func newFromNumbers(major int, minor int, patch int, matchType MatchType) (VersionMatch, error) {
+	version, err := versions.New(major, minor, patch, nil, nil)
+	if err != nil {
+		return VersionMatch{}, err
+	}
+
+	return New(version, matchType)
+}
+
+// parsePartialVersion parses a partial version like 1, 1.2, 1.x, 1.2.x or 1.x.x.
+// precision is the number of numeric parts, i.e. 1 for 1.x and 2 for 1.2.x.
+func parsePartialVersion(partialVersionString string) (major int, minor int, precision int, err error) {
+	parts := strings.Split(partialVersionString, ".")
+
+	numbers := make([]int, 0, 2)
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			// All following parts must be wildcards as well.
+			for _, followingPart := range parts[i:] {
+				if followingPart != "x" && followingPart != "X" && followingPart != "*" {
+					return 0, 0, 0, errors.New("invalid partial version: " + partialVersionString)
+				}
+			}
+			break
+		}
+
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, 0, 0, errors.New("invalid partial version: " + partialVersionString)
+		}
+		numbers = append(numbers, number)
+	}
+
+	switch len(numbers) {
+	case 1:
+		return numbers[0], 0, 1, nil
+	case 2:
+		return numbers[0], numbers[1], 2, nil
+	}
+
+	return 0, 0, 0, errors.New("invalid partial version: " + partialVersionString)
+}
@@ -36,7 +36,7 @@ func TestNew(t *testing.T) {
 			t.Fatalf("error at test %d: %s", index, err.Error())
 		}
 
-		if versionMatch.version != version {
+		if versionMatch.version.String() != version.String() {
 			t.Errorf("wrong version at test %d", index)
 		}
 
@@ -62,6 +62,31 @@ func TestNewFromString(t *testing.T) {
 		{"<=0.0.1", "<=0.0.1"},
 		{"!3.2.0", "!3.2.0"},
 		{"3.2.x", "3.2.x"},
+		{"=1.0.0", "1.0.0"},
+		{"^1.2.3", "^1.2.3"},
+		{"^1.2", "^1.2.0"},
+		{"^1", "1.x"},
+		{"^0.0", "0.0.x"},
+		{"~1.2.3", "~1.2.3"},
+		{"~1.2", "~1.2.0"},
+		{"~1", "1.x"},
+		{"1.2", "1.2.x"},
+		{"1.2.*", "1.2.x"},
+		{"1", "1.x"},
+		{"1.x", "1.x"},
+		{"1.x.x", "1.x"},
+		{"1.*", "1.x"},
+		{"*", "*"},
+		{"x", "*"},
+		{">=1.2", ">=1.2.0"},
+		{">1.2", ">=1.3.0"},
+		{"<1.2", "<1.2.0"},
+		{"<=1.2", "<1.3.0"},
+		{">1", ">=2.0.0"},
+
+		// Leading zeroes accepted by older versions of Lip.
+		{">=01.0.0", ">=1.0.0"},
+		{"1.02.x", "1.2.x"},
 	}
 
 	for index, test := range testList {
@@ -75,3 +100,228 @@ func TestNewFromString(t *testing.T) {
 		}
 	}
 }
+
+func TestMatchRange(t *testing.T) {
+	versionMatch0, _ := NewFromString(">=1.0.0")
+	versionMatch1, _ := NewFromString("<=1.1.0")
+	versionMatch2, _ := NewFromString("2.0.x")
+
+	versionRange := [][]VersionMatch{
+		{versionMatch0, versionMatch1},
+		{versionMatch2},
+	}
+
+	testList := []struct {
+		input  string
+		output bool
+	}{
+		{"0.9.0", false},
+		{"1.0.0", true},
+		{"1.0.6", true},
+		{"1.1.0", true},
+		{"1.2.0", false},
+		{"2.0.9", true},
+		{"2.1.0", false},
+	}
+
+	for index, test := range testList {
+		version, err := versions.NewFromString(test.input)
+		if err != nil {
+			t.Fatalf("error at test %d: %s", index, err.Error())
+		}
+
+		if MatchRange(version, versionRange) != test.output {
+			t.Errorf("wrong output at test %d: %t != %t", index, !test.output, test.output)
+		}
+	}
+
+	if RangeString(versionRange) != ">=1.0.0 <=1.1.0 || 2.0.x" {
+		t.Errorf("wrong range string: %s", RangeString(versionRange))
+	}
+}
+
+func TestNewFromStringInvalid(t *testing.T) {
+	testList := []string{
+		"",
+		"01.2",
+		"1.0.0.0",
+		"!1.2",
+		">=1.x",
+		"1.x.2",
+		"1.2.x.x",
+		"1.x.x.x",
+		"^",
+		"a.b.c",
+	}
+
+	for index, test := range testList {
+		_, err := NewFromString(test)
+		if err == nil {
+			t.Errorf("no error at test %d: %s", index, test)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	testList := []struct {
+		versionMatch string
+		version      string
+		output       bool
+	}{
+		{"^1.2.3", "1.2.3", true},
+		{"^1.2.3", "1.9.0", true},
+		{"^1.2.3", "1.2.2", false},
+		{"^1.2.3", "2.0.0", false},
+		{"^1.2.3", "2.0.0-beta", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.3", true},
+		{"^0.0.3", "0.0.4", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"^1.2.0-beta", "1.2.0-beta.1", true},
+		{"^1.2.0", "1.3.0-beta", false},
+		{"1.x", "1.9.9", true},
+		{"1.x", "2.0.0", false},
+		{"*", "3.2.1", true},
+		{"*", "3.2.0-beta", false},
+	}
+
+	for index, test := range testList {
+		versionMatch, err := NewFromString(test.versionMatch)
+		if err != nil {
+			t.Fatalf("error at test %d: %s", index, err.Error())
+		}
+
+		version, err := versions.NewFromString(test.version)
+		if err != nil {
+			t.Fatalf("error at test %d: %s", index, err.Error())
+		}
+
+		if versionMatch.Match(version) != test.output {
+			t.Errorf("wrong output at test %d: %s %s", index, test.versionMatch, test.version)
+		}
+	}
+}
+
+func TestNewRangeFromString(t *testing.T) {
+	testList := []struct {
+		input  string
+		output string
+	}{
+		{"1.0.0", "1.0.0"},
+		{">=1.0.0 <=1.1.0 || 2.0.x", ">=1.0.0 <=1.1.0 || 2.0.x"},
+		{"^1.2.3 || ~2.1", "^1.2.3 || ~2.1.0"},
+		{"1.0.0 - 2.0.0", ">=1.0.0 <=2.0.0"},
+		{"1.2 - 2", ">=1.2.0 <3.0.0"},
+		{"  >=1.0.0   <2.0.0  ||1.x", ">=1.0.0 <2.0.0 || 1.x"},
+		{"*", "*"},
+	}
+
+	for index, test := range testList {
+		versionRange, err := NewRangeFromString(test.input)
+		if err != nil {
+			t.Fatalf("error at test %d: %s", index, err.Error())
+		}
+
+		if RangeString(versionRange) != test.output {
+			t.Errorf("wrong output at test %d: %s != %s", index, RangeString(versionRange), test.output)
+		}
+
+		// The canonical string form should round-trip.
+		roundTripVersionRange, err := NewRangeFromString(RangeString(versionRange))
+		if err != nil {
+			t.Fatalf("error at round-trip test %d: %s", index, err.Error())
+		}
+
+		if RangeString(roundTripVersionRange) != test.output {
+			t.Errorf("wrong round-trip output at test %d: %s != %s", index, RangeString(roundTripVersionRange), test.output)
+		}
+	}
+
+	invalidList := []string{
+		"",
+		"||",
+		"1.0.0 ||",
+		"1.0.0 - ",
+		"^1.0.0 - 2.0.0",
+		"1.0.0 - 2.0.0 - 3.0.0",
+	}
+
+	for index, input := range invalidList {
+		_, err := NewRangeFromString(input)
+		if err == nil {
+			t.Errorf("no error at invalid test %d: %s", index, input)
+		}
+	}
+}
+
+func TestNewFromStringSemVer(t *testing.T) {
+	testList := []struct {
+		input   string
+		version string
+		output  bool
+	}{
+		{"1.0.0-rc.x1", "1.0.0-rc.x1", true},
+		{">=1.0.0-alpha.1", "1.0.0-alpha.beta", true},
+		{">=1.0.0-alpha.beta", "1.0.0-alpha.1", false},
+		{"1.0.0+build.1", "1.0.0+build.2", true},
+		{"^1.2.3-beta.2", "1.2.3-beta.11", true},
+	}
+
+	for index, test := range testList {
+		versionMatch, err := NewFromString(test.input)
+		if err != nil {
+			t.Fatalf("error at test %d: %s", index, err.Error())
+		}
+
+		version, err := versions.NewFromString(test.version)
+		if err != nil {
+			t.Fatalf("error at test %d: %s", index, err.Error())
+		}
+
+		if versionMatch.Match(version) != test.output {
+			t.Errorf("wrong output at test %d: %s %s", index, test.input, test.version)
+		}
+	}
+}
+
+func TestParseRangeMap(t *testing.T) {
+	versionRangeMap := map[string]interface{}{
+		"example.com/a": ">=1.0.0 <2.0.0 || ^3.1",
+		"example.com/b": []interface{}{
+			[]interface{}{">=1.0.0", "<=1.1.0"},
+			[]interface{}{"2.0.x"},
+		},
+	}
+
+	parsedVersionRangeMap, err := ParseRangeMap(versionRangeMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if RangeString(parsedVersionRangeMap["example.com/a"]) != ">=1.0.0 <2.0.0 || ^3.1.0" {
+		t.Errorf("wrong range of example.com/a: %s", RangeString(parsedVersionRangeMap["example.com/a"]))
+	}
+
+	if RangeString(parsedVersionRangeMap["example.com/b"]) != ">=1.0.0 <=1.1.0 || 2.0.x" {
+		t.Errorf("wrong range of example.com/b: %s", RangeString(parsedVersionRangeMap["example.com/b"]))
+	}
+
+	// The encoded form should round-trip.
+	roundTripVersionRangeMap, err := ParseRangeMap(EncodeRangeMap(parsedVersionRangeMap))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for toothPath, versionRange := range parsedVersionRangeMap {
+		if RangeString(roundTripVersionRangeMap[toothPath]) != RangeString(versionRange) {
+			t.Errorf("wrong round-trip range of %s: %s != %s", toothPath,
+				RangeString(roundTripVersionRangeMap[toothPath]), RangeString(versionRange))
+		}
+	}
+
+	if _, err := ParseRangeMap(map[string]interface{}{"example.com/a": ">=x"}); err == nil {
+		t.Errorf("ParseRangeMap() with an invalid range should fail")
+	}
+}
@@ -0,0 +1,163 @@
+// Package workspace deals with workspaces, i.e. directories containing a .lip
+// directory, and their settings.
+package workspace
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/liteldev/lip/localfile"
+)
+
+// Config is the settings of a workspace, stored in .lip/workspace.json.
+type Config struct {
+	// TargetOS and TargetArch are the platform that tooths in the workspace are
+	// installed for. Empty values mean the platform Lip is running on.
+	TargetOS   string
+	TargetArch string
+
+	// Scripts maps names of scripts to shell commands run by lip run.
+	Scripts map[string]string
+
+	// LipVersion is the version of Lip pinned by the workspace. It is fetched
+	// into .lip/tools/lip and run instead of the global Lip. An empty value
+	// means no pin.
+	LipVersion string
+}
+
+// ConfigPath returns the path to the .lip/workspace.json file.
+func ConfigPath() (string, error) {
+	workspaceLipDir, err := localfile.WorkspaceLipDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(workspaceLipDir, "workspace.json"), nil
+}
+
+// LoadConfig loads the settings of the workspace. If .lip/workspace.json does
+// not exist, an empty config is returned.
+func LoadConfig() (Config, error) {
+	configMap, err := readConfigMap()
+	if err != nil {
+		return Config{}, err
+	}
+
+	config := Config{}
+
+	if targetOS, ok := configMap["target_os"]; ok {
+		if err := json.Unmarshal(targetOS, &config.TargetOS); err != nil {
+			return Config{}, errors.New("invalid target_os in workspace.json: " + err.Error())
+		}
+	}
+
+	if targetArch, ok := configMap["target_arch"]; ok {
+		if err := json.Unmarshal(targetArch, &config.TargetArch); err != nil {
+			return Config{}, errors.New("invalid target_arch in workspace.json: " + err.Error())
+		}
+	}
+
+	if lipVersion, ok := configMap["lip_version"]; ok {
+		if err := json.Unmarshal(lipVersion, &config.LipVersion); err != nil {
+			return Config{}, errors.New("invalid lip_version in workspace.json: " + err.Error())
+		}
+	}
+
+	config.Scripts = make(map[string]string)
+	if scripts, ok := configMap["scripts"]; ok {
+		if err := json.Unmarshal(scripts, &config.Scripts); err != nil {
+			return Config{}, errors.New("invalid scripts in workspace.json: " + err.Error())
+		}
+	}
+
+	return config, nil
+}
+
+// SaveConfig saves the settings of the workspace. Keys unknown to Config are
+// kept as they are.
+func SaveConfig(config Config) error {
+	configMap, err := readConfigMap()
+	if err != nil {
+		return err
+	}
+
+	setString(configMap, "target_os", config.TargetOS)
+	setString(configMap, "target_arch", config.TargetArch)
+	setString(configMap, "lip_version", config.LipVersion)
+
+	if len(config.Scripts) == 0 {
+		delete(configMap, "scripts")
+	} else {
+		// Scripts often contain "&&" and ">", which should not be escaped.
+		scriptsBuf := bytes.NewBuffer([]byte{})
+		scriptsEncoder := json.NewEncoder(scriptsBuf)
+		scriptsEncoder.SetEscapeHTML(false)
+
+		err := scriptsEncoder.Encode(config.Scripts)
+		if err != nil {
+			return errors.New("failed to encode scripts: " + err.Error())
+		}
+		configMap["scripts"] = bytes.TrimSpace(scriptsBuf.Bytes())
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	encoder := json.NewEncoder(buf)
+	encoder.SetIndent("", "    ")
+	encoder.SetEscapeHTML(false)
+
+	err = encoder.Encode(configMap)
+	if err != nil {
+		return errors.New("failed to encode workspace.json: " + err.Error())
+	}
+
+	configPath, err := ConfigPath()
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(configPath, buf.Bytes(), 0644)
+	if err != nil {
+		return errors.New("failed to write " + configPath + ": " + err.Error())
+	}
+
+	return nil
+}
+
+// readConfigMap reads .lip/workspace.json as a map of raw values.
+func readConfigMap() (map[string]json.RawMessage, error) {
+	configPath, err := ConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	configMap := make(map[string]json.RawMessage)
+
+	content, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return configMap, nil
+	} else if err != nil {
+		return nil, errors.New("failed to read " + configPath + ": " + err.Error())
+	}
+
+	err = json.Unmarshal(content, &configMap)
+	if err != nil {
+		return nil, errors.New("failed to parse " + configPath + ": " + err.Error())
+	}
+
+	return configMap, nil
+}
+
+// setString sets a string value in the config map, or deletes the key if the
+// value is empty.
+func setString(configMap map[string]json.RawMessage, key string, value string) {
+	if value == "" {
+		delete(configMap, key)
+		return
+	}
+
+	raw, _ := json.Marshal(value)
+	configMap[key] = raw
+}
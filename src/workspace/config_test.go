@@ -0,0 +1,102 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveConfig(t *testing.T) {
+	workspaceDir := t.TempDir()
+	os.MkdirAll(filepath.Join(workspaceDir, ".lip"), 0755)
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	os.Chdir(workspaceDir)
+
+	// A missing file means an empty config.
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.TargetOS != "" || config.TargetArch != "" || len(config.Scripts) != 0 {
+		t.Errorf("LoadConfig() = %+v, want an empty config", config)
+	}
+
+	// Unknown keys are kept.
+	configPath, _ := ConfigPath()
+	os.WriteFile(configPath, []byte(`{"unknown": 1}`), 0644)
+
+	err = SaveConfig(Config{TargetOS: "windows", TargetArch: "amd64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err = LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.TargetOS != "windows" || config.TargetArch != "amd64" {
+		t.Errorf("LoadConfig() = %+v, want windows/amd64", config)
+	}
+
+	content, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(content), `"unknown": 1`) {
+		t.Errorf("unknown key is lost: %s", content)
+	}
+
+	// Empty values are removed.
+	SaveConfig(Config{})
+	content, _ = os.ReadFile(configPath)
+	if strings.Contains(string(content), "target_os") {
+		t.Errorf("target_os is not removed: %s", content)
+	}
+}
+
+func TestConfigScripts(t *testing.T) {
+	workspaceDir := t.TempDir()
+	os.MkdirAll(filepath.Join(workspaceDir, ".lip"), 0755)
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	os.Chdir(workspaceDir)
+
+	configPath, _ := ConfigPath()
+	os.WriteFile(configPath, []byte(`{"scripts": {"start": "./bedrock_server"}}`), 0644)
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Scripts["start"] != "./bedrock_server" {
+		t.Errorf("LoadConfig().Scripts = %v, want start", config.Scripts)
+	}
+
+	// Scripts are kept when other settings change.
+	config.TargetOS = "windows"
+	config.Scripts["backup"] = "tar czf backup.tgz worlds && echo done > backup.log"
+	err = SaveConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(content), "&& echo done > backup.log") {
+		t.Errorf("scripts are not saved as they are: %s", content)
+	}
+
+	config, err = LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Scripts) != 2 {
+		t.Errorf("LoadConfig().Scripts = %v, want 2 scripts", config.Scripts)
+	}
+
+	// Invalid scripts are reported.
+	os.WriteFile(configPath, []byte(`{"scripts": ["start"]}`), 0644)
+	if _, err := LoadConfig(); err == nil {
+		t.Errorf("invalid scripts are accepted")
+	}
+}
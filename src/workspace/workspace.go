@@ -0,0 +1,164 @@
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/liteldev/lip/localfile"
+	"github.com/liteldev/lip/utils/platform"
+)
+
+// Find returns the nearest directory containing a .lip directory, starting
+// from dir and walking up like git does. The home directory is only a workspace
+// if dir is the home directory itself, because ~/.lip is the global data
+// directory of Lip. If no workspace is found, found is false.
+func Find(dir string) (workspaceDir string, found bool, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", false, errors.New("failed to get absolute path of " + dir + ": " + err.Error())
+	}
+
+	homeDir, _ := os.UserHomeDir()
+
+	for currentDir := dir; ; {
+		if currentDir == dir || currentDir != homeDir {
+			fileInfo, err := os.Stat(filepath.Join(currentDir, ".lip"))
+			if err == nil && fileInfo.IsDir() {
+				return currentDir, true, nil
+			}
+		}
+
+		parentDir := filepath.Dir(currentDir)
+		if parentDir == currentDir {
+			return "", false, nil
+		}
+		currentDir = parentDir
+	}
+}
+
+// EnterGlobal changes the working directory to the global workspace in
+// ~/.lip/global, where tools are installed for all workspaces. The platform of
+// the current workspace does not apply to it.
+func EnterGlobal() error {
+	globalDir, err := localfile.GlobalDir()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Join(globalDir, ".lip", "records"), 0755)
+	if err != nil {
+		return errors.New("failed to create the global workspace: " + err.Error())
+	}
+
+	err = os.Chdir(globalDir)
+	if err != nil {
+		return errors.New("failed to enter the global workspace: " + err.Error())
+	}
+
+	platform.SetDefaultTarget("", "")
+
+	return nil
+}
+
+// Register adds the workspace directory to the registry of known workspaces in
+// ~/.lip/workspaces.json.
+func Register(workspaceDir string) error {
+	workspaceList, err := ListRegistered()
+	if err != nil {
+		return err
+	}
+
+	for _, registeredDir := range workspaceList {
+		if registeredDir == workspaceDir {
+			return nil
+		}
+	}
+
+	workspaceList = append(workspaceList, workspaceDir)
+	sort.Strings(workspaceList)
+
+	return writeRegistry(workspaceList)
+}
+
+// Unregister removes the workspace directory from the registry of known
+// workspaces.
+func Unregister(workspaceDir string) error {
+	workspaceList, err := ListRegistered()
+	if err != nil {
+		return err
+	}
+
+	newWorkspaceList := make([]string, 0, len(workspaceList))
+	for _, registeredDir := range workspaceList {
+		if registeredDir != workspaceDir {
+			newWorkspaceList = append(newWorkspaceList, registeredDir)
+		}
+	}
+
+	return writeRegistry(newWorkspaceList)
+}
+
+// ListRegistered returns the directories of all known workspaces. Some of them
+// may no longer exist.
+func ListRegistered() ([]string, error) {
+	registryPath, err := registryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(registryPath)
+	if os.IsNotExist(err) {
+		return make([]string, 0), nil
+	} else if err != nil {
+		return nil, errors.New("failed to read " + registryPath + ": " + err.Error())
+	}
+
+	var registry struct {
+		Workspaces []string `json:"workspaces"`
+	}
+	err = json.Unmarshal(content, &registry)
+	if err != nil {
+		return nil, errors.New("failed to parse " + registryPath + ": " + err.Error())
+	}
+
+	if registry.Workspaces == nil {
+		return make([]string, 0), nil
+	}
+
+	return registry.Workspaces, nil
+}
+
+// registryPath returns the path to the ~/.lip/workspaces.json file.
+func registryPath() (string, error) {
+	homeLipDir, err := localfile.HomeLipDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(homeLipDir, "workspaces.json"), nil
+}
+
+// writeRegistry writes the directories of known workspaces to the registry.
+func writeRegistry(workspaceList []string) error {
+	registryPath, err := registryPath()
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(map[string]interface{}{
+		"workspaces": workspaceList,
+	}, "", "    ")
+	if err != nil {
+		return errors.New("failed to encode workspace registry: " + err.Error())
+	}
+
+	err = os.WriteFile(registryPath, content, 0644)
+	if err != nil {
+		return errors.New("failed to write " + registryPath + ": " + err.Error())
+	}
+
+	return nil
+}
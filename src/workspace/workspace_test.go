@@ -0,0 +1,70 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	rootDir := t.TempDir()
+	homeDir := filepath.Join(rootDir, "home")
+	workspaceDir := filepath.Join(homeDir, "server")
+	subDir := filepath.Join(workspaceDir, "plugins", "example")
+	os.MkdirAll(filepath.Join(homeDir, ".lip"), 0755)
+	os.MkdirAll(filepath.Join(workspaceDir, ".lip"), 0755)
+	os.MkdirAll(subDir, 0755)
+	os.MkdirAll(filepath.Join(homeDir, "other"), 0755)
+	t.Setenv("HOME", homeDir)
+	t.Setenv("USERPROFILE", homeDir)
+
+	testList := []struct {
+		dir          string
+		workspaceDir string
+		found        bool
+	}{
+		{workspaceDir, workspaceDir, true},
+		{subDir, workspaceDir, true},
+		// ~/.lip is the global data directory.
+		{filepath.Join(homeDir, "other"), "", false},
+		{homeDir, homeDir, true},
+	}
+
+	for index, test := range testList {
+		workspaceDir, found, err := Find(test.dir)
+		if err != nil {
+			t.Fatalf("error at test %d: %s", index, err.Error())
+		}
+
+		if workspaceDir != test.workspaceDir || found != test.found {
+			t.Errorf("wrong output at test %d: %s, %v != %s, %v", index, workspaceDir, found, test.workspaceDir, test.found)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	homeDir := t.TempDir()
+	os.MkdirAll(filepath.Join(homeDir, ".lip"), 0755)
+	t.Setenv("HOME", homeDir)
+	t.Setenv("USERPROFILE", homeDir)
+
+	Register("/b")
+	Register("/a")
+	Register("/b")
+	Unregister("/c")
+
+	workspaceList, err := ListRegistered()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workspaceList) != 2 || workspaceList[0] != "/a" || workspaceList[1] != "/b" {
+		t.Errorf("ListRegistered() = %v, want [/a /b]", workspaceList)
+	}
+
+	Unregister("/a")
+
+	workspaceList, _ = ListRegistered()
+	if len(workspaceList) != 1 || workspaceList[0] != "/b" {
+		t.Errorf("ListRegistered() = %v, want [/b]", workspaceList)
+	}
+}